@@ -0,0 +1,34 @@
+// =======================
+// This package is package to store routes for adoption applications
+// each routes would have their own controller which
+// would be imported from the controllers package
+// =======================
+
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/controllers"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/policy"
+)
+
+// define controller
+var Application controllers.ApplicationControllers = *new(controllers.Application)
+
+// Applications router function that would be used by "/applications" endpoint
+func Applications(r chi.Router) {
+	r.Use(middleware.RequireUser)
+
+	// applicant could read and withdraw own application,
+	// it's checked by the controller
+	review := middleware.Authorize(policy.ReviewApplication)
+
+	r.Get("/", Application.GetApplications)
+	r.With(middleware.Authorize(policy.SubmitApplication)).Post("/", Application.SubmitApplication)
+	r.Get("/{id}", Application.GetApplication)
+	r.Post("/{id}/transitions", Application.TransitionApplication)
+	r.With(review).Post("/{id}/notes", Application.AddNote)
+	r.With(review).Post("/{id}/finalize", Application.FinalizeApplication)
+}
@@ -0,0 +1,41 @@
+// =======================
+// This package is package to store routes for authentication
+// each routes would have their own controller which
+// would be imported from the controllers package
+// =======================
+
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/controllers"
+	"github.com/ArkjuniorK/store_app/middleware"
+)
+
+// define controller
+var Authenticator controllers.AuthControllers = *new(controllers.Auth)
+
+// Auth router function that would be used by "/auth" endpoint
+func Auth(r chi.Router) {
+	r.Post("/register", Authenticator.Register)
+	r.Post("/login", Authenticator.Login)
+	r.Post("/token", Authenticator.IssueToken)
+	r.With(middleware.RequireUser).Post("/logout", Authenticator.Logout)
+	r.With(middleware.RequireUser).Get("/me", Authenticator.Me)
+	r.Get("/oidc/login", Authenticator.OIDCLogin)
+	r.Get("/oidc/callback", Authenticator.OIDCCallback)
+	r.With(middleware.RequireUser).Post("/oidc/link", Authenticator.OIDCLink)
+
+	// two-factor of current user, verify is used
+	// by login that is waiting for the code
+	r.Post("/2fa/verify", Authenticator.VerifyTwoFactor)
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RequireUser)
+
+		r.Post("/2fa/enroll", Authenticator.EnrollTwoFactor)
+		r.Post("/2fa/confirm", Authenticator.ConfirmTwoFactor)
+		r.Post("/2fa/recovery-codes", Authenticator.RecoveryCodes)
+		r.Post("/2fa/disable", Authenticator.DisableTwoFactor)
+	})
+}
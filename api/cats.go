@@ -21,6 +21,7 @@ import (
 
 	"github.com/ArkjuniorK/store_app/controllers"
 	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/policy"
 )
 
 // define controller
@@ -32,11 +33,19 @@ var (
 // Cats router function that would be exported to main.go
 // and used by "/cats" endpoint
 func Cats(r chi.Router) {
-	r.Get("/{page}/{limit}", Cat.GetCats)
-	r.Post("/add", Cat.AddCat)
-	r.Get("/{id}", Cat.GetCat)
-	r.Put("/{id}", Cat.UpdateCat)
-	r.Delete("/{id}", Cat.DeleteCat)
-	r.With(middleware.SetImage).Post("/{id}", Cat.UploadImageCat)
-	r.Delete("/{id}/{id_image}", Cat.DeleteImageCat)
+	// reading cat is public, changing it is only allowed
+	// for the role in policy, checked before the body is read
+	read := middleware.Authorize(policy.ReadCat)
+
+	r.With(read).Get("/", Cat.GetCats)
+	r.With(read).Get("/facets", Cat.GetFacets)
+	r.With(read).Get("/{id}", Cat.GetCat)
+
+	r.With(middleware.Authorize(policy.CreateCat)).Post("/add", Cat.AddCat)
+	r.With(middleware.Authorize(policy.UpdateCat)).Put("/{id}", Cat.UpdateCat)
+	r.With(middleware.Authorize(policy.UpdateCat)).Patch("/{id}", Cat.PatchCat)
+	r.With(middleware.Authorize(policy.DeleteCat)).Delete("/{id}", Cat.DeleteCat)
+	r.With(middleware.Authorize(policy.TransitionCat)).Post("/{id}/transitions", Cat.TransitionCat)
+	r.With(middleware.Authorize(policy.UploadImage), middleware.SetImage).Post("/{id}", Cat.UploadImageCat)
+	r.With(middleware.Authorize(policy.DeleteImage)).Delete("/{id}/{id_image}", Cat.DeleteImageCat)
 }
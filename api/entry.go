@@ -14,6 +14,9 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/problem"
 )
 
 // type to hold the Routes() function
@@ -23,13 +26,60 @@ func (e Entry) Routes() chi.Router {
 	// initiate new chi instance
 	r := chi.NewRouter()
 
+	// resolve tenant of every request so data of other
+	// tenant could not be read by the controllers
+	r.Use(middleware.Tenant)
+
+	// authenticate every request with api key, bearer token or
+	// session cookie, current user would be in the context and
+	// anonymous request is still served
+	r.Use(middleware.Authenticate)
+
 	// entry
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		render.PlainText(w, r, "Welcome to API")
 	})
 
-	// Route for cats endpoint
-	r.Route("/cats", Cats)
+	// Route for authentication endpoint, it's not checked for
+	// two-factor so staff could enroll when it's required
+	r.Route("/auth", Auth)
+
+	r.Group(func(r chi.Router) {
+		// staff must enable two-factor first
+		// when the tenant require it
+		r.Use(middleware.RequireTwoFactor)
+
+		// data of cat and shelter is not changed
+		// while fsck is repairing the storage
+		r.Use(middleware.HoldStorage)
+
+		// Route for cats endpoint
+		r.Route("/cats", Cats)
+
+		// Route for shelters endpoint
+		r.Route("/shelters", Shelters)
+
+		// Route for users endpoint
+		r.Route("/users", Users)
+
+		// Route for adoption applications endpoint
+		r.Route("/applications", Applications)
+
+		// Route for api keys endpoint
+		r.Route("/keys", Keys)
+
+		// Route for favorite cats endpoint
+		r.Route("/favorites", Favorites)
+	})
+
+	// unknown route and method is sent as problem
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		problem.NotFound(w, r, "route not found")
+	})
+
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		problem.New(http.StatusMethodNotAllowed, "method not allowed").Write(w, r)
+	})
 
 	// return the route so main file could mounted it
 	return r
@@ -0,0 +1,28 @@
+// =======================
+// This package is package to store routes for favorites
+// each routes would have their own controller which
+// would be imported from the controllers package
+// =======================
+
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/controllers"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/policy"
+)
+
+// define controller
+var Favorite controllers.FavoriteControllers = *new(controllers.Favorite)
+
+// Favorites router function that would be used by "/favorites" endpoint,
+// favorites belong to the signed in user so api key is not allowed
+func Favorites(r chi.Router) {
+	r.Use(middleware.Authorize(policy.FavoriteCat))
+
+	r.Get("/", Favorite.GetFavorites)
+	r.Put("/{id}", Favorite.AddFavorite)
+	r.Delete("/{id}", Favorite.RemoveFavorite)
+}
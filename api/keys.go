@@ -0,0 +1,30 @@
+// =======================
+// This package is package to store routes for api keys
+// each routes would have their own controller which
+// would be imported from the controllers package
+// =======================
+
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/controllers"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/policy"
+)
+
+// define controller
+var Key controllers.KeyControllers = *new(controllers.Key)
+
+// Keys router function that would be used by "/keys" endpoint,
+// all of them is only allowed for staff and keys of other
+// shelters is checked by the controller
+func Keys(r chi.Router) {
+	r.Use(middleware.Authorize(policy.ManageKeys))
+
+	r.Get("/", Key.GetKeys)
+	r.Post("/", Key.AddKey)
+	r.Get("/{id}", Key.GetKey)
+	r.Delete("/{id}", Key.RevokeKey)
+}
@@ -0,0 +1,33 @@
+// =======================
+// This package is package to store routes for shelters
+// each routes would have their own controller which
+// would be imported from the controllers package
+// =======================
+
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/controllers"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/policy"
+)
+
+// define controller
+var Shelter controllers.ShelterControllers = *new(controllers.Shelter)
+
+// Shelters router function that would be used by "/shelters" endpoint,
+// staff could only update shelters assigned to them and it's checked
+// by the controller
+func Shelters(r chi.Router) {
+	read := middleware.Authorize(policy.ReadShelter)
+
+	r.With(read).Get("/", Shelter.GetShelters)
+	r.With(read).Get("/{id}", Shelter.GetShelter)
+
+	r.With(middleware.Authorize(policy.CreateShelter)).Post("/", Shelter.AddShelter)
+	r.With(middleware.Authorize(policy.UpdateShelter)).Put("/{id}", Shelter.UpdateShelter)
+	r.With(middleware.Authorize(policy.DeleteShelter)).Delete("/{id}", Shelter.DeleteShelter)
+	r.With(middleware.Authorize(policy.UpdateShelter), middleware.SetImage).Post("/{id}/logo", Shelter.UploadLogo)
+}
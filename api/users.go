@@ -0,0 +1,29 @@
+// =======================
+// This package is package to store routes for users
+// each routes would have their own controller which
+// would be imported from the controllers package
+// =======================
+
+package api
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/controllers"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/policy"
+)
+
+// define controller
+var User controllers.UserControllers = *new(controllers.User)
+
+// Users router function that would be used by "/users" endpoint,
+// all of them is only allowed for admin
+func Users(r chi.Router) {
+	r.Use(middleware.Authorize(policy.ManageUsers))
+
+	r.Get("/{id}", User.GetUser)
+	r.Put("/{id}/role", User.SetRole)
+	r.Put("/{id}/shelters", User.SetShelters)
+	r.Delete("/{id}/2fa", User.ResetTwoFactor)
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Name of cookie and header used by browser session, CSRF cookie
+// is readable by the Vue app so it could be sent back as header.
+// Cookie of tenant other than default has the tenant id as suffix
+const (
+	SessionCookie = "session"
+	CSRFCookie    = "csrf_token"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+// Cookie is only sent over https except on dev mode
+func secure() bool {
+	return os.Getenv("APP_ENV") != "dev"
+}
+
+// Function to get name of the cookie inside the tenant, tenants that
+// is resolved by header share the same host so each of them has its
+// own cookie and logout of one tenant would not remove the others
+func CookieName(t *tenant.Tenant, name string) string {
+	if t.ID == tenant.DefaultID {
+		return name
+	}
+
+	return name + "_" + t.ID
+}
+
+// Function to set cookies of browser session
+func SetCookies(w http.ResponseWriter, t *tenant.Tenant, token string, session *Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName(t, SessionCookie),
+		Value:    token,
+		Path:     "/",
+		Expires:  session.Expires,
+		HttpOnly: true,
+		Secure:   secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName(t, CSRFCookie),
+		Value:    session.CSRF,
+		Path:     "/",
+		Expires:  session.Expires,
+		Secure:   secure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Function to remove cookies of browser session of the tenant
+func ClearCookies(w http.ResponseWriter, t *tenant.Tenant) {
+	for _, name := range []string{SessionCookie, CSRFCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     CookieName(t, name),
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: name == SessionCookie,
+			Secure:   secure(),
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
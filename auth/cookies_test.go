@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestCookieName(t *testing.T) {
+	tests := []struct {
+		name   string
+		tenant *tenant.Tenant
+		cookie string
+		want   string
+	}{
+		{"default session", &tenant.Tenant{ID: tenant.DefaultID}, SessionCookie, "session"},
+		{"default csrf", &tenant.Tenant{ID: tenant.DefaultID}, CSRFCookie, "csrf_token"},
+		{"tenant session", &tenant.Tenant{ID: "paws"}, SessionCookie, "session_paws"},
+		{"tenant csrf", &tenant.Tenant{ID: "paws"}, CSRFCookie, "csrf_token_paws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CookieName(tt.tenant, tt.cookie); got != tt.want {
+				t.Errorf("CookieName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearCookies(t *testing.T) {
+	w := httptest.NewRecorder()
+	ClearCookies(w, &tenant.Tenant{ID: "paws"})
+
+	res := &http.Response{Header: w.Header()}
+	cleared := map[string]bool{}
+
+	for _, c := range res.Cookies() {
+		cleared[c.Name] = c.MaxAge < 0
+	}
+
+	// cookies of other tenant on the same host are kept
+	if len(cleared) != 2 || !cleared["session_paws"] || !cleared["csrf_token_paws"] {
+		t.Errorf("cleared cookies = %v, want only cookies of the tenant", cleared)
+	}
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// How to work:
+// - Key is written as "sk_{id}_{secret}", id is used to find the key
+//   and only hash of the secret is stored so leaked data could
+//   not be used as key
+// - Key is read on each request so revoked key is rejected at once
+// - Last used time is written at most once per minute so busy
+//   partner site would not write the key on every request
+
+// Directory of api key's data
+const keysDir = "data/keys"
+
+// Prefix of api key, it make the key easy to recognize
+const KeyPrefix = "sk_"
+
+// How often last used time of key is written
+const lastUsedInterval = time.Minute
+
+// Error of api key lookup, expired and revoked
+// key is treated the same as unknown one
+var ErrKey = errors.New("invalid, expired or revoked api key")
+
+// storedKey type is stored api key with the secret hash
+type storedKey struct {
+	*models.APIKey
+	Hash string `json:"secret_hash"`
+}
+
+// Lock to write last used time
+var keysMu sync.Mutex
+
+// Get the path of key
+func keyPath(t *tenant.Tenant, id string) string {
+	return t.Path(keysDir, filepath.Base(id)+".json")
+}
+
+// Read one stored key
+func readKey(t *tenant.Tenant, id string) (*storedKey, error) {
+	var key storedKey
+
+	data, err := ioutil.ReadFile(keyPath(t, id))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Write stored key
+func writeKey(t *tenant.Tenant, key *storedKey) error {
+	data, err := json.Marshal(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(t.Path(keysDir), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(keyPath(t, key.ID.String()), data, 0600)
+}
+
+// Function to create api key, the key is returned
+// once and would be sent by client as X-API-Key header
+func NewKey(t *tenant.Tenant, key *models.APIKey) (string, error) {
+	secret, err := randomToken()
+
+	if err != nil {
+		return "", err
+	}
+
+	key.ID = xid.New()
+	key.Create = time.Now()
+	key.LastUsed = nil
+
+	if err = writeKey(t, &storedKey{APIKey: key, Hash: hashToken(secret)}); err != nil {
+		return "", err
+	}
+
+	return KeyPrefix + key.ID.String() + "_" + secret, nil
+}
+
+// Function to get api key by id
+func GetKey(t *tenant.Tenant, id string) (*models.APIKey, error) {
+	key, err := readKey(t, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key.APIKey, nil
+}
+
+// Function to get all api keys of tenant ordered by creation
+func ListKeys(t *tenant.Tenant) ([]*models.APIKey, error) {
+	keys := []*models.APIKey{}
+
+	files, err := ioutil.ReadDir(t.Path(keysDir))
+
+	if os.IsNotExist(err) {
+		return keys, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range files {
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		key, err := readKey(t, strings.TrimSuffix(v.Name(), ".json"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key.APIKey)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Create.Before(keys[j].Create) })
+
+	return keys, nil
+}
+
+// Function to find api key of the token inside the tenant,
+// last used time of the key is updated
+func LookupKey(t *tenant.Tenant, token string) (*models.APIKey, error) {
+	if !strings.HasPrefix(token, KeyPrefix) {
+		return nil, ErrKey
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(token, KeyPrefix), "_", 2)
+
+	if len(parts) != 2 {
+		return nil, ErrKey
+	}
+
+	if _, err := xid.FromString(parts[0]); err != nil {
+		return nil, ErrKey
+	}
+
+	key, err := readKey(t, parts[0])
+
+	if os.IsNotExist(err) {
+		return nil, ErrKey
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashToken(parts[1]))) != 1 || key.Expired() {
+		return nil, ErrKey
+	}
+
+	now := time.Now()
+
+	if key.LastUsed == nil || now.Sub(*key.LastUsed) >= lastUsedInterval {
+		keysMu.Lock()
+		defer keysMu.Unlock()
+
+		// read again so revoked key is not written back
+		if key, err = readKey(t, parts[0]); err != nil {
+			return nil, ErrKey
+		}
+
+		key.LastUsed = &now
+
+		if err = writeKey(t, key); err != nil {
+			return nil, err
+		}
+	}
+
+	return key.APIKey, nil
+}
+
+// Function to revoke api key, the key is deleted
+// so it's rejected on the next request
+func RevokeKey(t *tenant.Tenant, id string) error {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	return os.Remove(keyPath(t, id))
+}
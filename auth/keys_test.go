@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestLookupKey(t *testing.T) {
+	tn := &tenant.Tenant{ID: "paws", Root: t.TempDir()}
+	other := &tenant.Tenant{ID: "meow", Root: t.TempDir()}
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	token, err := NewKey(tn, &models.APIKey{Name: "Partner", Scope: models.KeyScopeRead, Expires: &future})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := NewKey(tn, &models.APIKey{Name: "Old partner", Scope: models.KeyScopeRead, Expires: &past})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := strings.SplitN(strings.TrimPrefix(token, KeyPrefix), "_", 2)[0]
+
+	tests := []struct {
+		name    string
+		tenant  *tenant.Tenant
+		token   string
+		wantErr error
+	}{
+		{"key", tn, token, nil},
+		{"other tenant", other, token, ErrKey},
+		{"expired", tn, expired, ErrKey},
+		{"wrong secret", tn, KeyPrefix + id + "_secret", ErrKey},
+		{"without secret", tn, KeyPrefix + id, ErrKey},
+		{"without prefix", tn, strings.TrimPrefix(token, KeyPrefix), ErrKey},
+		{"invalid id", tn, KeyPrefix + "../users_secret", ErrKey},
+		{"empty", tn, "", ErrKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupKey(tt.tenant, tt.token)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LookupKey() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.ID.String() != id {
+				t.Errorf("LookupKey() = %q, want %q", got.ID, id)
+			}
+		})
+	}
+
+	key, err := GetKey(tn, id)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.LastUsed == nil {
+		t.Error("last used time is not written after lookup")
+	}
+
+	if err = RevokeKey(tn, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = LookupKey(tn, token); !errors.Is(err, ErrKey) {
+		t.Errorf("LookupKey() of revoked key error = %v, want %v", err, ErrKey)
+	}
+}
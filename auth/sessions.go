@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Directory of session's data
+const sessionsDir = "data/sessions"
+
+// Kind of session
+const (
+	KindSession = "session" // browser session, sent as cookie
+	KindToken   = "token"   // bearer token of api client
+
+	// KindChallenge is login that is waiting for the second factor,
+	// Next is the kind that is created when it's verified
+	KindChallenge = "challenge"
+)
+
+// Lifetime of session and bearer token
+const (
+	SessionTTL = 7 * 24 * time.Hour
+	TokenTTL   = 30 * 24 * time.Hour
+
+	ChallengeTTL = 5 * time.Minute
+)
+
+// Error of session lookup, expired and revoked
+// session is treated the same as unknown one
+var ErrSession = errors.New("invalid or expired session")
+
+// Session type store logged in user. The token itself is only
+// known by client, ID is the hash of token so session could be
+// found by the token but token could not be made from the ID
+type Session struct {
+	ID      string    `json:"id"`
+	UserID  xid.ID    `json:"user_id"`
+	Kind    string    `json:"kind"`
+	CSRF    string    `json:"csrf,omitempty"`
+	Create  time.Time `json:"created_at"`
+	Expires time.Time `json:"expires_at"`
+
+	// Next and Attempts is only used by challenge
+	Next     string `json:"next,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+
+	// Issuer is OIDC provider that authenticated the session, MultiFactor
+	// is true when ID token prove the provider checked the second factor
+	Issuer      string `json:"issuer,omitempty"`
+	MultiFactor bool   `json:"multi_factor,omitempty"`
+}
+
+// Create random token encoded as url safe string
+func randomToken() (string, error) {
+	buff := make([]byte, 32)
+
+	if _, err := rand.Read(buff); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buff), nil
+}
+
+// Get the id of token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Function to create session of user, the token is returned
+// once and would be sent by client on each request
+func NewSession(t *tenant.Tenant, userID xid.ID, kind string) (string, *Session, error) {
+	token, err := randomToken()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	session := &Session{ID: hashToken(token), UserID: userID, Kind: kind, Create: now}
+
+	switch kind {
+	case KindSession:
+		// browser session need CSRF token since
+		// cookie is sent automatically by browser
+		if session.CSRF, err = randomToken(); err != nil {
+			return "", nil, err
+		}
+
+		session.Expires = now.Add(SessionTTL)
+	case KindChallenge:
+		session.Expires = now.Add(ChallengeTTL)
+	default:
+		session.Expires = now.Add(TokenTTL)
+	}
+
+	if err = writeSession(t, session); err != nil {
+		return "", nil, err
+	}
+
+	return token, session, nil
+}
+
+// Function to create browser session of user that is authenticated
+// by OIDC provider of the issuer, multiFactor is whether the provider
+// checked the second factor of the login
+func NewProviderSession(t *tenant.Tenant, userID xid.ID, issuer string, multiFactor bool) (string, *Session, error) {
+	token, session, err := NewSession(t, userID, KindSession)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	session.Issuer = issuer
+	session.MultiFactor = multiFactor
+
+	return token, session, writeSession(t, session)
+}
+
+// Write session's data
+func writeSession(t *tenant.Tenant, session *Session) error {
+	data, err := json.Marshal(session)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(t.Path(sessionsDir), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.Path(sessionsDir, session.ID+".json"), data, 0600)
+}
+
+// Function to find session of token with the kind inside the tenant,
+// expired session would be removed
+func Lookup(t *tenant.Tenant, token, kind string) (*Session, error) {
+	var session Session
+
+	if token == "" {
+		return nil, ErrSession
+	}
+
+	file := t.Path(sessionsDir, hashToken(token)+".json")
+	data, err := ioutil.ReadFile(file)
+
+	if os.IsNotExist(err) {
+		return nil, ErrSession
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+
+	if session.Kind != kind {
+		return nil, ErrSession
+	}
+
+	if time.Now().After(session.Expires) {
+		os.Remove(file)
+		return nil, ErrSession
+	}
+
+	return &session, nil
+}
+
+// Function to revoke session, revoked
+// session is not counted as error
+func Revoke(t *tenant.Tenant, session *Session) error {
+	err := os.Remove(t.Path(sessionsDir, session.ID+".json"))
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// CheckCSRF would report whether token is the CSRF token of session,
+// compared in constant time so it could not be guessed by timing
+func (s *Session) CheckCSRF(token string) bool {
+	return s.CSRF != "" && subtle.ConstantTimeCompare([]byte(s.CSRF), []byte(token)) == 1
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestLookup(t *testing.T) {
+	tn := &tenant.Tenant{ID: "paws", Root: t.TempDir()}
+	other := &tenant.Tenant{ID: "meow", Root: t.TempDir()}
+
+	token, session, err := NewSession(tn, xid.New(), KindSession)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tenant  *tenant.Tenant
+		token   string
+		kind    string
+		wantErr error
+	}{
+		{"session", tn, token, KindSession, nil},
+		{"other kind", tn, token, KindToken, ErrSession},
+		{"other tenant", other, token, KindSession, ErrSession},
+		{"session id as token", tn, session.ID, KindSession, ErrSession},
+		{"empty token", tn, "", KindSession, ErrSession},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lookup(tt.tenant, tt.token, tt.kind)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.ID != session.ID {
+				t.Errorf("Lookup() = %q, want %q", got.ID, session.ID)
+			}
+		})
+	}
+
+	if err = Revoke(tn, session); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = Lookup(tn, token, KindSession); !errors.Is(err, ErrSession) {
+		t.Errorf("Lookup() of revoked session error = %v, want %v", err, ErrSession)
+	}
+}
+
+func TestLoginTenant(t *testing.T) {
+	tn := &tenant.Tenant{ID: "paws", Root: t.TempDir()}
+	other := &tenant.Tenant{ID: "meow", Root: t.TempDir()}
+
+	if err := CreateUser(tn, &models.User{Email: "staff@paws.org", Name: "Staff"}, "password1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Login(tn, "Staff@Paws.org", "password1"); err != nil {
+		t.Errorf("Login() error = %v, want nil", err)
+	}
+
+	if _, err := Login(tn, "staff@paws.org", "password2"); !errors.Is(err, ErrCredentials) {
+		t.Errorf("Login() with wrong password error = %v, want %v", err, ErrCredentials)
+	}
+
+	// user of one tenant could not login to other tenant
+	if _, err := Login(other, "staff@paws.org", "password1"); !errors.Is(err, ErrCredentials) {
+		t.Errorf("Login() to other tenant error = %v, want %v", err, ErrCredentials)
+	}
+
+	// and the same email could be registered there
+	if err := CreateUser(other, &models.User{Email: "staff@paws.org", Name: "Staff"}, "password2"); err != nil {
+		t.Errorf("CreateUser() in other tenant error = %v, want nil", err)
+	}
+
+	if err := CreateUser(tn, &models.User{Email: "STAFF@paws.org", Name: "Staff"}, "password2"); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("CreateUser() of taken email error = %v, want %v", err, ErrEmailTaken)
+	}
+}
+
+func TestCheckCSRF(t *testing.T) {
+	tn := &tenant.Tenant{ID: "paws", Root: t.TempDir()}
+
+	_, session, err := NewSession(tn, xid.New(), KindSession)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		session *Session
+		token   string
+		want    bool
+	}{
+		{"token of session", session, session.CSRF, true},
+		{"other token", session, session.CSRF + "x", false},
+		{"empty token", session, "", false},
+		{"session without token", &Session{}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.session.CheckCSRF(tt.token); got != tt.want {
+				t.Errorf("CheckCSRF() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
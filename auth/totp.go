@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// How to work:
+// - TOTP code is HMAC-SHA1 of the current 30 seconds step (RFC 6238),
+//   the same as authenticator app so the secret could be scanned as QR
+// - Code of previous and next step is also accepted since clock of
+//   the phone could be a bit different
+// - Step that has been used is remembered so the same code could not
+//   be used twice
+
+// Setting of TOTP, it's the default of authenticator apps
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpSkew   = 1
+)
+
+// Encoding of TOTP secret
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Create random TOTP secret
+func newSecret() (string, error) {
+	buff := make([]byte, 20)
+
+	if _, err := rand.Read(buff); err != nil {
+		return "", err
+	}
+
+	return secretEncoding.EncodeToString(buff), nil
+}
+
+// Get the step of time
+func totpStep(now time.Time) int64 {
+	return now.Unix() / totpPeriod
+}
+
+// Get the code of secret on the step
+func totpCode(secret string, step int64) (string, error) {
+	key, err := secretEncoding.DecodeString(secret)
+
+	if err != nil {
+		return "", err
+	}
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%mod), nil
+}
+
+// Check the code against the steps around now that is newer than last,
+// the matched step is returned so it could not be used again
+func checkTOTP(secret, code string, last int64, now time.Time) (int64, bool) {
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := totpStep(now)
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= last {
+			continue
+		}
+
+		expected, err := totpCode(secret, step)
+
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// Function to get otpauth uri of the secret, it's encoded as QR code
+// by the client and scanned by authenticator app
+func ProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+
+	label := url.PathEscape(issuer + ":" + account)
+
+	return "otpauth://totp/" + label + "?" + strings.Replace(query.Encode(), "+", "%20", -1)
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret of RFC 6238 test vectors, "12345678901234567890" in base32
+const testSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// last 6 digits of SHA1 vectors of RFC 6238
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got, err := totpCode(testSecret, totpStep(time.Unix(tt.unix, 0)))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Errorf("totpCode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := totpStep(now)
+
+	code := func(step int64) string {
+		c, err := totpCode(testSecret, step)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		last     int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", code(current), 0, current, true},
+		{"previous step", code(current - 1), 0, current - 1, true},
+		{"next step", code(current + 1), 0, current + 1, true},
+		{"too old", code(current - 2), 0, 0, false},
+		{"too new", code(current + 2), 0, 0, false},
+		{"used step", code(current), current, 0, false},
+		{"newer than used step", code(current + 1), current, current + 1, true},
+		{"wrong code", "000000", 0, 0, false},
+		{"short code", code(current)[:5], 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := checkTOTP(testSecret, tt.code, tt.last, now)
+
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("checkTOTP() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	got := ProvisioningURI("Paws Rescue", "staff@paws.org", testSecret)
+	want := "otpauth://totp/Paws%20Rescue:staff@paws.org?algorithm=SHA1&digits=6&issuer=Paws%20Rescue&period=30&secret=" + testSecret
+
+	if got != want {
+		t.Errorf("ProvisioningURI() = %q, want %q", got, want)
+	}
+}
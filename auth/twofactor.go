@@ -0,0 +1,322 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// How to work:
+// - Enroll create pending secret, it's only enabled after the first
+//   code is confirmed so user could not be locked out by bad scan
+// - Recovery code is given when it's enabled, each of them could be
+//   used once instead of TOTP code and only its hash is stored
+// - Login of user with two-factor create challenge instead of session,
+//   challenge is removed after it's verified or failed too many times
+// - Failed code is also counted on the account, so new login could not
+//   be used to keep guessing, the account is locked for a while after
+//   too many failed codes
+
+// Number of recovery codes
+const recoveryCount = 10
+
+// Failed verification before the challenge is removed
+const maxAttempts = 5
+
+// Failed verification of account before it's locked and how long
+const (
+	maxFailed   = 10
+	lockoutTime = 15 * time.Minute
+)
+
+// Alphabet of recovery code, similar character is left out
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// Errors of two-factor authentication
+var (
+	ErrCode             = errors.New("invalid two-factor code")
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorOff     = errors.New("two-factor authentication is not enabled")
+	ErrNotEnrolled      = errors.New("two-factor enrollment is not started")
+	ErrLocked           = errors.New("too many invalid two-factor codes, try again later")
+)
+
+// Lock so attempts of the same challenge is counted one by one
+var challengeMu sync.Mutex
+
+// otp type store TOTP secret and recovery code's hash of account
+type otp struct {
+	Secret   string   `json:"secret,omitempty"`
+	Pending  string   `json:"pending,omitempty"`
+	Recovery []string `json:"recovery,omitempty"`
+	LastStep int64    `json:"last_step,omitempty"`
+
+	// Failed is count of failed code since the last
+	// valid code or lockout, Locked is end of lockout
+	Failed int        `json:"failed,omitempty"`
+	Locked *time.Time `json:"locked_until,omitempty"`
+}
+
+// Create recovery codes formatted as "xxxxx-xxxxx",
+// the codes is returned and the hashes is stored
+func newRecovery() ([]string, []string, error) {
+	var codes, hashes []string
+
+	for i := 0; i < recoveryCount; i++ {
+		buff := make([]byte, 10)
+
+		if _, err := rand.Read(buff); err != nil {
+			return nil, nil, err
+		}
+
+		for j, b := range buff {
+			buff[j] = recoveryAlphabet[int(b)%len(recoveryAlphabet)]
+		}
+
+		code := string(buff[:5]) + "-" + string(buff[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeCode(code)))
+	}
+
+	return codes, hashes, nil
+}
+
+// Normalize code so space and dash is ignored
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// Check TOTP or recovery code of account, used step
+// and recovery code is removed so it could not be reused
+func (o *otp) check(code string) bool {
+	code = normalizeCode(code)
+
+	if step, ok := checkTOTP(o.Secret, code, o.LastStep, time.Now()); ok {
+		o.LastStep = step
+		return true
+	}
+
+	hash := hashToken(code)
+
+	for i, v := range o.Recovery {
+		if subtle.ConstantTimeCompare([]byte(v), []byte(hash)) == 1 {
+			o.Recovery = append(o.Recovery[:i], o.Recovery[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verify code of account, the account is locked after too many
+// failed codes so it's rejected without checking the code
+func (o *otp) verify(code string, now time.Time) error {
+	if o.Locked != nil && now.Before(*o.Locked) {
+		return ErrLocked
+	}
+
+	if o.check(code) {
+		o.Failed, o.Locked = 0, nil
+		return nil
+	}
+
+	o.Failed++
+
+	if o.Failed >= maxFailed {
+		until := now.Add(lockoutTime)
+		o.Failed, o.Locked = 0, &until
+	}
+
+	return ErrCode
+}
+
+// Read account with the lock and call fn, account is
+// written when fn return no error
+func updateAccount(t *tenant.Tenant, id xid.ID, fn func(acc *account) error) error {
+	usersMu.Lock()
+	defer usersMu.Unlock()
+
+	acc, err := readAccount(t, id.String())
+
+	if err != nil {
+		return err
+	}
+
+	if err = fn(acc); err != nil {
+		return err
+	}
+
+	acc.TwoFactor = acc.OTP != nil && acc.OTP.Secret != ""
+	acc.Update = time.Now()
+
+	return writeAccount(t, acc)
+}
+
+// Verify code of user with two-factor then call fn, failed
+// count is written even when the code is not valid
+func verifyCode(t *tenant.Tenant, id xid.ID, code string, fn func(acc *account) error) error {
+	var result error
+
+	err := updateAccount(t, id, func(acc *account) error {
+		if acc.OTP == nil || acc.OTP.Secret == "" {
+			return ErrTwoFactorOff
+		}
+
+		if result = acc.OTP.verify(code, time.Now()); result != nil {
+			return nil
+		}
+
+		return fn(acc)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return result
+}
+
+// Function to start two-factor enrollment of user,
+// the secret is returned to be added to authenticator app
+func EnrollTwoFactor(t *tenant.Tenant, id xid.ID) (string, error) {
+	secret, err := newSecret()
+
+	if err != nil {
+		return "", err
+	}
+
+	return secret, updateAccount(t, id, func(acc *account) error {
+		if acc.OTP != nil && acc.OTP.Secret != "" {
+			return ErrTwoFactorEnabled
+		}
+
+		acc.OTP = &otp{Pending: secret}
+
+		return nil
+	})
+}
+
+// Function to enable two-factor of user with the first
+// code of pending secret, recovery codes is returned once
+func ConfirmTwoFactor(t *tenant.Tenant, id xid.ID, code string) ([]string, error) {
+	codes, hashes, err := newRecovery()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, updateAccount(t, id, func(acc *account) error {
+		switch {
+		case acc.OTP != nil && acc.OTP.Secret != "":
+			return ErrTwoFactorEnabled
+		case acc.OTP == nil || acc.OTP.Pending == "":
+			return ErrNotEnrolled
+		}
+
+		step, ok := checkTOTP(acc.OTP.Pending, normalizeCode(code), 0, time.Now())
+
+		if !ok {
+			return ErrCode
+		}
+
+		acc.OTP = &otp{Secret: acc.OTP.Pending, Recovery: hashes, LastStep: step}
+
+		return nil
+	})
+}
+
+// Function to replace recovery codes of user, current code is
+// required so stolen session could not read new codes
+func RegenerateRecovery(t *tenant.Tenant, id xid.ID, code string) ([]string, error) {
+	codes, hashes, err := newRecovery()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, verifyCode(t, id, code, func(acc *account) error {
+		acc.OTP.Recovery = hashes
+		return nil
+	})
+}
+
+// Function to disable two-factor of user with the current code,
+// reset skip the code and it's used when admin reset lost device
+func DisableTwoFactor(t *tenant.Tenant, id xid.ID, code string, reset bool) error {
+	disable := func(acc *account) error {
+		acc.OTP = nil
+		return nil
+	}
+
+	if reset {
+		return updateAccount(t, id, disable)
+	}
+
+	return verifyCode(t, id, code, disable)
+}
+
+// Function to create challenge of user that login with password,
+// next is the kind of session that is created after it's verified
+func NewChallenge(t *tenant.Tenant, userID xid.ID, next string) (string, *Session, error) {
+	token, session, err := NewSession(t, userID, KindChallenge)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	session.Next = next
+
+	return token, session, writeSession(t, session)
+}
+
+// Function to verify challenge with TOTP or recovery code, the
+// verified challenge is returned and removed. Challenge that
+// failed too many times is removed and ErrLocked is returned
+// when the account is locked so code could not be guessed
+func VerifyChallenge(t *tenant.Tenant, token, code string) (*Session, error) {
+	challengeMu.Lock()
+	defer challengeMu.Unlock()
+
+	challenge, err := Lookup(t, token, KindChallenge)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifyCode(t, challenge.UserID, code, func(acc *account) error {
+		return nil
+	})
+
+	// two-factor could be disabled after the challenge is created
+	if errors.Is(err, ErrTwoFactorOff) {
+		err = ErrCode
+	}
+
+	if errors.Is(err, ErrCode) {
+		challenge.Attempts++
+
+		if challenge.Attempts >= maxAttempts {
+			Revoke(t, challenge)
+			return nil, ErrSession
+		}
+
+		if err := writeSession(t, challenge); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrCode
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return challenge, Revoke(t, challenge)
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Create tenant with user that enabled two-factor,
+// the secret is returned to create the code
+func twoFactorUser(t *testing.T) (*tenant.Tenant, *models.User, string) {
+	t.Helper()
+
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+	user := &models.User{Email: "staff@paws.org", Name: "Staff"}
+
+	if err := CreateUser(tn, user, "password1"); err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := EnrollTwoFactor(tn, user.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := totpCode(secret, totpStep(time.Now()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = ConfirmTwoFactor(tn, user.ID, code); err != nil {
+		t.Fatal(err)
+	}
+
+	return tn, user, secret
+}
+
+// Wrong code that never match TOTP code since it's not a number
+const wrongCode = "00000x"
+
+func TestOTPVerify(t *testing.T) {
+	now := time.Now()
+	secret, _ := newSecret()
+	locked := now.Add(time.Minute)
+	expired := now.Add(-time.Minute)
+
+	valid := func() string {
+		code, _ := totpCode(secret, totpStep(now))
+		return code
+	}
+
+	tests := []struct {
+		name       string
+		otp        otp
+		code       string
+		want       error
+		wantFailed int
+		wantLocked bool
+	}{
+		{"valid code", otp{Secret: secret, Failed: 3}, valid(), nil, 0, false},
+		{"wrong code", otp{Secret: secret, Failed: 3}, wrongCode, ErrCode, 4, false},
+		{"last wrong code lock", otp{Secret: secret, Failed: maxFailed - 1}, wrongCode, ErrCode, 0, true},
+		{"locked valid code", otp{Secret: secret, Locked: &locked}, valid(), ErrLocked, 0, true},
+		{"lockout ended", otp{Secret: secret, Locked: &expired}, valid(), nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := tt.otp
+			err := o.verify(tt.code, now)
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("verify() = %v, want %v", err, tt.want)
+			}
+
+			if o.Failed != tt.wantFailed {
+				t.Errorf("Failed = %d, want %d", o.Failed, tt.wantFailed)
+			}
+
+			if (o.Locked != nil && now.Before(*o.Locked)) != tt.wantLocked {
+				t.Errorf("Locked = %v, want locked %v", o.Locked, tt.wantLocked)
+			}
+		})
+	}
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	tn, user, secret := twoFactorUser(t)
+
+	token, _, err := NewChallenge(tn, user.ID, KindSession)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the current step is used to confirm the secret
+	next, err := totpCode(secret, totpStep(time.Now())+1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, err := VerifyChallenge(tn, token, next)
+
+	if err != nil {
+		t.Fatalf("VerifyChallenge() = %v", err)
+	}
+
+	if challenge.UserID != user.ID || challenge.Next != KindSession {
+		t.Errorf("challenge = %+v, want session of user %s", challenge, user.ID)
+	}
+
+	// verified challenge is removed
+	if _, err = VerifyChallenge(tn, token, next); !errors.Is(err, ErrSession) {
+		t.Errorf("VerifyChallenge() again = %v, want %v", err, ErrSession)
+	}
+}
+
+func TestVerifyChallengeAttempts(t *testing.T) {
+	tn, user, _ := twoFactorUser(t)
+
+	token, _, err := NewChallenge(tn, user.ID, KindToken)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < maxAttempts; i++ {
+		if _, err = VerifyChallenge(tn, token, wrongCode); !errors.Is(err, ErrCode) {
+			t.Fatalf("attempt %d = %v, want %v", i, err, ErrCode)
+		}
+	}
+
+	// the last attempt remove the challenge
+	if _, err = VerifyChallenge(tn, token, wrongCode); !errors.Is(err, ErrSession) {
+		t.Errorf("last attempt = %v, want %v", err, ErrSession)
+	}
+}
+
+func TestVerifyChallengeLockout(t *testing.T) {
+	tn, user, secret := twoFactorUser(t)
+
+	// failed code is counted across challenges of new logins
+	for failed := 0; failed < maxFailed; {
+		token, _, err := NewChallenge(tn, user.ID, KindSession)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < maxAttempts && failed < maxFailed; i, failed = i+1, failed+1 {
+			VerifyChallenge(tn, token, wrongCode)
+		}
+	}
+
+	token, _, err := NewChallenge(tn, user.ID, KindSession)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := totpCode(secret, totpStep(time.Now())+1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = VerifyChallenge(tn, token, next); !errors.Is(err, ErrLocked) {
+		t.Errorf("VerifyChallenge() of locked account = %v, want %v", err, ErrLocked)
+	}
+
+	// admin reset is not blocked by the lockout
+	if err = DisableTwoFactor(tn, user.ID, "", true); err != nil {
+		t.Errorf("DisableTwoFactor() reset = %v", err)
+	}
+}
+
+func TestRecoveryCode(t *testing.T) {
+	tn, user, secret := twoFactorUser(t)
+
+	next, err := totpCode(secret, totpStep(time.Now())+1)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := RegenerateRecovery(tn, user.ID, next)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != recoveryCount {
+		t.Fatalf("recovery codes = %d, want %d", len(codes), recoveryCount)
+	}
+
+	token, _, err := NewChallenge(tn, user.ID, KindSession)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// space and case of the code is ignored
+	if _, err = VerifyChallenge(tn, token, " "+codes[0]+" "); err != nil {
+		t.Fatalf("VerifyChallenge() recovery code = %v", err)
+	}
+
+	token, _, err = NewChallenge(tn, user.ID, KindSession)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = VerifyChallenge(tn, token, codes[0]); !errors.Is(err, ErrCode) {
+		t.Errorf("VerifyChallenge() used recovery code = %v, want %v", err, ErrCode)
+	}
+}
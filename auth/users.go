@@ -0,0 +1,373 @@
+// ======================
+// This package is package to authenticate user of the api.
+// User is stored inside "data/users" with bcrypt hash of the password,
+// the hash is never sent to client since it's kept outside models.User.
+// Login would create session for browser (cookie with CSRF token) or
+// bearer token for api client, both of them is stored hashed inside
+// "data/sessions" so leaked data could not be used to login. User with
+// two-factor authentication get challenge that must be verified with
+// TOTP or recovery code before the session is created.
+// Users and sessions is stored inside root of the tenant so user
+// of one tenant could not login to other tenant.
+// ======================
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Directory of user's data
+const usersDir = "data/users"
+
+// Cost of bcrypt hash
+const hashCost = 12
+
+// Errors of user
+var (
+	ErrEmailTaken  = errors.New("email is already registered")
+	ErrCredentials = errors.New("invalid email or password")
+
+	// ErrLinkRequired is returned when provider login use email of
+	// other user, the user must login and link the provider first
+	ErrLinkRequired  = errors.New("email is registered, login and link the provider to the account first")
+	ErrIdentityTaken = errors.New("provider account is linked to other user")
+)
+
+// account type is stored user with the password hash
+// and the TOTP secret of two-factor authentication
+type account struct {
+	*models.User
+	Hash string `json:"password_hash"`
+	OTP  *otp   `json:"otp,omitempty"`
+}
+
+// Lock to make sure email is registered once
+var usersMu sync.Mutex
+
+// Hash that is compared when the email is not registered,
+// so unknown email take the same time as wrong password
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), hashCost)
+
+// Normalize email so it could be compared
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Read one account
+func readAccount(t *tenant.Tenant, id string) (*account, error) {
+	acc := &account{User: new(models.User)}
+
+	data, err := ioutil.ReadFile(t.Path(usersDir, filepath.Base(id)+".json"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, acc); err != nil {
+		return nil, err
+	}
+
+	return acc, nil
+}
+
+// Find account by email, nil is returned when it's not registered
+func findAccount(t *tenant.Tenant, email string) (*account, error) {
+	files, err := ioutil.ReadDir(t.Path(usersDir))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range files {
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		acc, err := readAccount(t, strings.TrimSuffix(v.Name(), ".json"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if acc.Email == email {
+			return acc, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Write account, the directory is created on the first user
+func writeAccount(t *tenant.Tenant, acc *account) error {
+	data, err := json.Marshal(acc)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(t.Path(usersDir), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.Path(usersDir, acc.ID.String()+".json"), data, 0600)
+}
+
+// Function to register user with the password,
+// ErrEmailTaken is returned when email is already used
+func CreateUser(t *tenant.Tenant, user *models.User, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), hashCost)
+
+	if err != nil {
+		return err
+	}
+
+	usersMu.Lock()
+	defer usersMu.Unlock()
+
+	user.Email = normalizeEmail(user.Email)
+
+	existing, err := findAccount(t, user.Email)
+
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return ErrEmailTaken
+	}
+
+	user.ID = xid.New()
+	user.Role = models.RoleAdopter
+	user.Create = time.Now()
+	user.Update = user.Create
+
+	return writeAccount(t, &account{User: user, Hash: string(hash)})
+}
+
+// Function to check email and password of user,
+// ErrCredentials is returned when it's not matched
+func Login(t *tenant.Tenant, email, password string) (*models.User, error) {
+	acc, err := findAccount(t, normalizeEmail(email))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if acc == nil {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil, ErrCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(acc.Hash), []byte(password)) != nil {
+		return nil, ErrCredentials
+	}
+
+	return acc.User, nil
+}
+
+// Function to get user by id
+func GetUser(t *tenant.Tenant, id string) (*models.User, error) {
+	acc, err := readAccount(t, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return acc.User, nil
+}
+
+// Function to get user by email, nil is returned when it's not registered
+func FindUser(t *tenant.Tenant, email string) (*models.User, error) {
+	acc, err := findAccount(t, normalizeEmail(email))
+
+	if err != nil || acc == nil {
+		return nil, err
+	}
+
+	return acc.User, nil
+}
+
+// Find account linked to identity, nil is returned when it's not linked
+func findIdentity(t *tenant.Tenant, identity *models.Identity) (*account, error) {
+	files, err := ioutil.ReadDir(t.Path(usersDir))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range files {
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		acc, err := readAccount(t, strings.TrimSuffix(v.Name(), ".json"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, i := range acc.Identities {
+			if *i == *identity {
+				return acc, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// Report whether role is higher than current role, role
+// that is not known is treated as the highest so it's never given
+func raises(current, role string) bool {
+	rank := func(v string) int {
+		for i, r := range models.Roles {
+			if r == v {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	return rank(role) < rank(current)
+}
+
+// Function to get user that is signed in by identity provider.
+// User linked to the identity is used, otherwise new user is registered
+// without password. User with the same email is never linked since the
+// provider could claim any email, ErrLinkRequired is returned so the user
+// link it by LinkIdentity while logged in. Role from the provider is given
+// to new user and could only lower role of linked user, so the provider
+// could not raise role that is managed on this app
+func LinkUser(t *tenant.Tenant, identity *models.Identity, profile *models.User, verified bool) (*models.User, error) {
+	usersMu.Lock()
+	defer usersMu.Unlock()
+
+	acc, err := findIdentity(t, identity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	email := normalizeEmail(profile.Email)
+	now := time.Now()
+
+	if acc == nil {
+		var existing *account
+
+		if email != "" {
+			if existing, err = findAccount(t, email); err != nil {
+				return nil, err
+			}
+		}
+
+		if existing != nil && verified {
+			return nil, ErrLinkRequired
+		}
+
+		// email is only kept when it's verified and not used by other user
+		if existing != nil || !verified {
+			email = ""
+		}
+
+		role := profile.Role
+		if role == "" {
+			role = models.RoleAdopter
+		}
+
+		acc = &account{User: &models.User{
+			ID:         xid.New(),
+			Email:      email,
+			Name:       profile.Name,
+			Role:       role,
+			Create:     now,
+			Identities: []*models.Identity{identity},
+		}}
+	} else if profile.Role != "" && !raises(acc.Role, profile.Role) {
+		acc.Role = profile.Role
+	}
+
+	if acc.Name == "" {
+		acc.Name = profile.Name
+	}
+
+	acc.Update = now
+
+	// account without password hash could
+	// only be signed in by the provider
+	return acc.User, writeAccount(t, acc)
+}
+
+// Function to link identity of provider to logged in user, role of
+// the user is not changed. Identity that is linked to other user
+// could not be linked again
+func LinkIdentity(t *tenant.Tenant, userID xid.ID, identity *models.Identity) (*models.User, error) {
+	usersMu.Lock()
+	defer usersMu.Unlock()
+
+	linked, err := findIdentity(t, identity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if linked != nil && linked.ID != userID {
+		return nil, ErrIdentityTaken
+	}
+
+	if linked != nil {
+		return linked.User, nil
+	}
+
+	acc, err := readAccount(t, userID.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	acc.Identities = append(acc.Identities, identity)
+	acc.Update = time.Now()
+
+	return acc.User, writeAccount(t, acc)
+}
+
+// Function to write changed user, the password is kept
+func UpdateUser(t *tenant.Tenant, user *models.User) error {
+	usersMu.Lock()
+	defer usersMu.Unlock()
+
+	acc, err := readAccount(t, user.ID.String())
+
+	if err != nil {
+		return err
+	}
+
+	// two-factor is only changed by its own function
+	user.TwoFactor = acc.OTP != nil && acc.OTP.Secret != ""
+	user.Update = time.Now()
+	acc.User = user
+
+	return writeAccount(t, acc)
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestLinkUser(t *testing.T) {
+	issuer := "https://idp.example.com"
+
+	tests := []struct {
+		name     string
+		local    *models.User // registered with password before the login
+		subject  string
+		profile  *models.User
+		verified bool
+		wantErr  error
+		wantNew  bool
+		wantRole string
+	}{
+		{"new user", nil, "1", &models.User{Email: "new@paws.org", Name: "New"}, true, nil, true, models.RoleAdopter},
+		{"new user with role", nil, "1", &models.User{Email: "new@paws.org", Role: models.RoleShelterStaff}, true, nil, true, models.RoleShelterStaff},
+		{"verified email of local user", &models.User{Email: "staff@paws.org"}, "1", &models.User{Email: "Staff@paws.org", Role: models.RoleAdmin}, true, ErrLinkRequired, false, ""},
+		{"unverified email of local user", &models.User{Email: "staff@paws.org"}, "1", &models.User{Email: "staff@paws.org"}, false, nil, true, models.RoleAdopter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+
+			if tt.local != nil {
+				if err := CreateUser(tn, tt.local, "password1"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := LinkUser(tn, &models.Identity{Issuer: issuer, Subject: tt.subject}, tt.profile, tt.verified)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LinkUser() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.local != nil {
+				local, err := GetUser(tn, tt.local.ID.String())
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(local.Identities) != 0 || local.Role != tt.local.Role {
+					t.Errorf("local user = %+v, want it unchanged", local)
+				}
+			}
+
+			if err != nil {
+				return
+			}
+
+			if tt.wantNew && tt.local != nil && (got.ID == tt.local.ID || got.Email != "") {
+				t.Errorf("LinkUser() = %+v, want new user without the email", got)
+			}
+
+			if got.Role != tt.wantRole {
+				t.Errorf("role = %q, want %q", got.Role, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestLinkUserRole(t *testing.T) {
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+	identity := &models.Identity{Issuer: "https://idp.example.com", Subject: "1"}
+
+	user, err := LinkUser(tn, identity, &models.User{Email: "staff@paws.org", Role: models.RoleShelterStaff}, true)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		claim string
+		want  string
+	}{
+		{"raise", models.RoleAdmin, models.RoleShelterStaff},
+		{"without claim", "", models.RoleShelterStaff},
+		{"lower", models.RoleFoster, models.RoleFoster},
+		{"raise back", models.RoleShelterStaff, models.RoleFoster},
+		{"unknown role", "owner", models.RoleFoster},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LinkUser(tn, identity, &models.User{Email: "staff@paws.org", Role: tt.claim}, true)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.ID != user.ID {
+				t.Fatalf("LinkUser() = %s, want linked user %s", got.ID, user.ID)
+			}
+
+			if got.Role != tt.want {
+				t.Errorf("role = %q, want %q", got.Role, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkIdentity(t *testing.T) {
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+	identity := &models.Identity{Issuer: "https://idp.example.com", Subject: "1"}
+
+	staff := &models.User{Email: "staff@paws.org"}
+	other := &models.User{Email: "other@paws.org"}
+
+	for _, u := range []*models.User{staff, other} {
+		if err := CreateUser(tn, u, "password1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := LinkIdentity(tn, staff.ID, identity); err != nil {
+		t.Fatal(err)
+	}
+
+	// linking twice is not an error
+	if _, err := LinkIdentity(tn, staff.ID, identity); err != nil {
+		t.Errorf("LinkIdentity() again error = %v, want nil", err)
+	}
+
+	if _, err := LinkIdentity(tn, other.ID, identity); !errors.Is(err, ErrIdentityTaken) {
+		t.Errorf("LinkIdentity() to other user error = %v, want %v", err, ErrIdentityTaken)
+	}
+
+	// provider login now use the linked user and could not raise its role
+	got, err := LinkUser(tn, identity, &models.User{Email: "staff@paws.org", Role: models.RoleAdmin}, true)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ID != staff.ID || got.Role != models.RoleAdopter || len(got.Identities) != 1 {
+		t.Errorf("LinkUser() = %+v, want linked local user with its role", got)
+	}
+
+	if _, err = Login(tn, "staff@paws.org", "password1"); err != nil {
+		t.Errorf("Login() with password after link error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,580 @@
+// =====================
+// This package is package to store controllers for adoption applications.
+// Application is submitted by adopter for one available cat then reviewed
+// by staff, approving application would move the cat to pending adoption
+// and finalizing it would move the cat to adopted.
+// =====================
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/tenant"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Directory of application's data inside root of the tenant
+const applicationsDir = "data/applications"
+
+// Define an interface for each application controllers
+type ApplicationControllers interface {
+	// Controller to get applications, filtered by cat and status
+	GetApplications(w http.ResponseWriter, r *http.Request)
+
+	// Controller to submit application for a cat
+	SubmitApplication(w http.ResponseWriter, r *http.Request)
+
+	// Controller to get one application based on given id
+	GetApplication(w http.ResponseWriter, r *http.Request)
+
+	// Controller to change status of application
+	TransitionApplication(w http.ResponseWriter, r *http.Request)
+
+	// Controller to add staff note to application
+	AddNote(w http.ResponseWriter, r *http.Request)
+
+	// Controller to finalize approved application
+	FinalizeApplication(w http.ResponseWriter, r *http.Request)
+}
+
+// define type that would be used as the controllers of application
+type Application string
+
+// Lock of application that change status of the cat, so two
+// applications of the same cat could not be approved at once
+var applicationsMu sync.Mutex
+
+// Read one application's data
+func readApplication(t *tenant.Tenant, id string) (*models.Application, error) {
+	var app models.Application
+
+	data, err := ioutil.ReadFile(t.Path(applicationsDir, filepath.Base(id)+".json"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &app); err != nil {
+		return nil, err
+	}
+
+	return &app, nil
+}
+
+// Read all application's data, missing
+// directory means there is no application
+func readApplications(t *tenant.Tenant) (models.Applications, error) {
+	apps := models.Applications{}
+
+	files, err := ioutil.ReadDir(t.Path(applicationsDir))
+
+	if os.IsNotExist(err) {
+		return apps, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range files {
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		app, err := readApplication(t, v.Name()[:len(v.Name())-len(".json")])
+
+		if err != nil {
+			return nil, err
+		}
+
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// Write application's data, the directory
+// is created on the first application
+func writeApplication(t *tenant.Tenant, app *models.Application) error {
+	data, err := json.Marshal(app)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(t.Path(applicationsDir), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.Path(applicationsDir, app.ID.String()+".json"), data, 0644)
+}
+
+// Get approved application that hold the cat in pending adoption,
+// nil is returned when no application hold the cat
+func holdingApplication(t *tenant.Tenant, catID xid.ID) (*models.Application, error) {
+	apps, err := readApplications(t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, app := range apps {
+		if app.CatID == catID && app.Status == models.Approved && app.Finalized == nil {
+			return app, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Check if current user could review application of cat owned by the
+// shelter, staff could only review application of their shelters
+func reviews(r *http.Request, shelter xid.ID) bool {
+	user := middleware.CurrentUser(r)
+
+	return policy.Allowed(user, policy.ReviewApplication) && policy.Manages(user, shelter)
+}
+
+// Check if current user could access the application, staff could
+// access applications of their shelters and applicant could only
+// access own application
+func ownApplication(r *http.Request, app *models.Application, shelter xid.ID) bool {
+	if reviews(r, shelter) {
+		return true
+	}
+
+	user := middleware.CurrentUser(r)
+
+	return user != nil && app.UserID == user.ID
+}
+
+// Get shelter of the application's cat, deleted cat has
+// no shelter so only admin could review the application
+func shelterOf(t *tenant.Tenant, app *models.Application) (xid.ID, error) {
+	cat, err := readCat(t, app.CatID.String())
+
+	if os.IsNotExist(err) {
+		return xid.NilID(), nil
+	}
+
+	if err != nil {
+		return xid.NilID(), err
+	}
+
+	return cat.Shelter, nil
+}
+
+// Controller for root of "/applications" endpoint.
+// Applications could be filtered by "cat_id" and "status" and
+// paginated using "page" and "size", newest application first.
+// Applicant would only get own applications and staff would only
+// get applications of cats owned by their shelters.
+// Response is JSON Object of models.Page with models.Applications as items.
+// Accepted methods [GET]
+func (c Application) GetApplications(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	query := r.URL.Query()
+
+	apps, err := readApplications(t)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading applications data")
+		return
+	}
+
+	cats, err := readCats(t)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading cats data")
+		return
+	}
+
+	shelters := make(map[xid.ID]xid.ID, len(cats))
+	for _, cat := range cats {
+		shelters[cat.ID] = cat.Shelter
+	}
+
+	// filter by cat and status
+	filtered := models.Applications{}
+
+	for _, app := range apps {
+		if !ownApplication(r, app, shelters[app.CatID]) {
+			continue
+		}
+
+		if v := query.Get("cat_id"); v != "" && app.CatID.String() != v {
+			continue
+		}
+
+		if v := query.Get("status"); v != "" && app.Status != v {
+			continue
+		}
+
+		filtered = append(filtered, app)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Create.After(filtered[j].Create)
+	})
+
+	// paginate by page number
+	page, start, end, err := paginateNumber(r, len(filtered))
+
+	if err != nil {
+		problem.BadRequest(w, r, err.Error())
+		return
+	}
+
+	page.Items = filtered[start:end]
+
+	render.JSON(w, r, page)
+}
+
+// Controller to submit application at "/applications" endpoint.
+// Requested body is models.Application with cat_id, applicant and
+// questionnaire, only available cat could be applied.
+// Response is JSON Object of the submitted application
+// Accepted methods [POST]
+func (c Application) SubmitApplication(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	app := new(models.Application)
+
+	if !decodeBody(w, r, app, "error invalid application") {
+		return
+	}
+
+	// the cat must exist and still available
+	cat, err := readCat(t, app.CatID.String())
+
+	if os.IsNotExist(err) {
+		problem.Invalid(w, r, "error invalid application", validation.Errors{validation.Error("cat_id", "cat not found")})
+		return
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error reading cat data")
+		return
+	}
+
+	if cat.Status != models.Available {
+		problem.New(http.StatusConflict, "error cat is not available for adoption").Write(w, r)
+		return
+	}
+
+	now := time.Now()
+
+	app.ID = xid.New()
+	app.UserID = middleware.CurrentUser(r).ID
+	app.Create = now
+	app.Update = now
+	app.Status = models.Submitted
+	app.History = []*models.Transition{{To: models.Submitted, At: now}}
+	app.Notes = []*models.Note{}
+
+	if err = writeApplication(t, app); err != nil {
+		problem.Internal(w, r, "error write application data")
+		return
+	}
+
+	render.JSON(w, r, app)
+}
+
+// Controller to get application at "/applications/{id}" endpoint.
+// Response is JSON Object of the application
+// Accepted methods [GET]
+func (c Application) GetApplication(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	app, err := readApplication(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "application not found", "error reading application data")
+		return
+	}
+
+	shelter, err := shelterOf(t, app)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading cat data")
+		return
+	}
+
+	if !ownApplication(r, app, shelter) {
+		problem.Forbidden(w, r, "error application is not owned by user")
+		return
+	}
+
+	render.JSON(w, r, app)
+}
+
+// Controller to change status of application at "/applications/{id}/transitions"
+// endpoint. Requested body is "to" and optional "reason". Approving application
+// would move the cat to pending adoption and withdrawing approved application
+// would make the cat available again when it's still held by the application.
+// Applicant could only withdraw own application, other transition is done by staff.
+// Response is JSON Object of the updated application
+// Accepted methods [POST]
+func (c Application) TransitionApplication(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	var review struct {
+		To     string `json:"to" validate:"required,enum=application_status"`
+		Reason string `json:"reason" validate:"max=500"`
+	}
+
+	if !decodeBody(w, r, &review, "error invalid transition") {
+		return
+	}
+
+	applicationsMu.Lock()
+	defer applicationsMu.Unlock()
+
+	app, err := readApplication(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "application not found", "error reading application data")
+		return
+	}
+
+	shelter, err := shelterOf(t, app)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading cat data")
+		return
+	}
+
+	if !reviews(r, shelter) {
+		if !ownApplication(r, app, shelter) || review.To != models.Withdrawn {
+			problem.Forbidden(w, r, "error applicant could only withdraw own application")
+			return
+		}
+	}
+
+	// finalized application could not be changed
+	if app.Finalized != nil {
+		problem.New(http.StatusConflict, "error application is finalized").Write(w, r)
+		return
+	}
+
+	now := time.Now()
+	from := app.Status
+
+	// application that is leaving approved status must be checked
+	// before the transition since it's still holding the cat
+	var holder *models.Application
+
+	if from == models.Approved {
+		if holder, err = holdingApplication(t, app.CatID); err != nil {
+			problem.Internal(w, r, "error reading applications data")
+			return
+		}
+	}
+
+	if err = app.Transition(review.To, review.Reason, now); err != nil {
+		transitionError(w, r, err)
+		return
+	}
+
+	// change the cat status together with application
+	catStatus := ""
+
+	switch {
+	case review.To == models.Approved:
+		catStatus = models.PendingAdoption
+	case from == models.Approved && holder != nil && holder.ID == app.ID:
+		catStatus = models.Available
+	}
+
+	// cat before the transition to be restored when application
+	// could not be written, so both of them stay in sync
+	var cat, previous *models.Cat
+
+	if catStatus != "" {
+		cat, err = readCat(t, app.CatID.String())
+
+		// deleted cat has nothing to release
+		if os.IsNotExist(err) && catStatus == models.Available {
+			catStatus = ""
+		} else if err != nil {
+			problem.Storage(w, r, err, "cat not found", "error reading cat data")
+			return
+		}
+	}
+
+	// cat is only released when it's still pending adoption,
+	// status that was changed by staff since then is kept
+	if catStatus == models.Available && cat.Status != models.PendingAdoption {
+		catStatus = ""
+	}
+
+	if catStatus != "" {
+		// other application of the same cat might be approved
+		if catStatus == models.PendingAdoption && cat.Status != models.Available {
+			problem.New(http.StatusConflict, "error cat is not available for adoption").Write(w, r)
+			return
+		}
+
+		reason := fmt.Sprintf("application %s %s", app.ID, review.To)
+		original := *cat
+		previous = &original
+
+		if err = cat.Transition(catStatus, reason, now); err != nil {
+			transitionError(w, r, fmt.Errorf("cat: %w", err))
+			return
+		}
+
+		cat.Update = now
+
+		if err = writeCat(t, cat); err != nil {
+			problem.Internal(w, r, "error write cat data")
+			return
+		}
+	}
+
+	app.Update = now
+
+	if err = writeApplication(t, app); err != nil {
+		// request already fail when the cat could not be restored
+		if previous != nil {
+			_ = writeCat(t, previous)
+		}
+
+		problem.Internal(w, r, "error write application data")
+		return
+	}
+
+	render.JSON(w, r, app)
+}
+
+// Controller to add staff note at "/applications/{id}/notes" endpoint.
+// Requested body is models.Note with "text".
+// Response is JSON Object of the updated application
+// Accepted methods [POST]
+func (c Application) AddNote(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	note := new(models.Note)
+
+	if !decodeBody(w, r, note, "error invalid note") {
+		return
+	}
+
+	app, err := readApplication(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "application not found", "error reading application data")
+		return
+	}
+
+	shelter, err := shelterOf(t, app)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading cat data")
+		return
+	}
+
+	if !reviews(r, shelter) {
+		problem.Forbidden(w, r, "error cat is not owned by shelter of user")
+		return
+	}
+
+	note.ID = xid.New()
+	note.At = time.Now()
+
+	app.Notes = append(app.Notes, note)
+	app.Update = note.At
+
+	if err = writeApplication(t, app); err != nil {
+		problem.Internal(w, r, "error write application data")
+		return
+	}
+
+	render.JSON(w, r, app)
+}
+
+// Controller to finalize application at "/applications/{id}/finalize"
+// endpoint when the cat is handed to the adopter. Only approved
+// application could be finalized and the cat would be adopted.
+// Response is JSON Object of the updated application
+// Accepted methods [POST]
+func (c Application) FinalizeApplication(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	applicationsMu.Lock()
+	defer applicationsMu.Unlock()
+
+	app, err := readApplication(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "application not found", "error reading application data")
+		return
+	}
+
+	if app.Status != models.Approved || app.Finalized != nil {
+		problem.New(http.StatusConflict, "error only approved application that is not finalized could be finalized").Write(w, r)
+		return
+	}
+
+	cat, err := readCat(t, app.CatID.String())
+
+	if err != nil {
+		problem.Storage(w, r, err, "cat not found", "error reading cat data")
+		return
+	}
+
+	if !reviews(r, cat.Shelter) {
+		problem.Forbidden(w, r, "error cat is not owned by shelter of user")
+		return
+	}
+
+	now := time.Now()
+	previous := *cat
+
+	if err = cat.Transition(models.Adopted, fmt.Sprintf("application %s finalized", app.ID), now); err != nil {
+		transitionError(w, r, fmt.Errorf("cat: %w", err))
+		return
+	}
+
+	cat.Update = now
+
+	if err = writeCat(t, cat); err != nil {
+		problem.Internal(w, r, "error write cat data")
+		return
+	}
+
+	app.Finalized = &now
+	app.Update = now
+
+	if err = writeApplication(t, app); err != nil {
+		_ = writeCat(t, &previous)
+		problem.Internal(w, r, "error write application data")
+		return
+	}
+
+	// favorites is only removed once the adoption is written
+	if err = releaseFavorites(t, cat.ID.String()); err != nil {
+		problem.Internal(w, r, "error remove cat's favorites")
+		return
+	}
+
+	render.JSON(w, r, app)
+}
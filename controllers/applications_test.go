@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Create request of admin inside the tenant with id as url param
+func adminRequest(t *tenant.Tenant, id, body string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+
+	ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, middleware.KeyTenant, t)
+	ctx = context.WithValue(ctx, middleware.KeyUser, &models.User{ID: xid.New(), Role: models.RoleAdmin})
+
+	return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)).WithContext(ctx)
+}
+
+// Write cat and its applications in review to the tenant
+func applyingCat(t *testing.T, tn *tenant.Tenant, status string, count int) (*models.Cat, []*models.Application) {
+	t.Helper()
+
+	cat := &models.Cat{ID: xid.New(), Name: "Tom", Status: status}
+
+	if err := os.MkdirAll(tn.Path("data/cats"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeCat(tn, cat); err != nil {
+		t.Fatal(err)
+	}
+
+	apps := make([]*models.Application, count)
+
+	for i := range apps {
+		apps[i] = &models.Application{ID: xid.New(), CatID: cat.ID, UserID: xid.New(), Status: models.InReview, Create: time.Now()}
+
+		if err := writeApplication(tn, apps[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cat, apps
+}
+
+// Send transition of application and return the status code
+func transitionApplication(tn *tenant.Tenant, app *models.Application, to string) int {
+	w := httptest.NewRecorder()
+	Application("").TransitionApplication(w, adminRequest(tn, app.ID.String(), `{"to":"`+to+`"}`))
+
+	return w.Code
+}
+
+// Read status of cat
+func catStatus(t *testing.T, tn *tenant.Tenant, cat *models.Cat) string {
+	t.Helper()
+
+	got, err := readCat(tn, cat.ID.String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return got.Status
+}
+
+func TestApproveConcurrently(t *testing.T) {
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+	cat, apps := applyingCat(t, tn, models.Available, 8)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		approved int
+	)
+
+	for _, app := range apps {
+		wg.Add(1)
+
+		go func(app *models.Application) {
+			defer wg.Done()
+
+			if transitionApplication(tn, app, models.Approved) == http.StatusOK {
+				mu.Lock()
+				approved++
+				mu.Unlock()
+			}
+		}(app)
+	}
+
+	wg.Wait()
+
+	if approved != 1 {
+		t.Errorf("approved applications = %d, want 1", approved)
+	}
+
+	if got := catStatus(t, tn, cat); got != models.PendingAdoption {
+		t.Errorf("cat status = %q, want %q", got, models.PendingAdoption)
+	}
+}
+
+func TestWithdrawApproved(t *testing.T) {
+	tests := []struct {
+		name      string
+		catStatus string // status of cat after the approval, empty when it's kept
+		want      string
+	}{
+		{"cat held by application", "", models.Available},
+		{"cat changed since approval", models.OnHold, models.OnHold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+			cat, apps := applyingCat(t, tn, models.Available, 1)
+
+			if code := transitionApplication(tn, apps[0], models.Approved); code != http.StatusOK {
+				t.Fatalf("approve = %d, want %d", code, http.StatusOK)
+			}
+
+			if tt.catStatus != "" {
+				changed, _ := readCat(tn, cat.ID.String())
+				changed.Status = tt.catStatus
+
+				if err := writeCat(tn, changed); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if code := transitionApplication(tn, apps[0], models.Withdrawn); code != http.StatusOK {
+				t.Fatalf("withdraw = %d, want %d", code, http.StatusOK)
+			}
+
+			if got := catStatus(t, tn, cat); got != tt.want {
+				t.Errorf("cat status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionHeldCat(t *testing.T) {
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+	cat, apps := applyingCat(t, tn, models.Available, 1)
+
+	if code := transitionApplication(tn, apps[0], models.Approved); code != http.StatusOK {
+		t.Fatalf("approve = %d, want %d", code, http.StatusOK)
+	}
+
+	transition := func(to string) int {
+		w := httptest.NewRecorder()
+		Cat("").TransitionCat(w, adminRequest(tn, cat.ID.String(), `{"to":"`+to+`"}`))
+
+		return w.Code
+	}
+
+	// held cat could only be released by the application
+	for _, to := range []string{models.Available, models.OnHold, models.Adopted} {
+		if code := transition(to); code != http.StatusConflict {
+			t.Errorf("transition to %q = %d, want %d", to, code, http.StatusConflict)
+		}
+	}
+
+	if code := transitionApplication(tn, apps[0], models.Withdrawn); code != http.StatusOK {
+		t.Fatalf("withdraw = %d, want %d", code, http.StatusOK)
+	}
+
+	if code := transition(models.OnHold); code != http.StatusOK {
+		t.Errorf("transition of released cat = %d, want %d", code, http.StatusOK)
+	}
+}
@@ -0,0 +1,239 @@
+// =====================
+// This package is package to store controllers for user authentication.
+// Browser login with session cookie and the CSRF token, api client
+// request bearer token with the same credentials. Staff could also
+// login with OpenID Connect provider of the tenant
+// =====================
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/problem"
+)
+
+// Define an interface for each auth controllers
+type AuthControllers interface {
+	// Controller to register new user
+	Register(w http.ResponseWriter, r *http.Request)
+
+	// Controller to login and create browser session
+	Login(w http.ResponseWriter, r *http.Request)
+
+	// Controller to logout from current session or token
+	Logout(w http.ResponseWriter, r *http.Request)
+
+	// Controller to create bearer token for api client
+	IssueToken(w http.ResponseWriter, r *http.Request)
+
+	// Controller to get current user
+	Me(w http.ResponseWriter, r *http.Request)
+
+	// Controller to start login with OIDC provider of the tenant
+	OIDCLogin(w http.ResponseWriter, r *http.Request)
+
+	// Controller to finish login with OIDC provider
+	OIDCCallback(w http.ResponseWriter, r *http.Request)
+
+	// Controller to start linking OIDC provider to current user
+	OIDCLink(w http.ResponseWriter, r *http.Request)
+
+	// Controller to start two-factor enrollment
+	EnrollTwoFactor(w http.ResponseWriter, r *http.Request)
+
+	// Controller to enable two-factor with the first code
+	ConfirmTwoFactor(w http.ResponseWriter, r *http.Request)
+
+	// Controller to verify two-factor challenge of login
+	VerifyTwoFactor(w http.ResponseWriter, r *http.Request)
+
+	// Controller to replace recovery codes
+	RecoveryCodes(w http.ResponseWriter, r *http.Request)
+
+	// Controller to disable two-factor
+	DisableTwoFactor(w http.ResponseWriter, r *http.Request)
+}
+
+// define type that would be used as the controllers of auth
+type Auth string
+
+// Check the credentials of requested body, the problem is sent
+// when it's not valid so nil user means the request is done
+func (c Auth) login(w http.ResponseWriter, r *http.Request) *models.User {
+	credentials := new(models.Credentials)
+
+	if !decodeBody(w, r, credentials, "error invalid credentials") {
+		return nil
+	}
+
+	user, err := auth.Login(middleware.CurrentTenant(r), credentials.Email, credentials.Password)
+
+	if errors.Is(err, auth.ErrCredentials) {
+		problem.Unauthorized(w, r, "error "+err.Error())
+		return nil
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error reading user data")
+		return nil
+	}
+
+	return user
+}
+
+// Controller to register user at "/auth/register" endpoint.
+// Requested body is models.Registration, email could only be used once.
+// Response is JSON Object of the registered user
+// Accepted methods [POST]
+func (c Auth) Register(w http.ResponseWriter, r *http.Request) {
+	registration := new(models.Registration)
+
+	if !decodeBody(w, r, registration, "error invalid registration") {
+		return
+	}
+
+	user := &models.User{Email: registration.Email, Name: registration.Name}
+	err := auth.CreateUser(middleware.CurrentTenant(r), user, registration.Password)
+
+	if errors.Is(err, auth.ErrEmailTaken) {
+		problem.New(http.StatusConflict, "error "+err.Error()).Write(w, r)
+		return
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error write user data")
+		return
+	}
+
+	render.JSON(w, r, user)
+}
+
+// Controller to login at "/auth/login" endpoint.
+// Requested body is models.Credentials, session is sent as http only
+// cookie and the CSRF token must be sent as X-CSRF-Token header on
+// request that change data. User with two-factor get challenge
+// that is verified at "/auth/2fa/verify" instead.
+// Response is JSON Object of user and the CSRF token
+// Accepted methods [POST]
+func (c Auth) Login(w http.ResponseWriter, r *http.Request) {
+	user := c.login(w, r)
+
+	if user == nil {
+		return
+	}
+
+	if user.TwoFactor {
+		c.challenge(w, r, user, auth.KindSession)
+		return
+	}
+
+	c.startSession(w, r, user)
+}
+
+// Create browser session of user and send it as cookies
+func (c Auth) startSession(w http.ResponseWriter, r *http.Request, user *models.User) {
+	// previous session of the browser is revoked
+	// so the session could not be fixated
+	if session := middleware.CurrentSession(r); session != nil && session.Kind == auth.KindSession {
+		auth.Revoke(middleware.CurrentTenant(r), session)
+	}
+
+	token, session, err := auth.NewSession(middleware.CurrentTenant(r), user.ID, auth.KindSession)
+
+	if err != nil {
+		problem.Internal(w, r, "error create session")
+		return
+	}
+
+	auth.SetCookies(w, middleware.CurrentTenant(r), token, session)
+
+	render.JSON(w, r, map[string]interface{}{
+		"user":       user,
+		"csrf_token": session.CSRF,
+		"expires_at": session.Expires,
+	})
+}
+
+// Create challenge of user with two-factor, next
+// is created after the challenge is verified
+func (c Auth) challenge(w http.ResponseWriter, r *http.Request, user *models.User, next string) {
+	token, challenge, err := auth.NewChallenge(middleware.CurrentTenant(r), user.ID, next)
+
+	if err != nil {
+		problem.Internal(w, r, "error create two-factor challenge")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"two_factor_required": true,
+		"challenge":           token,
+		"expires_at":          challenge.Expires,
+	})
+}
+
+// Controller to logout at "/auth/logout" endpoint.
+// Current session or bearer token would be revoked.
+// Response is success message
+// Accepted methods [POST]
+func (c Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	if session := middleware.CurrentSession(r); session != nil {
+		if err := auth.Revoke(middleware.CurrentTenant(r), session); err != nil {
+			problem.Internal(w, r, "error revoke session")
+			return
+		}
+	}
+
+	auth.ClearCookies(w, middleware.CurrentTenant(r))
+
+	render.JSON(w, r, map[string]string{"message": "logged out"})
+}
+
+// Controller to create bearer token at "/auth/token" endpoint.
+// Requested body is models.Credentials, user with two-factor
+// get challenge the same as login.
+// Response is JSON Object of the token that is only sent once
+// Accepted methods [POST]
+func (c Auth) IssueToken(w http.ResponseWriter, r *http.Request) {
+	user := c.login(w, r)
+
+	if user == nil {
+		return
+	}
+
+	if user.TwoFactor {
+		c.challenge(w, r, user, auth.KindToken)
+		return
+	}
+
+	c.issueToken(w, r, user)
+}
+
+// Create bearer token of user
+func (c Auth) issueToken(w http.ResponseWriter, r *http.Request, user *models.User) {
+	token, session, err := auth.NewSession(middleware.CurrentTenant(r), user.ID, auth.KindToken)
+
+	if err != nil {
+		problem.Internal(w, r, "error create token")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_at":   session.Expires,
+	})
+}
+
+// Controller to get current user at "/auth/me" endpoint.
+// Response is JSON Object of the user
+// Accepted methods [GET]
+func (c Auth) Me(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, middleware.CurrentUser(r))
+}
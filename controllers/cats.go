@@ -9,8 +9,8 @@
 // - Filter cat by zip code [done]
 // - Search cat by name using query [done]
 // - Filter cat by variety using query [done]
-// - Filter cat by gender using query
-// - Filter cat by age using query
+// - Filter cat by gender using query [done]
+// - Filter cat by age using query [done]
 // - Upload multiple image for cat [done]
 // - Delete image by image ID [done]
 //
@@ -23,12 +23,11 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"math"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
-	"regexp"
-	"strconv"
-	"strings"
+	"path/filepath"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -37,6 +36,13 @@ import (
 
 	"github.com/ArkjuniorK/store_app/middleware"
 	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/patch"
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/search"
+	"github.com/ArkjuniorK/store_app/storage"
+	"github.com/ArkjuniorK/store_app/tenant"
+	"github.com/ArkjuniorK/store_app/validation"
 )
 
 // Define an interface for each cat controllers
@@ -47,15 +53,21 @@ type CatControllers interface {
 	// With pagination and filters
 	GetCats(w http.ResponseWriter, r *http.Request)
 
+	// Controller to count cats by each filter value
+	GetFacets(w http.ResponseWriter, r *http.Request)
+
 	// Controller to add cat to be adopted
 	AddCat(w http.ResponseWriter, r *http.Request)
 
 	// Controller to get one cat based on given id
 	GetCat(w http.ResponseWriter, r *http.Request)
 
-	// Controller to update one cat based on given id
+	// Controller to replace one cat based on given id
 	UpdateCat(w http.ResponseWriter, r *http.Request)
 
+	// Controller to partially update one cat based on given id
+	PatchCat(w http.ResponseWriter, r *http.Request)
+
 	// Controller to delete cat based on given id
 	DeleteCat(w http.ResponseWriter, r *http.Request)
 
@@ -64,6 +76,9 @@ type CatControllers interface {
 
 	// Controller to delete cat's image
 	DeleteImageCat(w http.ResponseWriter, r *http.Request)
+
+	// Controller to change adoption status of cat
+	TransitionCat(w http.ResponseWriter, r *http.Request)
 }
 
 // define type that would use as pothe controllers of cat
@@ -73,263 +88,295 @@ type CatControllers interface {
 // to hold functions inside
 type Cat string
 
+// Check if listing query is limited to a location or a shelter
+func located(query url.Values) bool {
+	return query.Get("zip_code") != "" || query.Get("near") != "" || query.Get("shelter_id") != ""
+}
+
 // Controller for root of "/cats" endpoint.
-// Response is JSON Array take from the models.Cats slices.
+// Response is JSON Object of models.Page with the models.Cats as items.
 // Accepted methods [GET]
 func (c Cat) GetCats(w http.ResponseWriter, r *http.Request) {
-	// initiate cats variable that would be hold cat entities,
-	// new would init an empty data inside referenced type
-	var (
-		cats        *models.Cats  = new(models.Cats)
-		catsWrapper []models.Cats // hold models.Cats as chunck
-	)
+	t := middleware.CurrentTenant(r)
+
+	// query for filtering and searching cats
+	// type map
+	query := r.URL.Query()
 
-	// get working directory
-	wd, err := os.Getwd()
+	// the query for zip_code, near or shelter_id would always present
+	// to make sure it easy to find adopt cat by location/region
+	if !located(query) {
+		problem.BadRequest(w, r, "error zip_code, near or shelter_id not present in request")
+		return
+	}
+
+	// first read all the file inside cat's data
+	// then set it to *cats
+	cats, err := readCats(t)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error get working directory"))
+		problem.Internal(w, r, "error reading cats data")
 		return
 	}
 
-	// get params from request
-	page, _ := strconv.ParseFloat(chi.URLParam(r, "page"), 64)
-	limit, _ := strconv.ParseFloat(chi.URLParam(r, "limit"), 64)
+	listCats(w, r, cats)
+}
 
-	// query for filtering and searching cats
-	// type map
+// Filter, sort and paginate cats the same way for every listing,
+// favorite count is set on the page for staff that manage the cat
+func listCats(w http.ResponseWriter, r *http.Request, cats models.Cats) {
 	query := r.URL.Query()
 
-	// read cats directory
-	catsDir, err := ioutil.ReadDir(wd + "/data/cats")
+	// parse the filter and search query into node,
+	// invalid query would be sent as bad request
+	node, err := search.FromQuery(query)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error reading cats data"))
+		problem.BadRequest(w, r, err.Error())
 		return
 	}
 
-	// total page for pagination
-	totalPage := math.Ceil(float64(len(catsDir)) / limit)
+	// origin of near search, distance is only
+	// known when near is present in request
+	origin, _ := search.Origin(query.Get("country"), query.Get("near"))
 
-	if page > totalPage {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error page is bigger than total page"))
-		return
-	}
+	// get the sort keys, default is ordered by id
+	// which is the same as created order or by
+	// distance when searching near a zip code
+	sort := query.Get("sort")
 
-	// first read all the file inside cat's data
-	// then set it to *cats
-	for i := 0; i < len(catsDir); i++ {
+	if sort == "" && origin != nil {
+		sort = "distance"
+	}
 
-		var cat models.Cat
+	keys, err := search.ParseSort(sort)
 
-		// read each file
-		catData, err := ioutil.ReadFile(wd + "/data/cats/" + catsDir[i].Name())
+	if err != nil {
+		problem.BadRequest(w, r, err.Error())
+		return
+	}
 
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error read cat data"))
+	for _, key := range keys {
+		if key.Field == "distance" && origin == nil {
+			problem.BadRequest(w, r, "error sort by distance require near")
 			return
 		}
+	}
 
-		// unmarshall the catData
-		err = json.Unmarshal(catData, &cat)
+	// then filter the cats using the node, set the
+	// distance from origin and sort it
+	filtered := search.Filter(cats, node)
 
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error unmarshal cat data"))
-			return
-		}
+	if origin != nil {
+		search.SetDistance(filtered, origin)
+	}
+
+	search.Sort(filtered, keys)
+
+	// age is computed at the time it's read
+	now := time.Now()
 
-		*cats = append(*cats, &cat)
+	for _, cat := range filtered {
+		cat.SetAge(now)
 	}
 
-	// second filter the cat by it's zip code
-	// the query for zip_code would always present
-	// to make sure it easy to find adopt cat by location/region
-	catsByRegion, err := func(cats *models.Cats, zip []string) (*models.Cats, error) {
-		var catsWrapper models.Cats
-
-		// check the zip_code
-		// if it's not present in requested query
-		// send an error
-		if len(zip[0]) == 0 {
-			return nil, errors.New("error zip_code not present in request")
-		}
+	// last paginate the cats
+	page, err := paginate(w, r, filtered, keys)
 
-		// process the filter here
-		// change the format of zip to int16
-		zipCode, err := strconv.ParseInt(zip[0], 0, 16)
+	if err != nil {
+		problem.BadRequest(w, r, err.Error())
+		return
+	}
 
-		if err != nil {
-			return nil, errors.New("error parse zip_code to int")
-		}
+	if err = countFavorites(middleware.CurrentTenant(r), middleware.CurrentUser(r), page.Items.(models.Cats)); err != nil {
+		problem.Internal(w, r, "error reading favorites data")
+		return
+	}
 
-		// loop the cats data to compare each
-		// cat zip_code to requested zip_code
-		// then append the filtered cat to catsWrapper
-		for i := 0; i < len(*cats); i++ {
-			if zipCode == int64((*cats)[i].ZipCode) {
-				catsWrapper = append(catsWrapper, (*cats)[i])
-			}
-		}
+	// send the response to client
+	render.JSON(w, r, page)
+}
 
-		return &catsWrapper, nil
-	}(cats, query["zip_code"])
+// Controller for counting cats by each filter at "/cats/facets" endpoint.
+// Cats is filtered the same way as GetCats, except each facet ignore
+// its own filter so other option of the filter is still counted.
+// Response is JSON Object of total and count of each facet value.
+// Accepted methods [GET]
+func (c Cat) GetFacets(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	query := r.URL.Query()
+
+	// zip_code, near or shelter_id is required the same as GetCats
+	if !located(query) {
+		problem.BadRequest(w, r, "error zip_code, near or shelter_id not present in request")
+		return
+	}
+
+	node, err := search.FromQuery(query)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
+		problem.BadRequest(w, r, err.Error())
 		return
 	}
 
-	// third verify if query["name"] is enabled
-	// if it does then search for match cat name
-	// but it doesn't return cats param
-	catsByName := func(cats *models.Cats, name []string) *models.Cats {
-		// name is defined
-		// search the cat by given name
-		if len(name) != 0 {
-			// wrapper for cat name that match with the filter
-			var catsWrapper models.Cats
+	cats, err := readCats(t)
 
-			// set regexp for name
-			validName := regexp.MustCompile(name[0])
+	if err != nil {
+		problem.Internal(w, r, "error reading cats data")
+		return
+	}
 
-			// loop the cats to get each detail data
-			// to compare the validName with cat name
-			for i := 0; i < len(*cats); i++ {
-				if validName.MatchString(strings.ToLower((*cats)[i].Name)) {
-					catsWrapper = append(catsWrapper, (*cats)[i])
-				}
-			}
+	facets, err := search.Count(cats, query)
 
-			return &catsWrapper
-		}
+	if err != nil {
+		problem.BadRequest(w, r, err.Error())
+		return
+	}
 
-		// when name is not defined
-		return cats
-	}(catsByRegion, query["name"])
-
-	// next filter the the catsByName
-	// with veriety if it defined/enabled
-	catsByVariety := func(cats *models.Cats, variety []string) *models.Cats {
-		// variety is defined
-		// then filter the cats
-		if len(variety) != 0 {
-			// wrapper for filtered cats
-			var catsWrapper models.Cats
-
-			// loop the cats to find the match variety
-			// then append it to catsWrapper so it could be returned
-			for i := 0; i < len(*cats); i++ {
-				if variety[0] == (*cats)[i].Variety {
-					catsWrapper = append(catsWrapper, (*cats)[i])
-				}
-			}
+	render.JSON(w, r, map[string]interface{}{
+		"total":  len(search.Filter(cats, node)),
+		"facets": facets,
+	})
+}
 
-			return &catsWrapper
-		}
+// Normalize address and birthdate of cat before it's validated and
+// set its location from the postal code. Field that is computed on
+// reading is cleared so it's never stored
+func prepare(cat *models.Cat) {
+	cat.Address.Normalize()
+	cat.Birth.Normalize()
+	cat.Location = search.Locate(&models.Cat{Address: cat.Address})
+	cat.Distance = nil
+	cat.Age = nil
+	cat.LifeStage = ""
+	cat.Favorites = nil
+}
 
-		// when variety is not defined
-		return cats
-	}(catsByName, query["variety"])
-
-	// then filter cats by it's gender if
-	// query["gender"] is defined
-	catsByGender := func(cats *models.Cats, gender []string) *models.Cats {
-		// filter by gender is defined
-		if len(gender) != 0 {
-			// wrapper for filtered cats
-			var catsWrapper models.Cats
-
-			// loop the cats to find the match gender
-			// then append it to catsWrapper so it could be returned
-			for i := 0; i < len(*cats); i++ {
-				if gender[0] == (*cats)[i].Gender {
-					catsWrapper = append(catsWrapper, (*cats)[i])
-				}
-			}
+// Check if current user manage shelter of the cat, the problem is
+// sent when it's not so false means the request is done
+func managesCat(w http.ResponseWriter, r *http.Request, cat *models.Cat) bool {
+	if !policy.Manages(middleware.CurrentUser(r), cat.Shelter) {
+		problem.Forbidden(w, r, "error cat is not owned by shelter of user")
+		return false
+	}
 
-			return &catsWrapper
-		}
+	return true
+}
 
-		// gender is not defined
-		return cats
-	}(catsByVariety, query["gender"])
+// Check if shelter of the cat exist and managed by current user,
+// the problem is sent when it's not so false means the request is done
+func checkShelter(w http.ResponseWriter, r *http.Request, cat *models.Cat) bool {
+	_, err := readShelter(middleware.CurrentTenant(r), cat.Shelter.String())
 
-	// last filter the cats by age
-	// the query takes two value [min, max]
-	// so the logic would compare that ages is not more
-	// than the min and less than the max number
-	catsByAge, err := func(cats *models.Cats, age []string) (*models.Cats, error) {
-		// age is defined
-		if len(age) != 0 {
-			// wrapper for filtered cats
-			var catsWrapper models.Cats
+	if os.IsNotExist(err) {
+		problem.Invalid(w, r, "error invalid cat data", validation.Errors{validation.Error("shelter_id", "shelter not found")})
+		return false
+	}
 
-			// split the age to get the min and max value
-			splittedAge := strings.Split(age[0], ",")
+	if err != nil {
+		problem.Internal(w, r, "error reading shelter data")
+		return false
+	}
 
-			// change the format of min (string) to int
-			min, err := strconv.ParseInt(splittedAge[0], 0, 16)
+	return managesCat(w, r, cat)
+}
 
-			if err != nil {
-				return nil, errors.New("error parse min to int")
-			}
+// Get path of cat's data inside "data/cats" of the tenant
+func catPath(t *tenant.Tenant, id string) string {
+	return t.Path("data/cats", filepath.Base(id)+".json")
+}
 
-			// change the format of max (string) to int
-			max, err := strconv.ParseInt(splittedAge[1], 0, 16)
+// Read all cat's data inside "data/cats" of the tenant
+func readCats(t *tenant.Tenant) (models.Cats, error) {
+	var cats models.Cats
 
-			if err != nil {
-				return nil, errors.New("error parse max to int")
-			}
+	// read cats directory
+	catsDir, err := ioutil.ReadDir(t.Path("data/cats"))
 
-			// loop the cats data to get equivalent
-			// age of cats
-			for i := 0; i < len(*cats); i++ {
-				if min <= int64((*cats)[i].Age) && max >= int64((*cats)[i].Age) {
-					catsWrapper = append(catsWrapper, (*cats)[i])
-				}
-			}
+	if err != nil {
+		return nil, err
+	}
 
-			return &catsWrapper, nil
+	for _, v := range catsDir {
+		var cat models.Cat
+
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
 		}
 
-		// age is not defined
-		return cats, nil
-	}(catsByGender, query["age"])
+		// read each file
+		catData, err := ioutil.ReadFile(t.Path("data/cats", v.Name()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		// unmarshall the catData
+		if err = json.Unmarshal(catData, &cat); err != nil {
+			return nil, err
+		}
+
+		cats = append(cats, &cat)
+	}
+
+	return cats, nil
+}
+
+// Read one cat's data inside "data/cats" of the tenant
+func readCat(t *tenant.Tenant, id string) (*models.Cat, error) {
+	var cat models.Cat
+
+	data, err := ioutil.ReadFile(catPath(t, id))
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(err.Error()))
-		return
+		return nil, err
 	}
 
-	// loop through cats data and slice it into chunk
-	// that would be use to paginate cats data
-	for i := 0; i < len(*catsByAge); i += int(limit) {
+	if err = json.Unmarshal(data, &cat); err != nil {
+		return nil, err
+	}
 
-		// length of data
-		length := i + int(limit)
+	return &cat, nil
+}
 
-		// when length is bigger than len of data
-		// then assign length to len of data
-		// to avoid bound of length
-		if length > len(*catsByAge) {
-			length = len(*catsByAge)
-		}
+// Write cat's data to "data/cats" of the tenant
+func writeCat(t *tenant.Tenant, cat *models.Cat) error {
+	data, err := json.Marshal(cat)
 
-		// we slice the data into chuck and append it
-		// to catWrapper
-		wrapperChunk := (*catsByAge)[i:length]
-		catsWrapper = append(catsWrapper, wrapperChunk)
+	if err != nil {
+		return err
 	}
 
-	// send the response to client
-	render.JSON(w, r, catsWrapper[int(page)-1])
+	return ioutil.WriteFile(catPath(t, cat.ID.String()), data, 0644)
+}
+
+// Decode requested body into v then validate it,
+// the problem is sent when it's not valid
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}, detail string) bool {
+	var fields map[string]json.RawMessage
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		problem.BadRequest(w, r, "error reading requested body")
+		return false
+	}
+
+	if err = json.Unmarshal(body, &fields); err != nil || fields == nil {
+		problem.BadRequest(w, r, "error unmarshal requested body")
+		return false
+	}
+
+	errs := validation.Decode(v, fields)
+	errs = append(errs, validation.Struct(v)...)
+
+	if len(errs) != 0 {
+		problem.Invalid(w, r, detail, errs)
+		return false
+	}
+
+	return true
 }
 
 // Controller for post new cat at "/cats" endpoint.
@@ -337,8 +384,13 @@ func (c Cat) GetCats(w http.ResponseWriter, r *http.Request) {
 // More specify the res would be the new cat that have been posted.
 // Accepted methods [POST]
 func (c Cat) AddCat(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
 	// initiate cat variabels
-	var cat *models.Cat
+	var (
+		cat    *models.Cat = new(models.Cat)
+		fields map[string]json.RawMessage
+	)
 
 	// get the body from request
 	// since it is in []byte format change it to JSON
@@ -346,53 +398,66 @@ func (c Cat) AddCat(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error reading cats data"))
+		problem.BadRequest(w, r, "error reading requested body")
 		return
 	}
 
-	// unmarshal the body to change the format to JSON
-	err = json.Unmarshal(body, &cat)
+	// unmarshal the body to map so each field
+	// could be decoded and validated one by one
+	err = json.Unmarshal(body, &fields)
 
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error unmarshal requested body"))
+	if err != nil || fields == nil {
+		problem.BadRequest(w, r, "error unmarshal requested body")
 		return
 	}
 
-	// check for zip code
-	if cat.ZipCode == 0 {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error zip code is required"))
+	// decode the fields to cat, unknown field and
+	// mismatched type would be collected as violation
+	errs := validation.Decode(cat, fields)
+
+	// generate an id for requsted body
+	// also with create and update key,
+	// image is added by upload image controller
+	cat.ID = xid.New()
+	cat.Create = time.Now()
+	cat.Update = cat.Create
+	cat.Image = nil
+	prepare(cat)
+
+	// new cat is always started at intake,
+	// it's changed later using transition
+	cat.Status = models.Intake
+	cat.History = []*models.Transition{{To: models.Intake, At: cat.Create}}
+
+	// validate the cat then send all violations at once
+	errs = append(errs, validation.Struct(cat)...)
+
+	if len(errs) != 0 {
+		problem.Invalid(w, r, "error invalid cat data", errs)
 		return
 	}
 
-	// check for address
-	if cat.Address == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error address is required"))
+	// cat could only be added to shelter of the staff
+	if !checkShelter(w, r, cat) {
 		return
 	}
 
-	// generate an id for requsted body
-	// also with create and update key
-	cat.ID = xid.New()
-	cat.Create = time.Now()
-	cat.Update = time.Now()
-
 	// change the format of requsted body back to JSON
 	data, err := json.Marshal(*cat)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error marshal cat"))
+		problem.Internal(w, r, "error marshal cat")
 		return
 	}
 
 	// then write it to file and save it with generated id as filename
-	ioutil.WriteFile("data/cats/"+cat.ID.String()+".json", data, 0644)
+	if err = ioutil.WriteFile(catPath(t, cat.ID.String()), data, 0644); err != nil {
+		problem.Internal(w, r, "error write cat data")
+		return
+	}
 
 	// send response
+	cat.SetAge(time.Now())
 	render.JSON(w, r, cat)
 }
 
@@ -400,30 +465,44 @@ func (c Cat) AddCat(w http.ResponseWriter, r *http.Request) {
 // Response is JSON Object take from models.Cat struct.
 // Accepted methods [GET]
 func (c Cat) GetCat(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
 	// get the params
 	id := chi.URLParam(r, "id")
 
 	// read data cat based on given id
-	data, err := ioutil.ReadFile("data/cats/" + id + ".json")
+	data, err := ioutil.ReadFile(catPath(t, id))
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error reading cat data"))
+		problem.Storage(w, r, err, "cat not found", "error reading cat data")
+		return
+	}
+
+	// change the data to struct
+	var cat models.Cat
+
+	if err = json.Unmarshal(data, &cat); err != nil {
+		problem.Internal(w, r, "error unmarshal cat data")
 		return
 	}
 
 	// send struct type data as json to client
-	render.JSON(w, r, data)
+	cat.SetAge(time.Now())
+	render.JSON(w, r, cat)
 }
 
 // Controller for update cat entity at /cats/{id} endpoint.
+// Requested body is the full cat that would replace the stored cat,
+// missing field would be cleared and immutable field could be omitted.
 // Response is JSON Object from updated cat
 // Accepted methods [PUT]
 func (c Cat) UpdateCat(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
 	// initiate cat variable
 	var (
-		mcat  models.CatMap // store from file
-		mrcat models.CatMap // store from body
+		cat    models.Cat                 // store from file
+		fields map[string]json.RawMessage // store from body
 	)
 
 	// get the requested id and body
@@ -431,86 +510,231 @@ func (c Cat) UpdateCat(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error reading requested body"))
+		problem.BadRequest(w, r, "error reading requested body")
 		return
 	}
 
 	// find data of cat using id
-	file, err := ioutil.ReadFile("data/cats/" + id + ".json")
+	file, err := ioutil.ReadFile(catPath(t, id))
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error reading cat data"))
+		problem.Storage(w, r, err, "cat not found", "error reading cat data")
 		return
 	}
 
-	// unmarshall body and file to map type
-	if err = json.Unmarshal(body, &mrcat); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error unmarshall requested body"))
+	// unmarshall body to map and file to struct
+	if err = json.Unmarshal(body, &fields); err != nil || fields == nil {
+		problem.BadRequest(w, r, "error unmarshall requested body")
 		return
 	}
 
-	if err = json.Unmarshal(file, &mcat); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error unmarshall cat data"))
+	if err = json.Unmarshal(file, &cat); err != nil {
+		problem.Internal(w, r, "error unmarshall cat data")
 		return
 	}
 
-	// using map make us easy to compare each field
-	// since it could use for loop
-	for k := range mcat {
-		// check for requested field
-		// if it's nil then do not loop
-		if mrcat[k] != nil {
-			// check if the field in cat and rcat isn't same
-			// if condition fulfilled, do the update inside
-			if mcat[k] != mrcat[k] {
-				mcat[k] = mrcat[k]
-			}
-		}
+	// start from empty cat so the requested body replace
+	// the whole cat, only immutable field is kept
+	updated := models.Cat{
+		ID:     cat.ID,
+		Create: cat.Create,
+		Update: cat.Update,
+		Image:  cat.Image,
+	}
+
+	errs := validation.Decode(&updated, fields)
+
+	c.replace(w, r, &cat, &updated, errs)
+}
+
+// Controller for partial update of cat entity at /cats/{id} endpoint.
+// Requested body is JSON Merge Patch or JSON Patch based on content type,
+// the patched cat is validated the same way as full update.
+// Response is JSON Object from updated cat
+// Accepted methods [PATCH]
+func (c Cat) PatchCat(w http.ResponseWriter, r *http.Request) {
+	var (
+		cat     models.Cat
+		updated models.Cat
+		fields  map[string]json.RawMessage
+		id      = chi.URLParam(r, "id")
+		t       = middleware.CurrentTenant(r)
+	)
+
+	// get the format of patch from content type
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if contentType != patch.MergePatch && contentType != patch.JSONPatch {
+		w.Header().Set("Accept-Patch", patch.MergePatch+", "+patch.JSONPatch)
+		problem.New(http.StatusUnsupportedMediaType, "error content type must be "+
+			patch.MergePatch+" or "+patch.JSONPatch).Write(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		problem.BadRequest(w, r, "error reading requested body")
+		return
+	}
+
+	// find data of cat using id
+	file, err := ioutil.ReadFile(catPath(t, id))
+
+	if err != nil {
+		problem.Storage(w, r, err, "cat not found", "error reading cat data")
+		return
+	}
+
+	if err = json.Unmarshal(file, &cat); err != nil {
+		problem.Internal(w, r, "error unmarshall cat data")
+		return
+	}
+
+	// apply the patch to stored cat
+	var patched []byte
+
+	if contentType == patch.MergePatch {
+		patched, err = patch.Merge(file, body)
+	} else {
+		patched, err = patch.Apply(file, body)
+	}
+
+	switch {
+	case errors.Is(err, patch.ErrTestFailed):
+		problem.New(http.StatusConflict, err.Error()).Write(w, r)
+		return
+	case errors.Is(err, patch.ErrInvalid):
+		problem.BadRequest(w, r, err.Error())
+		return
+	case err != nil:
+		problem.New(http.StatusUnprocessableEntity, err.Error()).Write(w, r)
+		return
+	}
+
+	// patched document must still be a cat
+	if err = json.Unmarshal(patched, &fields); err != nil || fields == nil {
+		problem.New(http.StatusUnprocessableEntity, "error patched cat is not an object").Write(w, r)
+		return
+	}
+
+	// removed field would be cleared since
+	// the patched cat is decoded to empty cat
+	errs := validation.Decode(&updated, fields)
+
+	c.replace(w, r, &cat, &updated, errs)
+}
+
+// Validate updated cat against the stored cat then write it to file,
+// used by full and partial update so both validated the same way.
+// errs is violations found when decoding the updated cat
+func (c Cat) replace(w http.ResponseWriter, r *http.Request, cat, updated *models.Cat, errs validation.Errors) {
+	t := middleware.CurrentTenant(r)
+
+	if !managesCat(w, r, cat) {
+		return
+	}
+
+	// status is only changed by transition
+	updated.Status = cat.Status
+	updated.History = cat.History
+
+	// make sure immutable field is not changed
+	// and the result is still valid cat
+	prepare(updated)
+	errs = append(errs, validation.Immutable(cat, updated)...)
+	errs = append(errs, validation.Struct(updated)...)
+
+	if len(errs) != 0 {
+		problem.Invalid(w, r, "error invalid cat data", errs)
+		return
+	}
+
+	// moved cat must be moved to shelter of the staff too
+	if updated.Shelter != cat.Shelter && !checkShelter(w, r, updated) {
+		return
 	}
 
 	// then update the value of cat Update key
-	mcat["updated_at"] = time.Now()
+	updated.Update = time.Now()
 
 	// chnage the format to []byte
-	data, err := json.Marshal(mcat)
+	data, err := json.Marshal(updated)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error marshall cat data"))
+		problem.Internal(w, r, "error marshall cat data")
 		return
 	}
 
 	// write to file
-	if err = ioutil.WriteFile("data/cats/"+id+".json", data, 0644); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error write updated cat data"))
+	if err = ioutil.WriteFile(catPath(t, cat.ID.String()), data, 0644); err != nil {
+		problem.Internal(w, r, "error write updated cat data")
 		return
 	}
 
 	// send cat struct to client as json
-	render.JSON(w, r, data)
+	updated.SetAge(time.Now())
+	render.JSON(w, r, updated)
 }
 
 // Controller for delete cat entity based on id
 // Response is success message
 // Accepted methods [DELETE]
 func (c Cat) DeleteCat(w http.ResponseWriter, r *http.Request) {
+	var (
+		cat *models.Cat
+		t   = middleware.CurrentTenant(r)
+		wd  = t.Root
+	)
+
 	// get id from params
 	id := chi.URLParam(r, "id")
 
-	// find the cat data using id
-	err := os.Remove("data/cats/" + id + ".json")
+	// read the cat data first so the image
+	// reference could be released
+	file, err := ioutil.ReadFile(catPath(t, id))
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error reading cat data"))
+		problem.Storage(w, r, err, "cat not found", "error reading cat data")
 		return
 	}
 
+	if err = json.Unmarshal(file, &cat); err != nil {
+		problem.Internal(w, r, "error unmarshal cat data")
+		return
+	}
+
+	if !managesCat(w, r, cat) {
+		return
+	}
+
+	// delete the cat data
+	err = os.Remove(catPath(t, id))
+
+	if err != nil {
+		problem.Internal(w, r, "error deleting cat data")
+		return
+	}
+
+	// favorites of deleted cat is not needed anymore
+	if err = releaseFavorites(t, id); err != nil {
+		problem.Internal(w, r, "error remove cat's favorites")
+		return
+	}
+
+	// release each image, image would be deleted
+	// from disk when no other cat use it
+	if cat.Image != nil {
+		for _, v := range *cat.Image {
+			for _, filename := range storage.LinkFiles(v) {
+				if _, err = storage.Release(wd, filename); err != nil {
+					problem.Internal(w, r, "error release cat's image")
+					return
+				}
+			}
+		}
+	}
+
 	render.PlainText(w, r, "Success deleting cat")
 }
 
@@ -522,7 +746,9 @@ func (c Cat) UploadImageCat(w http.ResponseWriter, r *http.Request) {
 	var (
 		cat   *models.Cat  = new(models.Cat)
 		link  *models.Link = new(models.Link)
-		wd, _              = os.Getwd()
+		files []string
+		t     = middleware.CurrentTenant(r)
+		wd    = t.Root
 	)
 
 	// get id from url params
@@ -534,21 +760,35 @@ func (c Cat) UploadImageCat(w http.ResponseWriter, r *http.Request) {
 
 	// change format of filename to string using fmt
 	filename := fmt.Sprintf("%v", filenameCxt)
+	files = append(files, filename)
+
+	// animated image also has poster
+	poster, animated := r.Context().Value(middleware.KeyPoster).(string)
+	if animated {
+		files = append(files, poster)
+	}
+
+	// remove images from storage when it's not used
+	discard := func() error {
+		for _, v := range files {
+			if err := storage.Discard(wd, v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
 
 	// read cat data
-	catData, err := ioutil.ReadFile("data/cats/" + id + ".json")
+	catData, err := ioutil.ReadFile(catPath(t, id))
 
 	if err != nil {
-		// remove image from storage
-		err = os.Remove(wd + "/static/cats/" + filename + ".webp")
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error delete cat image"))
+		if err := discard(); err != nil {
+			problem.Internal(w, r, "error delete cat image")
 			return
 		}
 
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error read cat data"))
+		problem.Storage(w, r, err, "cat not found", "error read cat data")
 		return
 	}
 
@@ -556,63 +796,92 @@ func (c Cat) UploadImageCat(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(catData, &cat)
 
 	if err != nil {
-		// remove image from storage
-		err = os.Remove(wd + "/static/cats/" + filename + ".webp")
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error delete cat image"))
+		if err = discard(); err != nil {
+			problem.Internal(w, r, "error delete cat image")
+			return
+		}
+
+		problem.Internal(w, r, "error unmarshal cat data")
+		return
+	}
+
+	if !policy.Manages(middleware.CurrentUser(r), cat.Shelter) {
+		if err = discard(); err != nil {
+			problem.Internal(w, r, "error delete cat image")
 			return
 		}
 
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error unmarshal cat data"))
+		problem.Forbidden(w, r, "error cat is not owned by shelter of user")
 		return
 	}
 
 	// assign link
 	link.ID = xid.New()
-	link.URL = r.Host + "/static/cats/" + filename + ".webp"
+	link.URL = r.Host + t.StaticPath() + filename
+
+	if animated {
+		link.Animated = true
+		link.Poster = r.Host + t.StaticPath() + poster
+	}
 
 	// add image to cat
-	// init cat.Image slices first then append link
-	cat.Image = new(models.Picture)
+	// init cat.Image slices first when it's empty then append link
+	if cat.Image == nil {
+		cat.Image = new(models.Picture)
+	}
 	*cat.Image = append(*cat.Image, link)
 
 	// change cat struct to byte
 	data, err := json.Marshal(cat)
 
 	if err != nil {
-		// remove image from storage
-		err = os.Remove(wd + "/static/cats/" + filename + ".webp")
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error delete cat image"))
+		if err = discard(); err != nil {
+			problem.Internal(w, r, "error delete cat image")
 			return
 		}
 
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error encode cat data to bytes"))
+		problem.Internal(w, r, "error encode cat data to bytes")
 		return
 	}
 
+	// add reference to image before writing cat data
+	// so the image would not be discarded by other request
+	for i, v := range files {
+		if err = storage.Acquire(wd, v); err != nil {
+			// release what has been acquired
+			// and discard the rest
+			for _, acquired := range files[:i] {
+				storage.Release(wd, acquired)
+			}
+
+			for _, saved := range files[i:] {
+				storage.Discard(wd, saved)
+			}
+
+			problem.Internal(w, r, "error reference cat image")
+			return
+		}
+	}
+
 	// write update to file data
-	err = ioutil.WriteFile(wd+"/data/cats/"+id+".json", data, 0644)
+	err = ioutil.WriteFile(catPath(t, id), data, 0644)
 
 	if err != nil {
-		// remove image from storage
-		err = os.Remove(wd + "/static/cats/" + filename + ".webp")
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error delete cat image"))
-			return
+		// release the reference, image would be
+		// removed from storage when it's not used
+		for _, v := range files {
+			if _, err = storage.Release(wd, v); err != nil {
+				problem.Internal(w, r, "error delete cat image")
+				return
+			}
 		}
 
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error write cat data"))
+		problem.Internal(w, r, "error write cat data")
 		return
 	}
 
 	// send response
+	cat.SetAge(time.Now())
 	render.JSON(w, r, cat)
 }
 
@@ -621,19 +890,19 @@ func (c Cat) UploadImageCat(w http.ResponseWriter, r *http.Request) {
 // Accepted methods [DELETE]
 func (c Cat) DeleteImageCat(w http.ResponseWriter, r *http.Request) {
 	var (
-		filename *[]string = new([]string)
+		files    []string
 		cat      *models.Cat
 		id       = chi.URLParam(r, "id")
 		id_image = chi.URLParam(r, "id_image")
-		wd, _    = os.Getwd()
+		t        = middleware.CurrentTenant(r)
+		wd       = t.Root
 	)
 
 	// find cat data
-	file, err := ioutil.ReadFile(wd + "/data/cats/" + id + ".json")
+	file, err := ioutil.ReadFile(catPath(t, id))
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("error read cat data"))
+		problem.Storage(w, r, err, "cat not found", "error read cat data")
 		return
 	}
 
@@ -641,8 +910,16 @@ func (c Cat) DeleteImageCat(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(file, &cat)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error unmarshal cat file"))
+		problem.Internal(w, r, "error unmarshal cat file")
+		return
+	}
+
+	if !managesCat(w, r, cat) {
+		return
+	}
+
+	if cat.Image == nil {
+		problem.NotFound(w, r, "cat's image not found")
 		return
 	}
 
@@ -652,46 +929,42 @@ func (c Cat) DeleteImageCat(w http.ResponseWriter, r *http.Request) {
 	// then delete current index of image using append
 	for i, v := range *cat.Image {
 		if v.ID.String() == id_image {
-			*filename = strings.SplitAfter(v.URL, r.Host)
+			files = storage.LinkFiles(v)
 			*cat.Image = append((*cat.Image)[:i], (*cat.Image)[i+1:]...)
+			break
 		}
 	}
 
-	// change cat to byte
-	data, err := json.Marshal(cat)
-
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error marshal cat data"))
+	if len(files) == 0 {
+		problem.NotFound(w, r, "cat's image not found")
 		return
 	}
 
-	// save changes by write to file
-	err = ioutil.WriteFile(wd+"/data/cats/"+id+".json", data, 0644)
+	// change cat to byte
+	data, err := json.Marshal(cat)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error write cat data"))
+		problem.Internal(w, r, "error marshal cat data")
 		return
 	}
 
-	// check if image is written in disk
-	_, err = ioutil.ReadFile(wd + (*filename)[1])
+	// save changes by write to file
+	err = ioutil.WriteFile(catPath(t, id), data, 0644)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error read cat's image"))
+		problem.Internal(w, r, "error write cat data")
 		return
 	}
 
-	// delete image from disk
-	err = os.Remove(wd + (*filename)[1])
-
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("error deleting cat's image"))
-		return
+	// release the image, it would only be deleted
+	// from disk when no other cat use it
+	for _, v := range files {
+		if _, err = storage.Release(wd, v); err != nil {
+			problem.Internal(w, r, "error deleting cat's image")
+			return
+		}
 	}
 
+	cat.SetAge(time.Now())
 	render.JSON(w, r, cat)
 }
@@ -0,0 +1,313 @@
+// =====================
+// This package is package to store controllers for favorite cats.
+// Favorites is stored per cat inside "data/favorites" so the count
+// of each cat is read from one file and favorites of the cat is
+// removed at once when it's adopted or deleted
+// =====================
+
+package controllers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Directory of favorite's data inside root of the tenant
+const favoritesDir = "data/favorites"
+
+// Define an interface for each favorite controllers
+type FavoriteControllers interface {
+	// Controller to get favorite cats of current user
+	GetFavorites(w http.ResponseWriter, r *http.Request)
+
+	// Controller to favorite cat
+	AddFavorite(w http.ResponseWriter, r *http.Request)
+
+	// Controller to unfavorite cat
+	RemoveFavorite(w http.ResponseWriter, r *http.Request)
+}
+
+// define type that would be used as the controllers of favorite
+type Favorite string
+
+// favorite type store user that favorite the cat
+type favorite struct {
+	User   xid.ID    `json:"user_id"`
+	Create time.Time `json:"created_at"`
+}
+
+// Lock so favorite of the same cat is not lost
+var favoritesMu sync.Mutex
+
+// Get the path of cat's favorites
+func favoritesPath(t *tenant.Tenant, catID string) string {
+	return t.Path(favoritesDir, filepath.Base(catID)+".json")
+}
+
+// Read favorites of cat, missing file means nobody favorite it
+func readFavorites(t *tenant.Tenant, catID string) ([]*favorite, error) {
+	var favorites []*favorite
+
+	data, err := ioutil.ReadFile(favoritesPath(t, catID))
+
+	if os.IsNotExist(err) {
+		return favorites, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &favorites); err != nil {
+		return nil, err
+	}
+
+	return favorites, nil
+}
+
+// Write favorites of cat, the file is removed when it's empty
+func writeFavorites(t *tenant.Tenant, catID string, favorites []*favorite) error {
+	if len(favorites) == 0 {
+		return dropFavorites(t, catID)
+	}
+
+	data, err := json.Marshal(favorites)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(t.Path(favoritesDir), 0755); err != nil {
+		return err
+	}
+
+	// write to temporary file first then rename it so reader
+	// without the lock would never read incomplete favorites
+	path := favoritesPath(t, catID)
+	tmp := path + ".tmp"
+
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// Function to remove all favorites of cat, it must
+// be called after the cat is adopted or deleted
+func releaseFavorites(t *tenant.Tenant, catID string) error {
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+
+	return dropFavorites(t, catID)
+}
+
+// Remove favorites file of cat without the lock
+func dropFavorites(t *tenant.Tenant, catID string) error {
+	err := os.Remove(favoritesPath(t, catID))
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Set favorite count of cats that is managed by user,
+// other user never see how many favorite the cat has
+func countFavorites(t *tenant.Tenant, user *models.User, cats models.Cats) error {
+	for _, cat := range cats {
+		if !policy.Allowed(user, policy.ReadFavoriteCount) || !policy.Manages(user, cat.Shelter) {
+			continue
+		}
+
+		favorites, err := readFavorites(t, cat.ID.String())
+
+		if err != nil {
+			return err
+		}
+
+		count := len(favorites)
+		cat.Favorites = &count
+	}
+
+	return nil
+}
+
+// Controller for root of "/favorites" endpoint.
+// Cats could be filtered, sorted and paginated the same as "/cats"
+// except location is not required, cat that is not found is skipped.
+// Response is JSON Object of models.Page with the models.Cats as items.
+// Accepted methods [GET]
+func (c Favorite) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	var (
+		t    = middleware.CurrentTenant(r)
+		user = middleware.CurrentUser(r)
+		cats = models.Cats{}
+	)
+
+	files, err := ioutil.ReadDir(t.Path(favoritesDir))
+
+	if err != nil && !os.IsNotExist(err) {
+		problem.Internal(w, r, "error reading favorites data")
+		return
+	}
+
+	for _, v := range files {
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		id := strings.TrimSuffix(v.Name(), ".json")
+
+		favorites, err := readFavorites(t, id)
+
+		if err != nil {
+			problem.Internal(w, r, "error reading favorites data")
+			return
+		}
+
+		for _, f := range favorites {
+			if f.User != user.ID {
+				continue
+			}
+
+			cat, err := readCat(t, id)
+
+			if os.IsNotExist(err) {
+				break
+			}
+
+			if err != nil {
+				problem.Internal(w, r, "error reading cats data")
+				return
+			}
+
+			cats = append(cats, cat)
+			break
+		}
+	}
+
+	listCats(w, r, cats)
+}
+
+// Controller to favorite cat at "/favorites/{id}" endpoint,
+// favorite the same cat twice is not an error. Adopted cat
+// could not be favorited since its favorites is removed.
+// Response is JSON Object of the cat
+// Accepted methods [PUT]
+func (c Favorite) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	var (
+		t    = middleware.CurrentTenant(r)
+		user = middleware.CurrentUser(r)
+	)
+
+	// cat is read with the lock so favorite is not
+	// added after the cat is adopted and cleaned up
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+
+	cat, err := readCat(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "cat not found", "error reading cat data")
+		return
+	}
+
+	if cat.Status == models.Adopted {
+		problem.New(http.StatusConflict, "error cat is already adopted").Write(w, r)
+		return
+	}
+
+	favorites, err := readFavorites(t, cat.ID.String())
+
+	if err != nil {
+		problem.Internal(w, r, "error reading favorites data")
+		return
+	}
+
+	found := false
+	for _, f := range favorites {
+		if f.User == user.ID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		favorites = append(favorites, &favorite{User: user.ID, Create: time.Now()})
+
+		if err = writeFavorites(t, cat.ID.String(), favorites); err != nil {
+			problem.Internal(w, r, "error write favorites data")
+			return
+		}
+	}
+
+	cat.SetAge(time.Now())
+
+	if err = countFavorites(t, user, models.Cats{cat}); err != nil {
+		problem.Internal(w, r, "error reading favorites data")
+		return
+	}
+
+	render.JSON(w, r, cat)
+}
+
+// Controller to unfavorite cat at "/favorites/{id}" endpoint, cat
+// that is not favorited or deleted could still be unfavorited.
+// Response is success message
+// Accepted methods [DELETE]
+func (c Favorite) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	var (
+		t    = middleware.CurrentTenant(r)
+		user = middleware.CurrentUser(r)
+		id   = chi.URLParam(r, "id")
+	)
+
+	favoritesMu.Lock()
+	defer favoritesMu.Unlock()
+
+	favorites, err := readFavorites(t, id)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading favorites data")
+		return
+	}
+
+	kept := favorites[:0]
+	for _, f := range favorites {
+		if f.User != user.ID {
+			kept = append(kept, f)
+		}
+	}
+
+	if len(kept) != len(favorites) {
+		if err = writeFavorites(t, id, kept); err != nil {
+			problem.Internal(w, r, "error write favorites data")
+			return
+		}
+	}
+
+	render.PlainText(w, r, "Success removing favorite")
+}
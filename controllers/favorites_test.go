@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestCountFavorites(t *testing.T) {
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+	own, other := xid.New(), xid.New()
+
+	cat := &models.Cat{ID: xid.New(), Shelter: own}
+	favorites := []*favorite{{User: xid.New(), Create: time.Now()}, {User: xid.New(), Create: time.Now()}}
+
+	if err := writeFavorites(tn, cat.ID.String(), favorites); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		user *models.User
+		want int // -1 when count is hidden
+	}{
+		{"admin", &models.User{Role: models.RoleAdmin}, 2},
+		{"staff own shelter", &models.User{Role: models.RoleShelterStaff, Shelters: []xid.ID{own}}, 2},
+		{"staff other shelter", &models.User{Role: models.RoleShelterStaff, Shelters: []xid.ID{other}}, -1},
+		{"adopter", &models.User{Role: models.RoleAdopter}, -1},
+		{"anonymous", nil, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cat.Favorites = nil
+
+			if err := countFavorites(tn, tt.user, models.Cats{cat}); err != nil {
+				t.Fatal(err)
+			}
+
+			got := -1
+			if cat.Favorites != nil {
+				got = *cat.Favorites
+			}
+
+			if got != tt.want {
+				t.Errorf("favorites = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFavorites(t *testing.T) {
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+	id := xid.New().String()
+
+	if err := writeFavorites(tn, id, []*favorite{{User: xid.New(), Create: time.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(favoritesPath(tn, id) + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary file is kept after write")
+	}
+
+	if favorites, err := readFavorites(tn, id); err != nil || len(favorites) != 1 {
+		t.Errorf("readFavorites() = %d, %v, want 1", len(favorites), err)
+	}
+
+	// empty favorites remove the file
+	if err := writeFavorites(tn, id, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(favoritesPath(tn, id)); !os.IsNotExist(err) {
+		t.Error("favorites file is kept when it's empty")
+	}
+
+	if err := releaseFavorites(tn, id); err != nil {
+		t.Errorf("releaseFavorites() of cat without favorites error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,199 @@
+// =====================
+// This package is package to store controllers for api keys.
+// Key is used by partner site to read the listings and by shelter
+// system to push its cats, staff could only manage keys of shelters
+// that is assigned to them and read only keys that they created
+// =====================
+
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Define an interface for each api key controllers
+type KeyControllers interface {
+	// Controller to get all api keys managed by user
+	GetKeys(w http.ResponseWriter, r *http.Request)
+
+	// Controller to create api key
+	AddKey(w http.ResponseWriter, r *http.Request)
+
+	// Controller to get one api key based on given id
+	GetKey(w http.ResponseWriter, r *http.Request)
+
+	// Controller to revoke api key
+	RevokeKey(w http.ResponseWriter, r *http.Request)
+}
+
+// define type that would be used as the controllers of api key
+type Key string
+
+// Check if user manage the key, admin manage all keys and staff
+// manage keys of its shelters or read only keys created by them
+func managesKey(user *models.User, key *models.APIKey) bool {
+	if policy.RoleOf(user) == models.RoleAdmin {
+		return true
+	}
+
+	if key.Scope == models.KeyScopeRead {
+		return key.CreatedBy == user.ID
+	}
+
+	for _, v := range key.Shelters {
+		if !policy.Manages(user, v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Controller for root of "/keys" endpoint.
+// Keys is paginated using "page" and "size", ordered by creation.
+// Response is JSON Object of models.Page with api keys as items
+// Accepted methods [GET]
+func (c Key) GetKeys(w http.ResponseWriter, r *http.Request) {
+	user := middleware.CurrentUser(r)
+
+	keys, err := auth.ListKeys(middleware.CurrentTenant(r))
+
+	if err != nil {
+		problem.Internal(w, r, "error reading api keys data")
+		return
+	}
+
+	managed := []*models.APIKey{}
+
+	for _, key := range keys {
+		if managesKey(user, key) {
+			managed = append(managed, key)
+		}
+	}
+
+	page, start, end, err := paginateNumber(r, len(managed))
+
+	if err != nil {
+		problem.BadRequest(w, r, err.Error())
+		return
+	}
+
+	page.Items = managed[start:end]
+
+	render.JSON(w, r, page)
+}
+
+// Controller to create api key at "/keys" endpoint.
+// Requested body is models.APIKey, "shelters" scope must be given
+// shelters that is managed by the user.
+// Response is JSON Object of the key and "api_key" that is only sent once
+// Accepted methods [POST]
+func (c Key) AddKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		errs validation.Errors
+		t    = middleware.CurrentTenant(r)
+		user = middleware.CurrentUser(r)
+		key  = new(models.APIKey)
+	)
+
+	if !decodeBody(w, r, key, "error invalid api key") {
+		return
+	}
+
+	// each shelter must exist
+	for i, id := range key.Shelters {
+		_, err := readShelter(t, id.String())
+
+		if os.IsNotExist(err) {
+			errs = append(errs, validation.Error(fmt.Sprintf("shelter_ids[%d]", i), "shelter not found"))
+			continue
+		}
+
+		if err != nil {
+			problem.Internal(w, r, "error reading shelter data")
+			return
+		}
+	}
+
+	if len(errs) != 0 {
+		problem.Invalid(w, r, "error invalid api key", errs)
+		return
+	}
+
+	key.CreatedBy = user.ID
+
+	if !managesKey(user, key) {
+		problem.Forbidden(w, r, "error shelter is not managed by user")
+		return
+	}
+
+	token, err := auth.NewKey(t, key)
+
+	if err != nil {
+		problem.Internal(w, r, "error write api key data")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{
+		"key":     key,
+		"api_key": token,
+	})
+}
+
+// Controller to get api key at "/keys/{id}" endpoint.
+// Response is JSON Object of the key without its secret
+// Accepted methods [GET]
+func (c Key) GetKey(w http.ResponseWriter, r *http.Request) {
+	key, err := auth.GetKey(middleware.CurrentTenant(r), chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "api key not found", "error reading api key data")
+		return
+	}
+
+	if !managesKey(middleware.CurrentUser(r), key) {
+		problem.Forbidden(w, r, "error api key is not managed by user")
+		return
+	}
+
+	render.JSON(w, r, key)
+}
+
+// Controller to revoke api key at "/keys/{id}" endpoint,
+// the key is rejected from the next request.
+// Response is success message
+// Accepted methods [DELETE]
+func (c Key) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	key, err := auth.GetKey(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "api key not found", "error reading api key data")
+		return
+	}
+
+	if !managesKey(middleware.CurrentUser(r), key) {
+		problem.Forbidden(w, r, "error api key is not managed by user")
+		return
+	}
+
+	if err = auth.RevokeKey(t, key.ID.String()); err != nil && !os.IsNotExist(err) {
+		problem.Internal(w, r, "error revoke api key")
+		return
+	}
+
+	render.PlainText(w, r, "Success revoking api key")
+}
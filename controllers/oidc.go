@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/render"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/oidc"
+	"github.com/ArkjuniorK/store_app/problem"
+)
+
+// Load OIDC config of the tenant, the problem is sent
+// when it could not be used so nil means the request is done
+func oidcConfig(w http.ResponseWriter, r *http.Request) (*oidc.Config, *oidc.Provider) {
+	config, err := oidc.Load(middleware.CurrentTenant(r))
+
+	if errors.Is(err, oidc.ErrNotConfigured) {
+		problem.NotFound(w, r, "error "+err.Error())
+		return nil, nil
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error reading oidc config")
+		return nil, nil
+	}
+
+	provider, err := config.Provider()
+
+	if err != nil {
+		problem.New(http.StatusBadGateway, "error oidc provider is not available").Write(w, r)
+		return nil, nil
+	}
+
+	return config, provider
+}
+
+// Only path of this app is allowed as return_to,
+// "//host" is url of other host so it's rejected
+func returnTo(v string) string {
+	if !strings.HasPrefix(v, "/") || strings.HasPrefix(v, "//") || strings.Contains(v, "\\") {
+		return "/"
+	}
+
+	return v
+}
+
+// Controller to start OIDC login at "/auth/oidc/login" endpoint.
+// Browser is redirected to the provider of the tenant and would come
+// back to the callback, "return_to" is path to open after login.
+// Response is redirect to the provider
+// Accepted methods [GET]
+func (c Auth) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	config, provider := oidcConfig(w, r)
+
+	if config == nil {
+		return
+	}
+
+	state, err := oidc.NewState(middleware.CurrentTenant(r), config.Callback(r), returnTo(r.URL.Query().Get("return_to")), "")
+
+	if err != nil {
+		problem.Internal(w, r, "error create login state")
+		return
+	}
+
+	oidc.SetStateCookie(w, state)
+
+	http.Redirect(w, r, config.AuthURL(provider, state), http.StatusFound)
+}
+
+// Controller to start linking OIDC provider to current user at
+// "/auth/oidc/link" endpoint. It's only allowed for browser session
+// since the callback is finished by the same browser, "return_to"
+// is path to open after the provider is linked.
+// Response is JSON Object with "redirect_url" of the provider
+// Accepted methods [POST]
+func (c Auth) OIDCLink(w http.ResponseWriter, r *http.Request) {
+	session := middleware.CurrentSession(r)
+
+	if session == nil || session.Kind != auth.KindSession {
+		problem.Forbidden(w, r, "error provider could only be linked from browser session")
+		return
+	}
+
+	config, provider := oidcConfig(w, r)
+
+	if config == nil {
+		return
+	}
+
+	state, err := oidc.NewState(middleware.CurrentTenant(r), config.Callback(r), returnTo(r.URL.Query().Get("return_to")), session.UserID.String())
+
+	if err != nil {
+		problem.Internal(w, r, "error create login state")
+		return
+	}
+
+	oidc.SetStateCookie(w, state)
+
+	render.JSON(w, r, map[string]string{"redirect_url": config.AuthURL(provider, state)})
+}
+
+// Finish linking identity to user that started it, the user
+// must still be logged in with the same browser
+func linkIdentity(w http.ResponseWriter, r *http.Request, state *oidc.State, identity *models.Identity) {
+	user := middleware.CurrentUser(r)
+
+	if middleware.CurrentKey(r) != nil || user == nil || user.ID.String() != state.Link {
+		problem.Forbidden(w, r, "error provider must be linked by the user that started it")
+		return
+	}
+
+	_, err := auth.LinkIdentity(middleware.CurrentTenant(r), user.ID, identity)
+
+	if errors.Is(err, auth.ErrIdentityTaken) {
+		problem.New(http.StatusConflict, "error "+err.Error()).Write(w, r)
+		return
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error write user data")
+		return
+	}
+
+	http.Redirect(w, r, state.ReturnTo, http.StatusFound)
+}
+
+// Controller to finish OIDC login at "/auth/oidc/callback" endpoint.
+// Code is exchanged to ID token, user is found by its identity and
+// registered when it's new, role is mapped from claims of the token
+// when the tenant configured it. Link that is started by OIDCLink
+// add the identity to the logged in user instead.
+// Response is redirect to "return_to" with session cookie
+// Accepted methods [GET]
+func (c Auth) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+	query := r.URL.Query()
+
+	// state is checked against the cookie first so
+	// login of other browser could not be finished
+	if !oidc.CheckState(r, query.Get("state")) {
+		problem.BadRequest(w, r, "error "+oidc.ErrState.Error())
+		return
+	}
+
+	oidc.ClearStateCookie(w)
+
+	state, err := oidc.TakeState(t, query.Get("state"))
+
+	if errors.Is(err, oidc.ErrState) {
+		problem.BadRequest(w, r, "error "+err.Error())
+		return
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error reading login state")
+		return
+	}
+
+	// user denied the login on the provider
+	if v := query.Get("error"); v != "" {
+		problem.Unauthorized(w, r, "error login rejected by provider: "+v)
+		return
+	}
+
+	config, provider := oidcConfig(w, r)
+
+	if config == nil {
+		return
+	}
+
+	token, err := config.Exchange(provider, query.Get("code"), state)
+
+	if err != nil {
+		problem.New(http.StatusBadGateway, "error exchange code with provider").Write(w, r)
+		return
+	}
+
+	claims, err := config.Verify(provider, token, state.Nonce)
+
+	if errors.Is(err, oidc.ErrToken) {
+		problem.Unauthorized(w, r, "error "+err.Error())
+		return
+	}
+
+	if err != nil {
+		problem.New(http.StatusBadGateway, "error oidc provider is not available").Write(w, r)
+		return
+	}
+
+	identity, profile, verified := config.Profile(claims)
+
+	if state.Link != "" {
+		linkIdentity(w, r, state, identity)
+		return
+	}
+
+	user, err := auth.LinkUser(t, identity, profile, verified)
+
+	if errors.Is(err, auth.ErrLinkRequired) {
+		problem.New(http.StatusConflict, "error "+err.Error()).Write(w, r)
+		return
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error write user data")
+		return
+	}
+
+	if session := middleware.CurrentSession(r); session != nil && session.Kind == auth.KindSession {
+		auth.Revoke(t, session)
+	}
+
+	multiFactor := config.MultiFactor(claims)
+
+	// user with two-factor is asked for TOTP code unless the provider
+	// prove it checked the second factor, the challenge is sent in
+	// fragment so it's not sent to the server or other site
+	if user.TwoFactor && !multiFactor {
+		challenge, _, err := auth.NewChallenge(t, user.ID, auth.KindSession)
+
+		if err != nil {
+			problem.Internal(w, r, "error create two-factor challenge")
+			return
+		}
+
+		path := strings.SplitN(state.ReturnTo, "#", 2)[0]
+		http.Redirect(w, r, path+"#"+url.Values{"two_factor_challenge": {challenge}}.Encode(), http.StatusFound)
+		return
+	}
+
+	sessionToken, session, err := auth.NewProviderSession(t, user.ID, config.Issuer, multiFactor)
+
+	if err != nil {
+		problem.Internal(w, r, "error create session")
+		return
+	}
+
+	auth.SetCookies(w, t, sessionToken, session)
+
+	http.Redirect(w, r, state.ReturnTo, http.StatusFound)
+}
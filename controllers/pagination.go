@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/search"
+)
+
+// Default and maximum size of page
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Create link to the same url with replaced query
+func pageLink(r *http.Request, set map[string]string) *string {
+	query := r.URL.Query()
+
+	// page and cursor could not be used together
+	query.Del("page")
+	query.Del("cursor")
+
+	for k, v := range set {
+		query.Set(k, v)
+	}
+
+	link := r.URL.Path + "?" + query.Encode()
+	return &link
+}
+
+// Get range of items of page number, page after the last page is
+// empty instead of error. Number is checked before it's multiplied
+// so huge page number could not overflow to negative range
+func pageRange(number, size, total int) (int, int) {
+	if number-1 > total/size {
+		return total, total
+	}
+
+	start := (number - 1) * size
+	if start > total {
+		start = total
+	}
+
+	end := start + size
+	if end > total {
+		end = total
+	}
+
+	return start, end
+}
+
+// Function to paginate sorted cats using query of request.
+// Page could be requested using "page" and "size", otherwise
+// it's paginated by opaque "cursor" that is taken from
+// next or prev link. Link header is set for each link
+func paginate(w http.ResponseWriter, r *http.Request, cats models.Cats, keys []search.SortKey) (*models.Page, error) {
+	var (
+		query      = r.URL.Query()
+		total      = len(cats)
+		start, end int
+		size       = DefaultPageSize
+		links      []string
+	)
+
+	if v := query.Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+
+		if err != nil || n < 1 || n > MaxPageSize {
+			return nil, &search.ParamError{Param: "size", Msg: fmt.Sprintf("must be between 1 and %d", MaxPageSize)}
+		}
+
+		size = n
+	}
+
+	page := &models.Page{Total: total, Size: size}
+
+	switch {
+	case query.Get("page") != "":
+		// classic page mode
+		if query.Get("cursor") != "" {
+			return nil, &search.ParamError{Param: "cursor", Msg: "could not be used with page"}
+		}
+
+		number, err := strconv.Atoi(query.Get("page"))
+
+		if err != nil || number < 1 {
+			return nil, &search.ParamError{Param: "page", Msg: "must be a positive number"}
+		}
+
+		page.Page = number
+		start, end = pageRange(number, size, total)
+	case query.Get("cursor") != "":
+		cursor, err := search.DecodeCursor(query.Get("cursor"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		// cursor is only valid for the same order
+		if cursor.Sort != query.Get("sort") {
+			return nil, &search.ParamError{Param: "cursor", Msg: "cursor was created with different sort"}
+		}
+
+		start, end = search.Seek(cats, keys, cursor, size)
+	default:
+		// first page of cursor mode
+		end = size
+		if end > total {
+			end = total
+		}
+	}
+
+	// empty page is sent as empty array
+	items := models.Cats{}
+	if start < end {
+		items = cats[start:end]
+	}
+
+	page.Items = items
+
+	// page mode link to page number and
+	// cursor mode link to cursor of the edge cat
+	if end < total && len(items) != 0 {
+		if page.Page != 0 {
+			page.Next = pageLink(r, map[string]string{"page": strconv.Itoa(page.Page + 1)})
+		} else {
+			cursor := search.NewCursor(items[len(items)-1], query.Get("sort"), search.Next)
+			page.Next = pageLink(r, map[string]string{"cursor": cursor.Encode()})
+		}
+
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, *page.Next))
+	}
+
+	if start > 0 {
+		if page.Page != 0 {
+			prev := page.Page - 1
+
+			// previous of page after the last page is the last page
+			if last := (total + size - 1) / size; prev > last {
+				prev = last
+			}
+
+			page.Prev = pageLink(r, map[string]string{"page": strconv.Itoa(prev)})
+		} else if len(items) != 0 {
+			cursor := search.NewCursor(items[0], query.Get("sort"), search.Prev)
+			page.Prev = pageLink(r, map[string]string{"cursor": cursor.Encode()})
+		}
+
+		if page.Prev != nil {
+			links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, *page.Prev))
+		}
+	}
+
+	// first and last link is only available on page mode
+	if page.Page != 0 {
+		last := (total + size - 1) / size
+		if last < 1 {
+			last = 1
+		}
+
+		links = append(links,
+			fmt.Sprintf(`<%s>; rel="first"`, *pageLink(r, map[string]string{"page": "1"})),
+			fmt.Sprintf(`<%s>; rel="last"`, *pageLink(r, map[string]string{"page": strconv.Itoa(last)})),
+		)
+	}
+
+	if len(links) != 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	return page, nil
+}
+
+// Function to paginate list other than cats by page number using
+// "page" and "size" query. The page is returned without items
+// so the caller could set items[start:end] of its own type
+func paginateNumber(r *http.Request, total int) (*models.Page, int, int, error) {
+	var (
+		query  = r.URL.Query()
+		size   = DefaultPageSize
+		number = 1
+		err    error
+	)
+
+	if v := query.Get("size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size < 1 || size > MaxPageSize {
+			return nil, 0, 0, &search.ParamError{Param: "size", Msg: fmt.Sprintf("must be between 1 and %d", MaxPageSize)}
+		}
+	}
+
+	if v := query.Get("page"); v != "" {
+		if number, err = strconv.Atoi(v); err != nil || number < 1 {
+			return nil, 0, 0, &search.ParamError{Param: "page", Msg: "must be a positive number"}
+		}
+	}
+
+	page := &models.Page{Total: total, Size: size, Page: number}
+	start, end := pageRange(number, size, total)
+
+	if end < total {
+		page.Next = pageLink(r, map[string]string{"page": strconv.Itoa(number + 1)})
+	}
+
+	if number > 1 {
+		page.Prev = pageLink(r, map[string]string{"page": strconv.Itoa(number - 1)})
+	}
+
+	return page, start, end, nil
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/search"
+)
+
+func TestPageRange(t *testing.T) {
+	tests := []struct {
+		name                string
+		number, size, total int
+		wantStart, wantEnd  int
+	}{
+		{"first page", 1, 20, 45, 0, 20},
+		{"middle page", 2, 20, 45, 20, 40},
+		{"last page", 3, 20, 45, 40, 45},
+		{"after last page", 4, 20, 45, 45, 45},
+		{"empty list", 1, 20, 0, 0, 0},
+		{"exact size", 2, 10, 20, 10, 20},
+		{"huge page", 9223372036854775807, 20, 45, 45, 45},
+		{"huge page with max size", 9223372036854775807, 100, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := pageRange(tt.number, tt.size, tt.total)
+
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("pageRange() = %d, %d, want %d, %d", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+// Create sorted cats for pagination test
+func pageCats(n int) models.Cats {
+	cats := make(models.Cats, n)
+
+	for i := range cats {
+		cats[i] = &models.Cat{ID: xid.New(), Name: "cat" + strconv.Itoa(i)}
+	}
+
+	return cats
+}
+
+func TestPaginate(t *testing.T) {
+	cats := pageCats(45)
+
+	tests := []struct {
+		query string
+		items int
+		next  bool
+		prev  bool
+	}{
+		{"", 20, true, false},
+		{"?page=1", 20, true, false},
+		{"?page=3", 5, false, true},
+		{"?page=4", 0, false, true},
+		{"?page=2&size=40", 5, false, true},
+		{"?page=9223372036854775807", 0, false, true},
+		{"?page=9223372036854775807&size=100", 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/cats"+tt.query, nil)
+
+			page, err := paginate(httptest.NewRecorder(), r, cats, nil)
+
+			if err != nil {
+				t.Fatalf("paginate() error = %v", err)
+			}
+
+			if n := len(page.Items.(models.Cats)); n != tt.items {
+				t.Errorf("paginate() items = %d, want %d", n, tt.items)
+			}
+
+			if (page.Next != nil) != tt.next || (page.Prev != nil) != tt.prev {
+				t.Errorf("paginate() next = %v, prev = %v", page.Next, page.Prev)
+			}
+		})
+	}
+}
+
+func TestPaginateInvalid(t *testing.T) {
+	for _, query := range []string{"?page=0", "?page=-1", "?page=x", "?size=0", "?size=101", "?page=1&cursor=abc", "?cursor=abc"} {
+		var e *search.ParamError
+
+		r := httptest.NewRequest("GET", "/api/cats"+query, nil)
+
+		if _, err := paginate(httptest.NewRecorder(), r, pageCats(3), nil); !errors.As(err, &e) {
+			t.Errorf("paginate(%q) error = %v, want ParamError", query, err)
+		}
+	}
+}
+
+func TestPaginateCursor(t *testing.T) {
+	var (
+		cats = pageCats(45)
+		seen = map[xid.ID]bool{}
+		url  = "/api/cats?size=20"
+	)
+
+	// follow next link until the last page
+	for i := 0; url != ""; i++ {
+		if i > 3 {
+			t.Fatal("paginate() did not reach the last page")
+		}
+
+		page, err := paginate(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil), cats, nil)
+
+		if err != nil {
+			t.Fatalf("paginate() error = %v", err)
+		}
+
+		for _, cat := range page.Items.(models.Cats) {
+			if seen[cat.ID] {
+				t.Fatalf("cat %s is listed twice", cat.Name)
+			}
+
+			seen[cat.ID] = true
+		}
+
+		url = ""
+		if page.Next != nil {
+			url = *page.Next
+		}
+	}
+
+	if len(seen) != len(cats) {
+		t.Errorf("paginate() listed %d cats, want %d", len(seen), len(cats))
+	}
+}
+
+func TestPaginateNumber(t *testing.T) {
+	tests := []struct {
+		query      string
+		start, end int
+	}{
+		{"", 0, 20},
+		{"?page=2", 20, 25},
+		{"?page=3", 25, 25},
+		{"?page=9223372036854775807", 25, 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, start, end, err := paginateNumber(httptest.NewRequest("GET", "/api/keys"+tt.query, nil), 25)
+
+			if err != nil {
+				t.Fatalf("paginateNumber() error = %v", err)
+			}
+
+			if start != tt.start || end != tt.end {
+				t.Errorf("paginateNumber() = %d, %d, want %d, %d", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
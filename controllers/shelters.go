@@ -0,0 +1,421 @@
+// =====================
+// This package is package to store controllers for shelters.
+// Shelter own cats, staff could only manage cats of shelters
+// that is assigned to them and shelter is created by admin.
+// Logo of shelter is processed by the same image middleware as cat
+// =====================
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/search"
+	"github.com/ArkjuniorK/store_app/storage"
+	"github.com/ArkjuniorK/store_app/tenant"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Directory of shelter's data inside root of the tenant
+const sheltersDir = "data/shelters"
+
+// Define an interface for each shelter controllers
+type ShelterControllers interface {
+	// Controller to get all shelters
+	GetShelters(w http.ResponseWriter, r *http.Request)
+
+	// Controller to add shelter
+	AddShelter(w http.ResponseWriter, r *http.Request)
+
+	// Controller to get one shelter based on given id
+	GetShelter(w http.ResponseWriter, r *http.Request)
+
+	// Controller to replace one shelter based on given id
+	UpdateShelter(w http.ResponseWriter, r *http.Request)
+
+	// Controller to delete shelter that has no cat
+	DeleteShelter(w http.ResponseWriter, r *http.Request)
+
+	// Controller to post shelter's logo
+	UploadLogo(w http.ResponseWriter, r *http.Request)
+}
+
+// define type that would be used as the controllers of shelter
+type Shelter string
+
+// Read one shelter's data
+func readShelter(t *tenant.Tenant, id string) (*models.Shelter, error) {
+	var shelter models.Shelter
+
+	data, err := ioutil.ReadFile(t.Path(sheltersDir, filepath.Base(id)+".json"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &shelter); err != nil {
+		return nil, err
+	}
+
+	return &shelter, nil
+}
+
+// Read all shelter's data, missing
+// directory means there is no shelter
+func readShelters(t *tenant.Tenant) (models.Shelters, error) {
+	shelters := models.Shelters{}
+
+	files, err := ioutil.ReadDir(t.Path(sheltersDir))
+
+	if os.IsNotExist(err) {
+		return shelters, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range files {
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		shelter, err := readShelter(t, strings.TrimSuffix(v.Name(), ".json"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		shelters = append(shelters, shelter)
+	}
+
+	return shelters, nil
+}
+
+// Write shelter's data, the directory
+// is created on the first shelter
+func writeShelter(t *tenant.Tenant, shelter *models.Shelter) error {
+	data, err := json.Marshal(shelter)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(t.Path(sheltersDir), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.Path(sheltersDir, shelter.ID.String()+".json"), data, 0644)
+}
+
+// Normalize address of shelter before it's validated
+// and set its location from the postal code
+func prepareShelter(shelter *models.Shelter) {
+	shelter.Name = strings.TrimSpace(shelter.Name)
+	shelter.Address.Normalize()
+	shelter.Location = search.Locate(&models.Cat{Address: shelter.Address})
+}
+
+// Controller for root of "/shelters" endpoint.
+// Shelters could be filtered by "name" and "country" and
+// paginated using "page" and "size", ordered by name.
+// Response is JSON Object of models.Page with models.Shelters as items.
+// Accepted methods [GET]
+func (c Shelter) GetShelters(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	query := r.URL.Query()
+
+	shelters, err := readShelters(t)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading shelters data")
+		return
+	}
+
+	filtered := models.Shelters{}
+
+	for _, shelter := range shelters {
+		if v := query.Get("name"); v != "" && !strings.Contains(strings.ToLower(shelter.Name), strings.ToLower(v)) {
+			continue
+		}
+
+		if v := query.Get("country"); v != "" && !strings.EqualFold(shelter.Address.Country, v) {
+			continue
+		}
+
+		filtered = append(filtered, shelter)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return strings.ToLower(filtered[i].Name) < strings.ToLower(filtered[j].Name)
+	})
+
+	page, start, end, err := paginateNumber(r, len(filtered))
+
+	if err != nil {
+		problem.BadRequest(w, r, err.Error())
+		return
+	}
+
+	page.Items = filtered[start:end]
+
+	render.JSON(w, r, page)
+}
+
+// Controller to add shelter at "/shelters" endpoint.
+// Requested body is models.Shelter, logo is added by upload logo controller.
+// Response is JSON Object of the new shelter
+// Accepted methods [POST]
+func (c Shelter) AddShelter(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	shelter := new(models.Shelter)
+
+	if !decodeBody(w, r, shelter, "error invalid shelter data") {
+		return
+	}
+
+	prepareShelter(shelter)
+
+	// address is validated again after it's normalized
+	if errs := validation.Struct(shelter); len(errs) != 0 {
+		problem.Invalid(w, r, "error invalid shelter data", errs)
+		return
+	}
+
+	shelter.ID = xid.New()
+	shelter.Create = time.Now()
+	shelter.Update = shelter.Create
+
+	if err := writeShelter(t, shelter); err != nil {
+		problem.Internal(w, r, "error write shelter data")
+		return
+	}
+
+	render.JSON(w, r, shelter)
+}
+
+// Controller to get shelter at "/shelters/{id}" endpoint.
+// Response is JSON Object of the shelter
+// Accepted methods [GET]
+func (c Shelter) GetShelter(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	shelter, err := readShelter(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "shelter not found", "error reading shelter data")
+		return
+	}
+
+	render.JSON(w, r, shelter)
+}
+
+// Controller to replace shelter at "/shelters/{id}" endpoint.
+// Requested body is the full shelter, staff could only update
+// shelters that is assigned to them.
+// Response is JSON Object of the updated shelter
+// Accepted methods [PUT]
+func (c Shelter) UpdateShelter(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	shelter, err := readShelter(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "shelter not found", "error reading shelter data")
+		return
+	}
+
+	if !policy.Manages(middleware.CurrentUser(r), shelter.ID) {
+		problem.Forbidden(w, r, "error shelter is not managed by user")
+		return
+	}
+
+	// start from empty shelter so the requested body
+	// replace the whole shelter except readonly field
+	updated := &models.Shelter{
+		ID:     shelter.ID,
+		Logo:   shelter.Logo,
+		Create: shelter.Create,
+	}
+
+	if !decodeBody(w, r, updated, "error invalid shelter data") {
+		return
+	}
+
+	prepareShelter(updated)
+
+	if errs := validation.Struct(updated); len(errs) != 0 {
+		problem.Invalid(w, r, "error invalid shelter data", errs)
+		return
+	}
+
+	updated.Update = time.Now()
+
+	if err = writeShelter(t, updated); err != nil {
+		problem.Internal(w, r, "error write shelter data")
+		return
+	}
+
+	render.JSON(w, r, updated)
+}
+
+// Controller to delete shelter at "/shelters/{id}" endpoint.
+// Shelter that still own cat could not be deleted, the cat
+// must be moved to other shelter first.
+// Response is success message
+// Accepted methods [DELETE]
+func (c Shelter) DeleteShelter(w http.ResponseWriter, r *http.Request) {
+	var (
+		t  = middleware.CurrentTenant(r)
+		wd = t.Root
+	)
+
+	shelter, err := readShelter(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "shelter not found", "error reading shelter data")
+		return
+	}
+
+	cats, err := readCats(t)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading cats data")
+		return
+	}
+
+	for _, cat := range cats {
+		if cat.Shelter == shelter.ID {
+			problem.New(http.StatusConflict, "error shelter still own cats").Write(w, r)
+			return
+		}
+	}
+
+	if err = os.Remove(t.Path(sheltersDir, shelter.ID.String()+".json")); err != nil {
+		problem.Internal(w, r, "error deleting shelter data")
+		return
+	}
+
+	// release the logo, it would be deleted
+	// from disk when nothing else use it
+	if shelter.Logo != nil {
+		for _, filename := range storage.LinkFiles(shelter.Logo) {
+			if _, err = storage.Release(wd, filename); err != nil {
+				problem.Internal(w, r, "error release shelter's logo")
+				return
+			}
+		}
+	}
+
+	render.PlainText(w, r, "Success deleting shelter")
+}
+
+// Controller to post shelter's logo at "/shelters/{id}/logo" endpoint,
+// the image is processed by image middleware and replace the old logo.
+// Response is JSON Object of the updated shelter
+// Accepted methods [POST]
+func (c Shelter) UploadLogo(w http.ResponseWriter, r *http.Request) {
+	var (
+		files    []string
+		t        = middleware.CurrentTenant(r)
+		wd       = t.Root
+		filename = fmt.Sprintf("%v", r.Context().Value(middleware.KeyName))
+	)
+
+	files = append(files, filename)
+
+	// animated image also has poster
+	poster, animated := r.Context().Value(middleware.KeyPoster).(string)
+	if animated {
+		files = append(files, poster)
+	}
+
+	// remove images from storage when it's not used,
+	// used when the logo could not be written
+	discard := func() {
+		for _, v := range files {
+			storage.Discard(wd, v)
+		}
+	}
+
+	shelter, err := readShelter(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		discard()
+		problem.Storage(w, r, err, "shelter not found", "error reading shelter data")
+		return
+	}
+
+	if !policy.Manages(middleware.CurrentUser(r), shelter.ID) {
+		discard()
+		problem.Forbidden(w, r, "error shelter is not managed by user")
+		return
+	}
+
+	old := shelter.Logo
+
+	shelter.Logo = &models.Link{ID: xid.New(), URL: r.Host + t.StaticPath() + filename}
+
+	if animated {
+		shelter.Logo.Animated = true
+		shelter.Logo.Poster = r.Host + t.StaticPath() + poster
+	}
+
+	shelter.Update = time.Now()
+
+	// add reference to image before writing shelter data
+	// so the image would not be discarded by other request
+	for i, v := range files {
+		if err = storage.Acquire(wd, v); err != nil {
+			for _, acquired := range files[:i] {
+				storage.Release(wd, acquired)
+			}
+
+			for _, saved := range files[i:] {
+				storage.Discard(wd, saved)
+			}
+
+			problem.Internal(w, r, "error reference shelter's logo")
+			return
+		}
+	}
+
+	if err = writeShelter(t, shelter); err != nil {
+		for _, v := range files {
+			storage.Release(wd, v)
+		}
+
+		problem.Internal(w, r, "error write shelter data")
+		return
+	}
+
+	// then release the replaced logo
+	if old != nil {
+		for _, v := range storage.LinkFiles(old) {
+			if _, err = storage.Release(wd, v); err != nil {
+				problem.Internal(w, r, "error release shelter's logo")
+				return
+			}
+		}
+	}
+
+	render.JSON(w, r, shelter)
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+func TestManagesCat(t *testing.T) {
+	own, other := xid.New(), xid.New()
+	staff := &models.User{Role: models.RoleShelterStaff, Shelters: []xid.ID{own}}
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		shelter  xid.ID
+		want     bool
+		wantCode int
+	}{
+		{"staff own shelter", staff, own, true, http.StatusOK},
+		{"staff other shelter", staff, other, false, http.StatusForbidden},
+		{"staff cat without shelter", staff, xid.NilID(), false, http.StatusForbidden},
+		{"admin other shelter", &models.User{Role: models.RoleAdmin}, other, true, http.StatusOK},
+		{"anonymous", nil, own, false, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/cats/1", nil)
+
+			if tt.user != nil {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.KeyUser, tt.user))
+			}
+
+			w := httptest.NewRecorder()
+
+			if got := managesCat(w, r, &models.Cat{Shelter: tt.shelter}); got != tt.want {
+				t.Errorf("managesCat() = %v, want %v", got, tt.want)
+			}
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/problem"
+)
+
+// Send error of transition, disallowed transition is a conflict
+func transitionError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, models.ErrTransition) {
+		problem.New(http.StatusConflict, err.Error()).Write(w, r)
+		return
+	}
+
+	problem.Internal(w, r, "error change status")
+}
+
+// Controller to change adoption status of cat at "/cats/{id}/transitions"
+// endpoint. Requested body is models.Transition with "to" and optional
+// "reason", transition that is not allowed from current status is
+// sent as conflict. Cat that is held by approved application could not
+// leave pending adoption. Response is JSON Object from updated cat
+// Accepted methods [POST]
+func (c Cat) TransitionCat(w http.ResponseWriter, r *http.Request) {
+	var (
+		transition models.Transition
+		id         = chi.URLParam(r, "id")
+		t          = middleware.CurrentTenant(r)
+	)
+
+	// validate the requested transition first
+	if !decodeBody(w, r, &transition, "error invalid transition") {
+		return
+	}
+
+	// status of cat is also changed by application
+	applicationsMu.Lock()
+	defer applicationsMu.Unlock()
+
+	// find data of cat using id
+	cat, err := readCat(t, id)
+
+	if err != nil {
+		problem.Storage(w, r, err, "cat not found", "error reading cat data")
+		return
+	}
+
+	if !managesCat(w, r, cat) {
+		return
+	}
+
+	// cat that is held by approved application is released by
+	// withdrawing or finalizing the application instead
+	if cat.Status == models.PendingAdoption && transition.To != models.PendingAdoption {
+		holder, err := holdingApplication(t, cat.ID)
+
+		if err != nil {
+			problem.Internal(w, r, "error reading applications data")
+			return
+		}
+
+		if holder != nil {
+			problem.New(http.StatusConflict, fmt.Sprintf("error cat is held by approved application %s", holder.ID)).Write(w, r)
+			return
+		}
+	}
+
+	// change the status, transition is checked
+	// against the current status of cat
+	now := time.Now()
+
+	if err = cat.Transition(transition.To, transition.Reason, now); err != nil {
+		transitionError(w, r, err)
+		return
+	}
+
+	cat.Update = now
+
+	if err = writeCat(t, cat); err != nil {
+		problem.Internal(w, r, "error write cat data")
+		return
+	}
+
+	// adopted cat could not be favorited anymore
+	if cat.Status == models.Adopted {
+		if err = releaseFavorites(t, id); err != nil {
+			problem.Internal(w, r, "error remove cat's favorites")
+			return
+		}
+	}
+
+	// send response
+	cat.SetAge(now)
+	render.JSON(w, r, cat)
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Requested body that carry TOTP or recovery code
+type twoFactorCode struct {
+	Code string `json:"code" validate:"required,max=20"`
+}
+
+// Send the problem of two-factor error
+func twoFactorError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	switch {
+	case errors.Is(err, auth.ErrCode):
+		problem.Invalid(w, r, "error "+err.Error(), validation.Errors{validation.Error("code", "is invalid")})
+	case errors.Is(err, auth.ErrLocked):
+		problem.New(http.StatusTooManyRequests, "error "+err.Error()).Write(w, r)
+	case errors.Is(err, auth.ErrTwoFactorEnabled), errors.Is(err, auth.ErrTwoFactorOff), errors.Is(err, auth.ErrNotEnrolled):
+		problem.New(http.StatusConflict, "error "+err.Error()).Write(w, r)
+	default:
+		problem.Storage(w, r, err, "user not found", detail)
+	}
+}
+
+// Controller to start two-factor enrollment at "/auth/2fa/enroll" endpoint.
+// The secret is pending until it's confirmed, enroll again would
+// replace the pending secret.
+// Response is JSON Object of the secret and otpauth uri to be shown as QR code
+// Accepted methods [POST]
+func (c Auth) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var (
+		t    = middleware.CurrentTenant(r)
+		user = middleware.CurrentUser(r)
+	)
+
+	secret, err := auth.EnrollTwoFactor(t, user.ID)
+
+	if err != nil {
+		twoFactorError(w, r, err, "error write user data")
+		return
+	}
+
+	// email could be empty for user of OIDC provider
+	account := user.Email
+	if account == "" {
+		account = user.Name
+	}
+
+	render.JSON(w, r, map[string]string{
+		"secret": secret,
+		"uri":    auth.ProvisioningURI(t.Name, account, secret),
+	})
+}
+
+// Controller to enable two-factor at "/auth/2fa/confirm" endpoint.
+// Requested body is "code" of the enrolled secret.
+// Response is JSON Object of recovery codes that is only sent once
+// Accepted methods [POST]
+func (c Auth) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	body := new(twoFactorCode)
+
+	if !decodeBody(w, r, body, "error invalid two-factor code") {
+		return
+	}
+
+	codes, err := auth.ConfirmTwoFactor(middleware.CurrentTenant(r), middleware.CurrentUser(r).ID, body.Code)
+
+	if err != nil {
+		twoFactorError(w, r, err, "error write user data")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"recovery_codes": codes})
+}
+
+// Controller to verify login challenge at "/auth/2fa/verify" endpoint.
+// Requested body is "challenge" of login and "code" that is TOTP or
+// recovery code, challenge is removed after 5 wrong codes and
+// the account is locked for 15 minutes after 10 wrong codes.
+// Response is the same as login or token endpoint that create the challenge
+// Accepted methods [POST]
+func (c Auth) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Challenge string `json:"challenge" validate:"required"`
+		Code      string `json:"code" validate:"required,max=20"`
+	}
+
+	if !decodeBody(w, r, &body, "error invalid two-factor code") {
+		return
+	}
+
+	t := middleware.CurrentTenant(r)
+
+	challenge, err := auth.VerifyChallenge(t, body.Challenge, body.Code)
+
+	if errors.Is(err, auth.ErrSession) {
+		problem.Unauthorized(w, r, "error invalid or expired challenge")
+		return
+	}
+
+	if errors.Is(err, auth.ErrCode) {
+		problem.Unauthorized(w, r, "error "+err.Error())
+		return
+	}
+
+	if errors.Is(err, auth.ErrLocked) {
+		problem.New(http.StatusTooManyRequests, "error "+err.Error()).Write(w, r)
+		return
+	}
+
+	if err != nil {
+		problem.Internal(w, r, "error verify two-factor code")
+		return
+	}
+
+	user, err := auth.GetUser(t, challenge.UserID.String())
+
+	if err != nil {
+		problem.Unauthorized(w, r, "error user of challenge not found")
+		return
+	}
+
+	if challenge.Next == auth.KindToken {
+		c.issueToken(w, r, user)
+		return
+	}
+
+	c.startSession(w, r, user)
+}
+
+// Controller to replace recovery codes at "/auth/2fa/recovery-codes" endpoint.
+// Requested body is "code" that is TOTP or recovery code.
+// Response is JSON Object of new recovery codes, the old codes is not valid anymore
+// Accepted methods [POST]
+func (c Auth) RecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	body := new(twoFactorCode)
+
+	if !decodeBody(w, r, body, "error invalid two-factor code") {
+		return
+	}
+
+	codes, err := auth.RegenerateRecovery(middleware.CurrentTenant(r), middleware.CurrentUser(r).ID, body.Code)
+
+	if err != nil {
+		twoFactorError(w, r, err, "error write user data")
+		return
+	}
+
+	render.JSON(w, r, map[string]interface{}{"recovery_codes": codes})
+}
+
+// Controller to disable two-factor at "/auth/2fa/disable" endpoint.
+// Requested body is "code" that is TOTP or recovery code, staff of
+// tenant that require two-factor could not disable it.
+// Response is JSON Object of the updated user
+// Accepted methods [POST]
+func (c Auth) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var (
+		t    = middleware.CurrentTenant(r)
+		user = middleware.CurrentUser(r)
+		body = new(twoFactorCode)
+	)
+
+	if policy.RequiresTwoFactor(user, t.RequireTwoFactor) {
+		problem.Forbidden(w, r, "error two-factor authentication is required by the organization")
+		return
+	}
+
+	if !decodeBody(w, r, body, "error invalid two-factor code") {
+		return
+	}
+
+	if err := auth.DisableTwoFactor(t, user.ID, body.Code, false); err != nil {
+		twoFactorError(w, r, err, "error write user data")
+		return
+	}
+
+	user, err := auth.GetUser(t, user.ID.String())
+
+	if err != nil {
+		problem.Internal(w, r, "error reading user data")
+		return
+	}
+
+	render.JSON(w, r, user)
+}
@@ -0,0 +1,162 @@
+// =====================
+// This package is package to store controllers for managing users,
+// only admin could change role of other user and assign shelters to staff
+// =====================
+
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Define an interface for each user controllers
+type UserControllers interface {
+	// Controller to get one user based on given id
+	GetUser(w http.ResponseWriter, r *http.Request)
+
+	// Controller to change role of user
+	SetRole(w http.ResponseWriter, r *http.Request)
+
+	// Controller to assign shelters to staff
+	SetShelters(w http.ResponseWriter, r *http.Request)
+
+	// Controller to reset two-factor of user that lost the device
+	ResetTwoFactor(w http.ResponseWriter, r *http.Request)
+}
+
+// define type that would be used as the controllers of user
+type User string
+
+// Controller to get user at "/users/{id}" endpoint.
+// Response is JSON Object of the user
+// Accepted methods [GET]
+func (c User) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := auth.GetUser(middleware.CurrentTenant(r), chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "user not found", "error reading user data")
+		return
+	}
+
+	render.JSON(w, r, user)
+}
+
+// Controller to change role of user at "/users/{id}/role" endpoint.
+// Requested body is "role" that is one of models.Roles except anonymous.
+// Response is JSON Object of the updated user
+// Accepted methods [PUT]
+func (c User) SetRole(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Role string `json:"role" validate:"required,enum=role"`
+	}
+
+	if !decodeBody(w, r, &body, "error invalid role") {
+		return
+	}
+
+	user, err := auth.GetUser(middleware.CurrentTenant(r), chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "user not found", "error reading user data")
+		return
+	}
+
+	user.Role = body.Role
+
+	if err = auth.UpdateUser(middleware.CurrentTenant(r), user); err != nil {
+		problem.Internal(w, r, "error write user data")
+		return
+	}
+
+	render.JSON(w, r, user)
+}
+
+// Controller to assign shelters of staff at "/users/{id}/shelters" endpoint.
+// Requested body is "shelter_ids" that replace the assigned shelters,
+// staff could only manage cats of the assigned shelters.
+// Response is JSON Object of the updated user
+// Accepted methods [PUT]
+func (c User) SetShelters(w http.ResponseWriter, r *http.Request) {
+	var (
+		body struct {
+			Shelters []xid.ID `json:"shelter_ids"`
+		}
+		errs validation.Errors
+	)
+
+	if !decodeBody(w, r, &body, "error invalid shelters") {
+		return
+	}
+
+	// each shelter must exist
+	for i, id := range body.Shelters {
+		_, err := readShelter(middleware.CurrentTenant(r), id.String())
+
+		if os.IsNotExist(err) {
+			errs = append(errs, validation.Error(fmt.Sprintf("shelter_ids[%d]", i), "shelter not found"))
+			continue
+		}
+
+		if err != nil {
+			problem.Internal(w, r, "error reading shelter data")
+			return
+		}
+	}
+
+	if len(errs) != 0 {
+		problem.Invalid(w, r, "error invalid shelters", errs)
+		return
+	}
+
+	user, err := auth.GetUser(middleware.CurrentTenant(r), chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "user not found", "error reading user data")
+		return
+	}
+
+	user.Shelters = body.Shelters
+
+	if err = auth.UpdateUser(middleware.CurrentTenant(r), user); err != nil {
+		problem.Internal(w, r, "error write user data")
+		return
+	}
+
+	render.JSON(w, r, user)
+}
+
+// Controller to reset two-factor of user at "/users/{id}/2fa" endpoint,
+// used when user lost the device and the recovery codes. The user
+// would need to enroll again when the tenant require it.
+// Response is JSON Object of the updated user
+// Accepted methods [DELETE]
+func (c User) ResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	t := middleware.CurrentTenant(r)
+
+	user, err := auth.GetUser(t, chi.URLParam(r, "id"))
+
+	if err != nil {
+		problem.Storage(w, r, err, "user not found", "error reading user data")
+		return
+	}
+
+	if err = auth.DisableTwoFactor(t, user.ID, "", true); err != nil {
+		problem.Internal(w, r, "error write user data")
+		return
+	}
+
+	user.TwoFactor = false
+
+	render.JSON(w, r, user)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/maintenance"
+	"github.com/ArkjuniorK/store_app/storage"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// default interval for background fsck job,
+// could be changed using FSCK_INTERVAL env (ex: "6h")
+const fsckInterval = 24 * time.Hour
+
+// Command to cross check cat data against cat image,
+// usage: store_app fsck [-tenant id] [-repair], repair is refused
+// while the server is running since the server hold the storage
+func fsck(args []string) {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "delete orphan image and drop dangling link")
+	id := fs.String("tenant", tenant.DefaultID, "tenant to be checked")
+	fs.Parse(args)
+
+	t, err := tenant.Get(*id)
+
+	if err != nil {
+		log.Fatalf("fsck: %v", err)
+	}
+
+	var (
+		wd     = t.Root
+		report *maintenance.Report
+	)
+
+	// repair check the storage by itself, it's refused while server
+	// use the storage since pending upload is only known by the server
+	if *repair {
+		var lock *storage.FileLock
+
+		lock, err = storage.LockDir(wd)
+
+		if errors.Is(err, storage.ErrLocked) {
+			log.Fatalf("fsck: %v, stop the server or set FSCK_REPAIR=true so the server repair it", err)
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		defer lock.Unlock()
+
+		report, err = maintenance.Repair(wd)
+	} else {
+		report, err = maintenance.Check(wd)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// print the report as json so it could be piped
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+
+	if *repair {
+		return
+	}
+
+	// exit with non zero code when problem found
+	// and not repaired, same as fsck does
+	if !report.Clean() {
+		os.Exit(1)
+	}
+}
+
+// Locks of storage held by the server, it's kept
+// so the lock file is not closed until the server exit
+var storageLocks []*storage.FileLock
+
+// Lock storage of each tenant for the server, so repair command
+// of other process could not change it while it's served
+func lockStorage() {
+	for _, t := range tenant.All() {
+		lock, err := storage.LockDir(t.Root)
+
+		if err != nil {
+			log.Fatalf("storage of tenant %q: %v", t.ID, err)
+		}
+
+		storageLocks = append(storageLocks, lock)
+	}
+}
+
+// Start fsck as background job for each tenant, the job would
+// only report the problem unless FSCK_REPAIR env is set to true
+func startFsck() {
+	interval := fsckInterval
+	if v, err := time.ParseDuration(os.Getenv("FSCK_INTERVAL")); err == nil && v > 0 {
+		interval = v
+	}
+
+	repair, _ := strconv.ParseBool(os.Getenv("FSCK_REPAIR"))
+
+	for _, t := range tenant.All() {
+		go maintenance.Schedule(t.Root, interval, repair)
+	}
+}
@@ -0,0 +1,183 @@
+// ======================
+// This package is package to geocode postal code offline.
+// Postal code centroid is read from dataset in GeoNames postal code
+// format (tab separated: country, postal code, place name, admin name1,
+// admin code1, admin name2, admin code2, admin name3, admin code3,
+// latitude, longitude, accuracy).
+//
+// Full dataset must be downloaded from https://download.geonames.org/export/zip/
+// (CC BY 4.0) and set as GEO_POSTAL_CODES env to the file path, the server
+// would not start without it. Only small sample dataset is bundled inside
+// the binary and it's only used on dev mode (APP_ENV=dev).
+// ======================
+
+package geo
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default country of postal code
+const DefaultCountry = "US"
+
+// Mean radius of earth in km
+const earthRadius = 6371.0
+
+//go:embed postal_codes.txt
+var bundled []byte
+
+// Point type store coordinate in degree
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Place type store centroid and locality of postal code
+type Place struct {
+	Point
+	City   string
+	Region string
+}
+
+// loaded dataset, keyed by country and compacted postal code
+var (
+	places   map[string]*Place
+	loadErr  error
+	loadOnce sync.Once
+)
+
+// ErrNoDataset is returned when GEO_POSTAL_CODES is not set outside dev mode
+var ErrNoDataset = errors.New("GEO_POSTAL_CODES is not set, download postal code dataset from https://download.geonames.org/export/zip/")
+
+// Read dataset in GeoNames format
+func parse(r io.Reader) map[string]*Place {
+	points := make(map[string]*Place)
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		cols := strings.Split(scanner.Text(), "\t")
+
+		if len(cols) < 11 {
+			continue
+		}
+
+		lat, errLat := strconv.ParseFloat(cols[9], 64)
+		lon, errLon := strconv.ParseFloat(cols[10], 64)
+
+		if errLat != nil || errLon != nil {
+			continue
+		}
+
+		points[cols[0]+":"+Compact(cols[1])] = &Place{Point{lat, lon}, cols[2], cols[4]}
+	}
+
+	return points
+}
+
+// Read dataset at path, the bundled sample is
+// only used when path is empty on dev mode
+func open(path string, dev bool) (map[string]*Place, error) {
+	if path == "" {
+		if !dev {
+			return nil, ErrNoDataset
+		}
+
+		log.Print("geo: GEO_POSTAL_CODES is not set, using bundled sample dataset")
+		return parse(bytes.NewReader(bundled)), nil
+	}
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	points := parse(f)
+
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no postal code found in %s", path)
+	}
+
+	return points, nil
+}
+
+// Function to load the dataset once from GEO_POSTAL_CODES, it's
+// called on server start so missing dataset stop the server.
+// Postal code could not be found when it's failed
+func Load() error {
+	loadOnce.Do(func() {
+		places, loadErr = open(os.Getenv("GEO_POSTAL_CODES"), os.Getenv("APP_ENV") == "dev")
+
+		if loadErr != nil {
+			log.Printf("geo: %v", loadErr)
+		}
+	})
+
+	return loadErr
+}
+
+// Function to get place of postal code in country,
+// empty country would be the default country
+func Find(country, code string) (*Place, bool) {
+	if Load() != nil {
+		return nil, false
+	}
+
+	if country == "" {
+		country = DefaultCountry
+	}
+
+	country = strings.ToUpper(country)
+
+	if p, ok := places[country+":"+Compact(code)]; ok {
+		return p, true
+	}
+
+	// some dataset only have the outward part of postal code,
+	// ex: "SW1A" of "SW1A 1AA" or "1012" of "1012 AB"
+	if _, canonical, err := NormalizePostalCode(country, code); err == nil {
+		if i := strings.IndexAny(canonical, " -"); i > 0 {
+			p, ok := places[country+":"+canonical[:i]]
+			return p, ok
+		}
+	}
+
+	return nil, false
+}
+
+// Function to get centroid of postal code in country
+func Lookup(country, code string) (*Point, bool) {
+	if p, ok := Find(country, code); ok {
+		point := p.Point
+		return &point, true
+	}
+
+	return nil, false
+}
+
+// Function to get distance between two point in km
+// using haversine formula
+func Distance(a, b *Point) float64 {
+	rad := math.Pi / 180
+
+	dLat := (b.Lat - a.Lat) * rad
+	dLon := (b.Lon - a.Lon) * rad
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(a.Lat*rad)*math.Cos(b.Lat*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
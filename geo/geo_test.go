@@ -0,0 +1,154 @@
+package geo
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// tests use the bundled sample dataset
+	os.Setenv("APP_ENV", "dev")
+	os.Unsetenv("GEO_POSTAL_CODES")
+	os.Exit(m.Run())
+}
+
+func TestParse(t *testing.T) {
+	data := strings.Join([]string{
+		"US\t10001\tNew York\tNew York\tNY\t\t\t\t\t40.7484\t-73.9967\t4",
+		"GB\tSW1A 1AA\tLondon\tEngland\tENG\t\t\t\t\t51.501\t-0.1416\t6",
+		"US\t99999\tBroken\tNowhere\tNW\t\t\t\t\tx\t1\t4",
+		"too\tfew\tcolumns",
+		"",
+	}, "\n")
+
+	places := parse(strings.NewReader(data))
+
+	if len(places) != 2 {
+		t.Fatalf("parse() = %d places, want 2", len(places))
+	}
+
+	p := places["GB:SW1A1AA"]
+
+	if p == nil || p.City != "London" || p.Region != "ENG" || p.Lat != 51.501 || p.Lon != -0.1416 {
+		t.Errorf("parse() GB:SW1A1AA = %+v", p)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geo")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var (
+		valid = filepath.Join(dir, "US.txt")
+		empty = filepath.Join(dir, "empty.txt")
+	)
+
+	ioutil.WriteFile(valid, []byte("US\t10001\tNew York\tNew York\tNY\t\t\t\t\t40.7484\t-73.9967\t4\n"), 0644)
+	ioutil.WriteFile(empty, nil, 0644)
+
+	tests := []struct {
+		name    string
+		path    string
+		dev     bool
+		wantLen int
+		wantErr bool
+	}{
+		{"dataset", valid, false, 1, false},
+		{"dataset on dev mode", valid, true, 1, false},
+		{"sample on dev mode", "", true, len(parse(strings.NewReader(string(bundled)))), false},
+		{"required outside dev mode", "", false, 0, true},
+		{"missing file", filepath.Join(dir, "missing.txt"), true, 0, true},
+		{"empty file", empty, true, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			places, err := open(tt.path, tt.dev)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("open() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if len(places) != tt.wantLen {
+				t.Errorf("open() = %d places, want %d", len(places), tt.wantLen)
+			}
+		})
+	}
+
+	if _, err := open("", false); !errors.Is(err, ErrNoDataset) {
+		t.Errorf("open() error = %v, want %v", err, ErrNoDataset)
+	}
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		country, code string
+		city          string
+	}{
+		{"", "10001", "New York"},
+		{"us", "10001", "New York"},
+		{"US", "10001-1234", "New York"},
+		{"GB", "sw1a 1aa", "London"},
+		{"GB", "SW1A1AA", "London"},
+		// only outward part of Dutch postal code is in the dataset
+		{"NL", "1012 AB", "Amsterdam"},
+		{"US", "00000", ""},
+		{"DE", "10001", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.country+" "+tt.code, func(t *testing.T) {
+			p, ok := Find(tt.country, tt.code)
+
+			if tt.city == "" {
+				if ok {
+					t.Errorf("Find() = %+v, want not found", p)
+				}
+
+				return
+			}
+
+			if !ok || p.City != tt.city {
+				t.Errorf("Find() = %+v, %v, want %s", p, ok, tt.city)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64 // km
+	}{
+		{"same point", Point{40.7484, -73.9967}, Point{40.7484, -73.9967}, 0},
+		{"new york to boston", Point{40.7484, -73.9967}, Point{42.3576, -71.0674}, 302},
+		{"london to berlin", Point{51.501, -0.1416}, Point{52.5323, 13.3846}, 932},
+		{"quarter of equator", Point{0, 0}, Point{0, 90}, math.Pi * earthRadius / 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(&tt.a, &tt.b)
+
+			if math.Abs(got-tt.want) > 2 {
+				t.Errorf("Distance() = %.1f, want %.1f", got, tt.want)
+			}
+
+			// distance is symmetric
+			if back := Distance(&tt.b, &tt.a); math.Abs(back-got) > 1e-9 {
+				t.Errorf("Distance() is not symmetric, %.3f and %.3f", got, back)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package geo
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Errors of postal code validation
+var (
+	ErrCountry    = errors.New("must be ISO 3166-1 alpha-2 country code")
+	ErrPostalCode = errors.New("is not valid postal code for the country")
+)
+
+// Format type store pattern of postal code in country,
+// pattern is matched against compacted code (no space)
+// and Canonical would format compacted code for display
+type Format struct {
+	Pattern   *regexp.Regexp
+	Canonical func(code string) string
+}
+
+// Format code by inserting space before the last n characters
+func spaceBefore(n int) func(string) string {
+	return func(code string) string {
+		return code[:len(code)-n] + " " + code[len(code)-n:]
+	}
+}
+
+// Format US ZIP+4 with dash
+func zipPlus4(code string) string {
+	code = strings.ReplaceAll(code, "-", "")
+
+	if len(code) == 9 {
+		return code[:5] + "-" + code[5:]
+	}
+
+	return code
+}
+
+// Postal code formats of supported country, keyed by country code.
+// Country that is not listed here is accepted as long as the code
+// is alphanumeric, since it could not be validated
+var Formats = map[string]*Format{
+	"US": {regexp.MustCompile(`^\d{5}(-?\d{4})?$`), zipPlus4},
+	"CA": {regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[A-Z]\d[A-Z]\d$`), spaceBefore(3)},
+	"GB": {regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]?\d[A-Z]{2}$`), spaceBefore(3)},
+	"NL": {regexp.MustCompile(`^[1-9]\d{3}[A-Z]{2}$`), spaceBefore(2)},
+	"DE": {regexp.MustCompile(`^\d{5}$`), nil},
+	"FR": {regexp.MustCompile(`^\d{5}$`), nil},
+	"ES": {regexp.MustCompile(`^\d{5}$`), nil},
+	"IT": {regexp.MustCompile(`^\d{5}$`), nil},
+	"ID": {regexp.MustCompile(`^\d{5}$`), nil},
+	"AU": {regexp.MustCompile(`^\d{4}$`), nil},
+	"BE": {regexp.MustCompile(`^\d{4}$`), nil},
+	"JP": {regexp.MustCompile(`^\d{3}-?\d{4}$`), func(code string) string {
+		code = strings.ReplaceAll(code, "-", "")
+		return code[:3] + "-" + code[3:]
+	}},
+}
+
+var (
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+	genericPattern = regexp.MustCompile(`^[A-Z\d-]{2,10}$`)
+)
+
+// Compact postal code so it could be compared,
+// space is removed and letter is upper cased
+func Compact(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
+
+// Function to normalize country and postal code into canonical
+// form, ex: ("gb", "sw1a1aa") would be ("GB", "SW1A 1AA").
+// Error is returned when the code is not valid for the country
+func NormalizePostalCode(country, code string) (string, string, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	code = Compact(code)
+
+	if !countryPattern.MatchString(country) {
+		return country, code, ErrCountry
+	}
+
+	format, ok := Formats[country]
+
+	if !ok {
+		if !genericPattern.MatchString(code) {
+			return country, code, ErrPostalCode
+		}
+
+		return country, code, nil
+	}
+
+	if !format.Pattern.MatchString(code) {
+		return country, code, ErrPostalCode
+	}
+
+	if format.Canonical != nil {
+		code = format.Canonical(code)
+	}
+
+	return country, code, nil
+}
@@ -0,0 +1,72 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePostalCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		country     string
+		code        string
+		wantCountry string
+		wantCode    string
+		wantErr     error
+	}{
+		{"us zip", "us", "02108", "US", "02108", nil},
+		{"us zip+4", "US", "021081234", "US", "02108-1234", nil},
+		{"us zip+4 with dash", "US", "02108-1234", "US", "02108-1234", nil},
+		{"us short zip", "US", "2108", "US", "2108", ErrPostalCode},
+		{"gb compact", "gb", "sw1a1aa", "GB", "SW1A 1AA", nil},
+		{"gb spaced", "GB", " SW1A 1AA ", "GB", "SW1A 1AA", nil},
+		{"gb short outward", "GB", "m11ae", "GB", "M1 1AE", nil},
+		{"gb invalid", "GB", "12345", "GB", "12345", ErrPostalCode},
+		{"ca", "CA", "k1a0b1", "CA", "K1A 0B1", nil},
+		{"ca invalid letter", "CA", "D1A0B1", "CA", "D1A0B1", ErrPostalCode},
+		{"nl", "NL", "1012ab", "NL", "1012 AB", nil},
+		{"nl leading zero", "NL", "0123AB", "NL", "0123AB", ErrPostalCode},
+		{"jp", "JP", "1000001", "JP", "100-0001", nil},
+		{"jp with dash", "JP", "100-0001", "JP", "100-0001", nil},
+		{"de", "DE", "10115", "DE", "10115", nil},
+		{"au", "AU", "2000", "AU", "2000", nil},
+		{"unlisted country", "br", "01310-100", "BR", "01310-100", nil},
+		{"unlisted invalid", "BR", "01310_100", "BR", "01310_100", ErrPostalCode},
+		{"country name", "USA", "02108", "USA", "02108", ErrCountry},
+		{"empty country", "", "02108", "", "02108", ErrCountry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			country, code, err := NormalizePostalCode(tt.country, tt.code)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NormalizePostalCode() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if country != tt.wantCountry || code != tt.wantCode {
+				t.Errorf("NormalizePostalCode() = %q, %q, want %q, %q", country, code, tt.wantCountry, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"sw1a 1aa", "SW1A1AA"},
+		{" 02108 ", "02108"},
+		{"100-0001", "100-0001"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := Compact(tt.code); got != tt.want {
+				t.Errorf("Compact(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,4 +7,5 @@ require (
 	github.com/go-chi/render v1.0.1
 	github.com/h2non/bimg v1.1.5
 	github.com/rs/xid v1.3.0
+	golang.org/x/crypto v0.0.0-20220214200702-86341886e292
 )
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -9,17 +11,65 @@ import (
 	"github.com/go-chi/render"
 
 	"github.com/ArkjuniorK/store_app/api"
+	"github.com/ArkjuniorK/store_app/geo"
 	"github.com/ArkjuniorK/store_app/static"
+	"github.com/ArkjuniorK/store_app/tenant"
+	"github.com/ArkjuniorK/store_app/web"
 )
 
 func main() {
+	wd, err := os.Getwd()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// load tenants first since every command
+	// read and write data inside tenant root
+	if err = tenant.Load(wd); err != nil {
+		log.Fatal(err)
+	}
+
+	// run fsck, migrate, role or mockoidc command instead of serving when requested
+	if len(os.Args) > 1 && os.Args[1] == "fsck" {
+		fsck(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		role(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "mockoidc" {
+		mockOIDC(os.Args[2:])
+		return
+	}
+
+	// radius search need the full postal code dataset,
+	// the error is already logged by geo package
+	if geo.Load() != nil {
+		os.Exit(1)
+	}
+
+	// hold storage of each tenant before anything is written
+	lockStorage()
+
+	// migrate legacy cat data of each tenant before serving
+	// so it could be read by the controllers
+	startMigrate()
+
+	// check orphan image and dangling link periodically
+	startFsck()
 
 	// define the router
 	r := chi.NewRouter()
 
-	// working directory
-	// wd, _ := os.Getwd()
-
 	// base middleware stack
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
@@ -32,24 +82,6 @@ func main() {
 	// processing should be stopped.
 	r.Use(middleware.Timeout(60 * time.Second))
 
-	// // base route
-	// r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
-	// 	// render.PlainText(w, r, "Welcome!")
-	// 	filedir := http.Dir(filepath.Join(wd, "view"))
-	// 	rctx := chi.RouteContext(r.Context())
-	// 	pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
-	// 	fs := http.StripPrefix(pathPrefix, http.FileServer(filedir))
-	// 	fs.ServeHTTP(w, r)
-	// })
-
-	// r.Get("/source/*", func(w http.ResponseWriter, r *http.Request) {
-	// 	filedir := http.Dir(filepath.Join(wd, "source"))
-	// 	rctx := chi.RouteContext(r.Context())
-	// 	pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
-	// 	fs := http.StripPrefix(pathPrefix, http.FileServer(filedir))
-	// 	fs.ServeHTTP(w, r)
-	// })
-
 	// api endpoints to "/api" endpoint to create more convienent
 	// way of managing the endpoint structure, this endpoint would
 	// used to access all api request to backend
@@ -58,6 +90,10 @@ func main() {
 	// static endpoints to "/static" endpoint to manage static assets
 	r.Mount("/static", static.Entry{}.Routes())
 
+	// everything else is served by the Vue app, embedded
+	// to the binary or proxied to dev server on dev mode
+	r.Mount("/", web.Entry{}.Routes())
+
 	// serve the route
 	http.ListenAndServe(":3000", r)
 }
@@ -0,0 +1,66 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ArkjuniorK/store_app/geo"
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Convert plain address and numeric zip code into models.Address,
+// zip code was stored as number in US so the leading zero is dropped
+func migrateAddress(fields map[string]json.RawMessage) (bool, bool, error) {
+	// address is already an object
+	if !strings.HasPrefix(strings.TrimSpace(string(fields["address"])), `"`) && fields["zip_code"] == nil {
+		return false, false, nil
+	}
+
+	var (
+		street string
+		zip    json.Number
+	)
+
+	if err := json.Unmarshal(fields["address"], &street); err != nil {
+		return false, false, err
+	}
+
+	if fields["zip_code"] != nil {
+		if err := json.Unmarshal(fields["zip_code"], &zip); err != nil {
+			return false, false, err
+		}
+	}
+
+	code := zip.String()
+
+	if n, err := zip.Int64(); err == nil {
+		code = fmt.Sprintf("%05d", n)
+	}
+
+	address := models.Address{Street: street, PostalCode: code, Country: geo.DefaultCountry}
+	address.Normalize()
+
+	place, found := geo.Find(address.Country, address.PostalCode)
+
+	if found {
+		address.City = place.City
+		address.Region = place.Region
+	}
+
+	var err error
+
+	if fields["address"], err = json.Marshal(address); err != nil {
+		return false, false, err
+	}
+
+	if found {
+		if fields["location"], err = json.Marshal(place.Point); err != nil {
+			return false, false, err
+		}
+	}
+
+	delete(fields, "zip_code")
+
+	return true, !found, nil
+}
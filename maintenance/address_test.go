@@ -0,0 +1,93 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+func TestMain(m *testing.M) {
+	// postal code is looked up in the bundled sample
+	os.Setenv("APP_ENV", "dev")
+	os.Exit(m.Run())
+}
+
+func TestMigrateAddress(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		wantChanged    bool
+		wantIncomplete bool
+		wantAddress    models.Address
+		wantLocation   bool
+	}{
+		{
+			"numeric zip drop leading zero",
+			`{"address":"1 Beacon St","zip_code":2108}`,
+			true, false,
+			models.Address{Street: "1 Beacon St", City: "Boston", Region: "MA", PostalCode: "02108", Country: "US"},
+			true,
+		},
+		{
+			"unknown zip",
+			`{"address":"1 Main St","zip_code":99999}`,
+			true, true,
+			models.Address{Street: "1 Main St", PostalCode: "99999", Country: "US"},
+			false,
+		},
+		{
+			"address without zip",
+			`{"address":"1 Main St"}`,
+			true, true,
+			models.Address{Street: "1 Main St", Country: "US"},
+			false,
+		},
+		{
+			"already migrated",
+			`{"address":{"street":"1 Beacon St","city":"Boston","postal_code":"02108","country":"US"}}`,
+			false, false,
+			models.Address{Street: "1 Beacon St", City: "Boston", PostalCode: "02108", Country: "US"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields map[string]json.RawMessage
+
+			if err := json.Unmarshal([]byte(tt.data), &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			changed, incomplete, err := migrateAddress(fields)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if changed != tt.wantChanged || incomplete != tt.wantIncomplete {
+				t.Errorf("migrateAddress() = %v, %v, want %v, %v", changed, incomplete, tt.wantChanged, tt.wantIncomplete)
+			}
+
+			var address models.Address
+
+			if err = json.Unmarshal(fields["address"], &address); err != nil {
+				t.Fatal(err)
+			}
+
+			if address != tt.wantAddress {
+				t.Errorf("address = %+v, want %+v", address, tt.wantAddress)
+			}
+
+			if _, ok := fields["location"]; ok != tt.wantLocation {
+				t.Errorf("location = %v, want %v", ok, tt.wantLocation)
+			}
+
+			if _, ok := fields["zip_code"]; ok {
+				t.Error("zip_code is not removed")
+			}
+		})
+	}
+}
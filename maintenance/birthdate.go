@@ -0,0 +1,45 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Convert static age in years into approximate birthdate, the age
+// was entered when the cat is created so it's counted from that time
+func migrateBirthdate(fields map[string]json.RawMessage) (bool, bool, error) {
+	if fields["birthdate"] != nil {
+		return false, false, nil
+	}
+
+	var (
+		age    int
+		create time.Time
+	)
+
+	if fields["age"] != nil {
+		if err := json.Unmarshal(fields["age"], &age); err != nil {
+			return false, false, err
+		}
+	}
+
+	if err := json.Unmarshal(fields["created_at"], &create); err != nil {
+		return false, false, err
+	}
+
+	// only the month of birth could be estimated
+	birth := models.Birthdate{Date: create.AddDate(-age, 0, 0).Format("2006-01"), Approximate: true}
+
+	data, err := json.Marshal(birth)
+
+	if err != nil {
+		return false, false, err
+	}
+
+	fields["birthdate"] = data
+	delete(fields, "age")
+
+	return true, false, nil
+}
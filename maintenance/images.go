@@ -0,0 +1,412 @@
+// ======================
+// This package is package to store maintenance task for the storage.
+// Since cat data is stored as json file inside "data/cats" and the image
+// is stored inside "static/cats" both of them could be out of sync, for
+// example when uploading image failed or cat is deleted. Logo of shelter
+// inside "data/shelters" is stored the same way as cat's image.
+// Task inside this package would be used by "fsck" command and by
+// background job that started from main.go, migration of cat data
+// would be used by "migrate" command and on server start
+// ======================
+
+package maintenance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/storage"
+)
+
+// Grace period for image file before it could be treated as orphan,
+// image middleware write the image before the controller write the
+// link to cat data so fresh image would not be counted
+const GracePeriod = 10 * time.Minute
+
+// Dangling type store information of cat's link or shelter's
+// logo which the image file is not exist on disk
+type Dangling struct {
+	CatID     string `json:"cat_id,omitempty"`
+	ShelterID string `json:"shelter_id,omitempty"`
+	LinkID    string `json:"link_id"`
+	URL       string `json:"url"`
+}
+
+// Report type store the result of checking storage
+type Report struct {
+	Orphans    []string    `json:"orphans"`    // image filename without cat or shelter
+	Dangling   []*Dangling `json:"dangling"`   // cat link without image
+	Miscounted []string    `json:"miscounted"` // image with wrong reference count
+
+	// reference count of existing image
+	// counted from cat data
+	refs storage.Refs
+}
+
+// Clean would return true when there is no problem found
+func (rp *Report) Clean() bool {
+	return len(rp.Orphans) == 0 && len(rp.Dangling) == 0 && len(rp.Miscounted) == 0
+}
+
+// Function to cross check cat data in "data/cats" with image
+// in "static/cats" inside working directory wd
+func Check(wd string) (*Report, error) {
+	var (
+		report     *Report      = &Report{refs: make(storage.Refs)}
+		referenced storage.Refs = make(storage.Refs)
+		dataDir                 = filepath.Join(wd, "data/cats")
+		imageDir                = filepath.Join(wd, "static/cats")
+	)
+
+	// read all cat data
+	catsDir, err := ioutil.ReadDir(dataDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// read all image file
+	imagesDir, err := ioutil.ReadDir(imageDir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// store existing image so it could be compared
+	// with cat's link
+	exist := make(map[string]bool, len(imagesDir))
+	for _, v := range imagesDir {
+		exist[v.Name()] = true
+	}
+
+	// loop each cat data and find link which
+	// image is not exist on disk
+	for _, v := range catsDir {
+		var cat models.Cat
+
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dataDir, v.Name()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		// skip broken cat data instead of stopping
+		// the whole check
+		if err = json.Unmarshal(data, &cat); err != nil {
+			log.Printf("fsck: skip %s: %v", v.Name(), err)
+			continue
+		}
+
+		if cat.Image == nil {
+			continue
+		}
+
+		for _, link := range *cat.Image {
+			var (
+				files    = storage.LinkFiles(link)
+				dangling = false
+			)
+
+			for _, filename := range files {
+				referenced[filename]++
+				dangling = dangling || !exist[filename]
+			}
+
+			// only link that would be kept by repair is counted
+			if !dangling {
+				for _, filename := range files {
+					report.refs[filename]++
+				}
+			} else {
+				report.Dangling = append(report.Dangling, &Dangling{
+					CatID:  cat.ID.String(),
+					LinkID: link.ID.String(),
+					URL:    link.URL,
+				})
+			}
+		}
+	}
+
+	// shelter's logo is referenced the same as cat's link
+	shelters, err := readShelters(filepath.Join(wd, "data/shelters"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, shelter := range shelters {
+		var (
+			files    = storage.LinkFiles(shelter.Logo)
+			dangling = false
+		)
+
+		for _, filename := range files {
+			referenced[filename]++
+			dangling = dangling || !exist[filename]
+		}
+
+		if !dangling {
+			for _, filename := range files {
+				report.refs[filename]++
+			}
+		} else {
+			report.Dangling = append(report.Dangling, &Dangling{
+				ShelterID: shelter.ID.String(),
+				LinkID:    shelter.Logo.ID.String(),
+				URL:       shelter.Logo.URL,
+			})
+		}
+	}
+
+	// then find image that is not referenced by any cat or shelter
+	for _, v := range imagesDir {
+		if v.IsDir() || referenced[v.Name()] > 0 {
+			continue
+		}
+
+		if time.Since(v.ModTime()) < GracePeriod {
+			continue
+		}
+
+		report.Orphans = append(report.Orphans, v.Name())
+	}
+
+	// last compare the counted reference with
+	// the stored reference count
+	refs, err := storage.ReadRefs(wd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range report.refs {
+		if refs[k] != v {
+			report.Miscounted = append(report.Miscounted, k)
+		}
+	}
+
+	for k := range refs {
+		if _, ok := report.refs[k]; !ok {
+			report.Miscounted = append(report.Miscounted, k)
+		}
+	}
+
+	return report, nil
+}
+
+// Function to repair the problem of storage, orphan image would
+// be deleted from disk, dangling link would be dropped from cat data
+// and reference count of each image would be rebuilt. Storage is
+// checked again while no request could change cat or shelter data,
+// so upload or delete after the previous check is never lost.
+// Return the report that has been repaired
+func Repair(wd string) (*Report, error) {
+	var report *Report
+
+	err := storage.Exclusive(func() error {
+		var err error
+
+		if report, err = Check(wd); err != nil {
+			return err
+		}
+
+		return repair(wd, report)
+	})
+
+	return report, err
+}
+
+// Repair the problem found in report, it must be
+// called inside storage.Exclusive
+func repair(wd string, report *Report) error {
+	dataDir := filepath.Join(wd, "data/cats")
+
+	// delete orphan image
+	for _, v := range report.Orphans {
+		if err := storage.RemoveOrphan(wd, v); err != nil {
+			return err
+		}
+	}
+
+	// group dangling link by cat id so each
+	// cat data only written once
+	links := make(map[string]map[string]bool)
+	for _, v := range report.Dangling {
+		// dangling logo is dropped from the shelter
+		if v.ShelterID != "" {
+			if err := dropLogo(filepath.Join(wd, "data/shelters", v.ShelterID+".json"), v.LinkID); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if links[v.CatID] == nil {
+			links[v.CatID] = make(map[string]bool)
+		}
+
+		links[v.CatID][v.LinkID] = true
+	}
+
+	for id, dangling := range links {
+		var cat models.Cat
+
+		file := filepath.Join(dataDir, id+".json")
+		data, err := ioutil.ReadFile(file)
+
+		// cat could be deleted after checking
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = json.Unmarshal(data, &cat); err != nil {
+			return err
+		}
+
+		if cat.Image == nil {
+			continue
+		}
+
+		// keep link that is not dangling
+		picture := make(models.Picture, 0, len(*cat.Image))
+		for _, link := range *cat.Image {
+			if !dangling[link.ID.String()] {
+				picture = append(picture, link)
+			}
+		}
+
+		*cat.Image = picture
+
+		data, err = json.Marshal(cat)
+
+		if err != nil {
+			return err
+		}
+
+		if err = ioutil.WriteFile(file, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	// dangling link is not counted so reference
+	// count could be replaced as is
+	return storage.Rebuild(wd, report.refs)
+}
+
+// Read shelters with logo inside dir, missing directory
+// means there is no shelter and broken data is skipped
+func readShelters(dir string) (models.Shelters, error) {
+	var shelters models.Shelters
+
+	files, err := ioutil.ReadDir(dir)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range files {
+		var shelter models.Shelter
+
+		if v.IsDir() || filepath.Ext(v.Name()) != ".json" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, v.Name()))
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(data, &shelter); err != nil {
+			log.Printf("fsck: skip %s: %v", v.Name(), err)
+			continue
+		}
+
+		if shelter.Logo != nil {
+			shelters = append(shelters, &shelter)
+		}
+	}
+
+	return shelters, nil
+}
+
+// Remove logo of shelter data in file when it's still the dangling link
+func dropLogo(file, linkID string) error {
+	var shelter models.Shelter
+
+	data, err := ioutil.ReadFile(file)
+
+	// shelter could be deleted after checking
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, &shelter); err != nil {
+		return err
+	}
+
+	if shelter.Logo == nil || shelter.Logo.ID.String() != linkID {
+		return nil
+	}
+
+	shelter.Logo = nil
+
+	if data, err = json.Marshal(shelter); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// Function to run Check periodically as background job,
+// when repair is true the problem would be repaired too.
+// It would block so it should be called as goroutine
+func Schedule(wd string, interval time.Duration, repair bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		report, err := Check(wd)
+
+		if err != nil {
+			log.Printf("fsck: %v", err)
+			continue
+		}
+
+		if report.Clean() {
+			continue
+		}
+
+		log.Printf("fsck: found %d orphan image, %d dangling link and %d miscounted image",
+			len(report.Orphans), len(report.Dangling), len(report.Miscounted))
+
+		if !repair {
+			continue
+		}
+
+		if _, err = Repair(wd); err != nil {
+			log.Printf("fsck: error repair: %v", err)
+		}
+	}
+}
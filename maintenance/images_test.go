@@ -0,0 +1,323 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/storage"
+)
+
+// Working directory of test storage
+type testStorage struct {
+	t  *testing.T
+	wd string
+}
+
+// Create working directory with data and image directory
+func newStorage(t *testing.T) *testStorage {
+	t.Helper()
+
+	wd := t.TempDir()
+
+	for _, dir := range []string{"data/cats", "data/shelters", "static/cats"} {
+		if err := os.MkdirAll(filepath.Join(wd, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &testStorage{t, wd}
+}
+
+// Write image file, old image is outside the grace period
+func (s *testStorage) image(name string, old bool) {
+	s.t.Helper()
+
+	path := filepath.Join(s.wd, "static/cats", name)
+
+	if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+		s.t.Fatal(err)
+	}
+
+	if old {
+		at := time.Now().Add(-2 * GracePeriod)
+
+		if err := os.Chtimes(path, at, at); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+}
+
+// Create link to image
+func link(name string) *models.Link {
+	return &models.Link{ID: xid.New(), URL: "http://localhost:3000/static/cats/" + name}
+}
+
+// Write cat with the links
+func (s *testStorage) cat(links ...*models.Link) *models.Cat {
+	s.t.Helper()
+
+	picture := models.Picture(links)
+	cat := &models.Cat{ID: xid.New(), Name: "Tom", Image: &picture}
+
+	s.write(filepath.Join("data/cats", cat.ID.String()+".json"), cat)
+
+	return cat
+}
+
+// Write shelter with the logo
+func (s *testStorage) shelter(logo *models.Link) *models.Shelter {
+	s.t.Helper()
+
+	shelter := &models.Shelter{ID: xid.New(), Name: "Paws", Logo: logo}
+	s.write(filepath.Join("data/shelters", shelter.ID.String()+".json"), shelter)
+
+	return shelter
+}
+
+// Write reference count
+func (s *testStorage) refs(refs storage.Refs) {
+	s.t.Helper()
+	s.write("data/refs.json", refs)
+}
+
+// Write value as json file inside working directory
+func (s *testStorage) write(name string, v interface{}) {
+	s.t.Helper()
+
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(s.wd, name), data, 0644); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// Read json file inside working directory
+func (s *testStorage) read(name string, v interface{}) {
+	s.t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join(s.wd, name))
+
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// Get sorted link ids of dangling links
+func danglingIDs(report *Report) []string {
+	ids := []string{}
+	for _, v := range report.Dangling {
+		ids = append(ids, v.LinkID)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Compare sorted string slices, nil is the same as empty
+func sameStrings(a, b []string) bool {
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		setup          func(s *testStorage) []string // return wanted dangling link ids
+		wantOrphans    []string
+		wantMiscounted []string
+	}{
+		{"clean", func(s *testStorage) []string {
+			s.image("a.webp", true)
+			s.image("b.webp", true)
+			s.cat(link("a.webp"), link("b.webp"))
+			s.cat(link("a.webp"))
+			s.refs(storage.Refs{"a.webp": 2, "b.webp": 1})
+			return nil
+		}, nil, nil},
+		{"orphan image", func(s *testStorage) []string {
+			s.image("a.webp", true)
+			s.image("old.webp", true)
+			s.cat(link("a.webp"))
+			s.refs(storage.Refs{"a.webp": 1})
+			return nil
+		}, []string{"old.webp"}, nil},
+		{"fresh image is not orphan", func(s *testStorage) []string {
+			s.image("fresh.webp", false)
+			return nil
+		}, nil, nil},
+		{"dangling link", func(s *testStorage) []string {
+			s.image("a.webp", true)
+			missing := link("missing.webp")
+			s.cat(link("a.webp"), missing)
+			s.refs(storage.Refs{"a.webp": 1})
+			return []string{missing.ID.String()}
+		}, nil, nil},
+		{"dangling poster", func(s *testStorage) []string {
+			s.image("a.webp", true)
+			animated := link("a.webp")
+			animated.Poster = "http://localhost:3000/static/cats/poster.webp"
+			s.cat(animated)
+			return []string{animated.ID.String()}
+		}, nil, nil},
+		{"dangling logo", func(s *testStorage) []string {
+			logo := link("logo.webp")
+			s.shelter(logo)
+			return []string{logo.ID.String()}
+		}, nil, nil},
+		{"logo is referenced", func(s *testStorage) []string {
+			s.image("logo.webp", true)
+			s.shelter(link("logo.webp"))
+			s.refs(storage.Refs{"logo.webp": 1})
+			return nil
+		}, nil, nil},
+		{"miscounted", func(s *testStorage) []string {
+			s.image("a.webp", true)
+			s.image("b.webp", true)
+			s.cat(link("a.webp"), link("b.webp"))
+			s.refs(storage.Refs{"a.webp": 2, "gone.webp": 1})
+			return nil
+		}, nil, []string{"a.webp", "b.webp", "gone.webp"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t)
+			wantDangling := tt.setup(s)
+			sort.Strings(wantDangling)
+
+			report, err := Check(s.wd)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !sameStrings(report.Orphans, tt.wantOrphans) {
+				t.Errorf("orphans = %v, want %v", report.Orphans, tt.wantOrphans)
+			}
+
+			if got := danglingIDs(report); !sameStrings(got, wantDangling) {
+				t.Errorf("dangling = %v, want %v", got, wantDangling)
+			}
+
+			if !sameStrings(report.Miscounted, tt.wantMiscounted) {
+				t.Errorf("miscounted = %v, want %v", report.Miscounted, tt.wantMiscounted)
+			}
+
+			wantClean := len(tt.wantOrphans) == 0 && len(wantDangling) == 0 && len(tt.wantMiscounted) == 0
+
+			if report.Clean() != wantClean {
+				t.Errorf("Clean() = %v, want %v", report.Clean(), wantClean)
+			}
+		})
+	}
+}
+
+func TestCheckWithoutDirectory(t *testing.T) {
+	if _, err := Check(t.TempDir()); err == nil {
+		t.Error("Check() of working directory without data error = nil, want error")
+	}
+}
+
+func TestRepair(t *testing.T) {
+	s := newStorage(t)
+
+	s.image("a.webp", true)
+	s.image("old.webp", true)
+
+	kept, missing := link("a.webp"), link("missing.webp")
+	cat := s.cat(kept, missing)
+	shelter := s.shelter(link("logo.webp"))
+	s.refs(storage.Refs{"a.webp": 3, "missing.webp": 1})
+
+	// image that is saved by request but not yet linked
+	pending, err := storage.Save(s.wd, []byte("pending"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer storage.Discard(s.wd, pending)
+
+	at := time.Now().Add(-2 * GracePeriod)
+	if err = os.Chtimes(filepath.Join(s.wd, "static/cats", pending), at, at); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Repair(s.wd)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Dangling) != 2 || !sameStrings(report.Orphans, []string{"old.webp", pending}) {
+		t.Errorf("Repair() = %+v, want the problem that is repaired", report)
+	}
+
+	if _, err = os.Stat(filepath.Join(s.wd, "static/cats/old.webp")); !os.IsNotExist(err) {
+		t.Error("orphan image is not removed")
+	}
+
+	if _, err = os.Stat(filepath.Join(s.wd, "static/cats", pending)); err != nil {
+		t.Error("pending image is removed as orphan")
+	}
+
+	var got models.Cat
+	s.read(filepath.Join("data/cats", cat.ID.String()+".json"), &got)
+
+	if got.Image == nil || len(*got.Image) != 1 || (*got.Image)[0].ID != kept.ID {
+		t.Errorf("links = %v, want only link with image", got.Image)
+	}
+
+	var gotShelter models.Shelter
+	s.read(filepath.Join("data/shelters", shelter.ID.String()+".json"), &gotShelter)
+
+	if gotShelter.Logo != nil {
+		t.Errorf("logo = %v, want dangling logo dropped", gotShelter.Logo)
+	}
+
+	refs, err := storage.ReadRefs(s.wd)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(refs) != 1 || refs["a.webp"] != 1 {
+		t.Errorf("refs = %v, want rebuilt count", refs)
+	}
+
+	// pending image is still reported until it's linked or discarded
+	if report, err = Check(s.wd); err != nil || !sameStrings(report.Orphans, []string{pending}) || len(report.Dangling) != 0 || len(report.Miscounted) != 0 {
+		t.Errorf("Check() after repair = %+v, %v, want only the pending image", report, err)
+	}
+}
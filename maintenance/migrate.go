@@ -0,0 +1,164 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Migration type store the result of migrating cat data
+// to the current format of models.Cat
+type Migration struct {
+	Migrated   []string `json:"migrated"`   // cat id that is migrated
+	Incomplete []string `json:"incomplete"` // migrated cat that need to be reviewed
+	Failed     []string `json:"failed"`     // cat id that could not be migrated
+	Unassigned []string `json:"unassigned"` // cat id that still has no shelter
+}
+
+// step type is one change of cat data format, step change the
+// fields in place and report whether it's changed. incomplete is
+// true when some value could not be known from the legacy data
+type step func(fields map[string]json.RawMessage) (changed, incomplete bool, err error)
+
+// Steps of migration in the order it's applied,
+// new step should be appended to the last
+var steps = []step{
+	migrateAddress,
+	migrateBirthdate,
+	migrateStatus,
+}
+
+// Apply all steps to cat data then assign shelter to cat without
+// one, cat is nil when the data is already in current format
+func migrateCat(data []byte, shelter xid.ID) (*models.Cat, bool, error) {
+	var (
+		fields     map[string]json.RawMessage
+		changed    bool
+		incomplete bool
+	)
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, false, err
+	}
+
+	for _, step := range append(steps[:len(steps):len(steps)], migrateShelter(shelter)) {
+		c, i, err := step(fields)
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		changed = changed || c
+		incomplete = incomplete || i
+	}
+
+	if !changed {
+		return nil, false, nil
+	}
+
+	// decode it again as cat so it's written
+	// the same way as the controller does
+	data, err := json.Marshal(fields)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	cat := new(models.Cat)
+
+	if err = json.Unmarshal(data, cat); err != nil {
+		return nil, false, err
+	}
+
+	return cat, incomplete, nil
+}
+
+// Function to migrate cat data in "data/cats" inside working directory
+// wd, data that is already migrated would be skipped so it's safe to be
+// run multiple times. Cat without shelter is assigned to shelter unless
+// it's nil, otherwise it's reported as unassigned. Nothing is written
+// when dry is true
+func Migrate(wd string, dry bool, shelter xid.ID) (*Migration, error) {
+	var (
+		migration = &Migration{Migrated: []string{}, Incomplete: []string{}, Failed: []string{}, Unassigned: []string{}}
+		dataDir   = filepath.Join(wd, "data/cats")
+	)
+
+	files, err := filepath.Glob(filepath.Join(dataDir, "*.json"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		id := strings.TrimSuffix(filepath.Base(file), ".json")
+
+		data, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cat, incomplete, err := migrateCat(data, shelter)
+
+		if err != nil {
+			migration.Failed = append(migration.Failed, id)
+			continue
+		}
+
+		// only admin could manage cat without shelter
+		if cat != nil && cat.Shelter.IsNil() || cat == nil && !hasShelter(data) {
+			migration.Unassigned = append(migration.Unassigned, id)
+		}
+
+		if cat == nil {
+			continue
+		}
+
+		migration.Migrated = append(migration.Migrated, id)
+
+		if incomplete {
+			migration.Incomplete = append(migration.Incomplete, id)
+		}
+
+		if dry {
+			continue
+		}
+
+		if data, err = json.Marshal(cat); err != nil {
+			return nil, err
+		}
+
+		// write to temporary file first so the
+		// cat data would not be written partially
+		tmp := file + ".tmp"
+
+		if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+			return nil, err
+		}
+
+		if err = os.Rename(tmp, file); err != nil {
+			os.Remove(tmp)
+			return nil, err
+		}
+	}
+
+	return migration, nil
+}
+
+// Check whether cat data that is not migrated has shelter
+func hasShelter(data []byte) bool {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+
+	return !unassigned(fields)
+}
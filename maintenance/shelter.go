@@ -0,0 +1,40 @@
+package maintenance
+
+import (
+	"encoding/json"
+
+	"github.com/rs/xid"
+)
+
+// Assign shelter to cat that is created before shelters exist,
+// the cat is left without shelter when shelter is nil so it
+// is only reported as unassigned
+func migrateShelter(shelter xid.ID) step {
+	return func(fields map[string]json.RawMessage) (bool, bool, error) {
+		if !unassigned(fields) || shelter.IsNil() {
+			return false, false, nil
+		}
+
+		data, err := json.Marshal(shelter)
+
+		if err != nil {
+			return false, false, err
+		}
+
+		fields["shelter_id"] = data
+
+		return true, false, nil
+	}
+}
+
+// Check whether cat has no shelter, zero id is written
+// as null when the cat is saved without shelter
+func unassigned(fields map[string]json.RawMessage) bool {
+	var id string
+
+	if err := json.Unmarshal(fields["shelter_id"], &id); err != nil {
+		return fields["shelter_id"] == nil
+	}
+
+	return id == "" || id == xid.NilID().String()
+}
@@ -0,0 +1,165 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+func TestUnassigned(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"missing", `{}`, true},
+		{"null", `{"shelter_id":null}`, true},
+		{"empty", `{"shelter_id":""}`, true},
+		{"zero id", `{"shelter_id":"00000000000000000000"}`, true},
+		{"assigned", `{"shelter_id":"` + xid.New().String() + `"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields map[string]json.RawMessage
+
+			if err := json.Unmarshal([]byte(tt.data), &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := unassigned(fields); got != tt.want {
+				t.Errorf("unassigned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Write cat in current format to "data/cats" of wd
+func writeTestCat(t *testing.T, wd string, shelter xid.ID) *models.Cat {
+	t.Helper()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	cat := &models.Cat{
+		ID:      xid.New(),
+		Name:    "Tom",
+		Shelter: shelter,
+		Create:  now,
+		Update:  now,
+		Status:  models.Available,
+		History: []*models.Transition{{To: models.Available, Reason: "created", At: now}},
+	}
+
+	data, err := json.Marshal(cat)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(wd, "data/cats", cat.ID.String()+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return cat
+}
+
+// Read shelter of cat in "data/cats" of wd
+func readTestShelter(t *testing.T, wd string, id xid.ID) xid.ID {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join(wd, "data/cats", id.String()+".json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cat := new(models.Cat)
+
+	if err = json.Unmarshal(data, cat); err != nil {
+		t.Fatal(err)
+	}
+
+	return cat.Shelter
+}
+
+func TestMigrateShelter(t *testing.T) {
+	wd := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(wd, "data/cats"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	own, shelter := xid.New(), xid.New()
+	legacy := writeTestCat(t, wd, xid.NilID())
+	assigned := writeTestCat(t, wd, own)
+
+	t.Run("without shelter", func(t *testing.T) {
+		migration, err := Migrate(wd, false, xid.NilID())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(migration.Migrated) != 0 {
+			t.Errorf("Migrated = %v, want none", migration.Migrated)
+		}
+
+		if len(migration.Unassigned) != 1 || migration.Unassigned[0] != legacy.ID.String() {
+			t.Errorf("Unassigned = %v, want [%s]", migration.Unassigned, legacy.ID)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		migration, err := Migrate(wd, true, shelter)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(migration.Migrated) != 1 || migration.Migrated[0] != legacy.ID.String() {
+			t.Errorf("Migrated = %v, want [%s]", migration.Migrated, legacy.ID)
+		}
+
+		if got := readTestShelter(t, wd, legacy.ID); !got.IsNil() {
+			t.Errorf("shelter = %s, want none on dry run", got)
+		}
+	})
+
+	t.Run("with shelter", func(t *testing.T) {
+		migration, err := Migrate(wd, false, shelter)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(migration.Unassigned) != 0 {
+			t.Errorf("Unassigned = %v, want none", migration.Unassigned)
+		}
+
+		if got := readTestShelter(t, wd, legacy.ID); got != shelter {
+			t.Errorf("legacy cat shelter = %s, want %s", got, shelter)
+		}
+
+		if got := readTestShelter(t, wd, assigned.ID); got != own {
+			t.Errorf("assigned cat shelter = %s, want %s", got, own)
+		}
+	})
+
+	t.Run("already migrated", func(t *testing.T) {
+		migration, err := Migrate(wd, false, xid.New())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(migration.Migrated) != 0 || len(migration.Unassigned) != 0 {
+			t.Errorf("Migration = %+v, want nothing to migrate", migration)
+		}
+	})
+}
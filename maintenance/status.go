@@ -0,0 +1,35 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Set status of cat that is created before adoption status exist,
+// the cat was listed for adoption so it's treated as available
+func migrateStatus(fields map[string]json.RawMessage) (bool, bool, error) {
+	if fields["status"] != nil {
+		return false, false, nil
+	}
+
+	var create time.Time
+
+	if err := json.Unmarshal(fields["created_at"], &create); err != nil {
+		return false, false, err
+	}
+
+	history := []*models.Transition{{To: models.Available, Reason: "migrated", At: create}}
+
+	data, err := json.Marshal(history)
+
+	if err != nil {
+		return false, false, err
+	}
+
+	fields["status"], _ = json.Marshal(models.Available)
+	fields["status_history"] = data
+
+	return true, false, nil
+}
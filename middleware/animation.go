@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+
+	"github.com/h2non/bimg"
+)
+
+// Maximum frames of animated image that would be processed
+const maxFrames = 300
+
+// Maximum canvas of animated image and maximum pixels of all
+// frames, GIF could declare huge canvas in a few bytes so it's
+// checked before the frames is decoded
+const (
+	maxCanvas = 4096 * 4096
+	maxPixels = 100000000
+)
+
+// Errors of animated image that is too large to be processed
+var (
+	errTooLarge      = errors.New("animated image is too large")
+	errTooManyFrames = errors.New("animated image has too many frames")
+)
+
+// Options used to resize image and each frame of animated image
+var resizeOptions = bimg.Options{
+	Width:       800,
+	Height:      0,
+	Quality:     80,
+	Compression: 80,
+	Type:        bimg.WEBP,
+}
+
+// Skip GIF sub-blocks starting at i, return index after the terminator
+func skipBlocks(buff []byte, i int) (int, bool) {
+	for i < len(buff) {
+		size := int(buff[i])
+		i += size + 1
+
+		if size == 0 {
+			return i, true
+		}
+	}
+
+	return i, false
+}
+
+// Read canvas size and number of frames of GIF without decoding
+// the frames, ok is false when buff is not valid GIF
+func scanGIF(buff []byte) (width, height, frames int, ok bool) {
+	if len(buff) < 13 || (string(buff[:6]) != "GIF87a" && string(buff[:6]) != "GIF89a") {
+		return 0, 0, 0, false
+	}
+
+	width = int(binary.LittleEndian.Uint16(buff[6:8]))
+	height = int(binary.LittleEndian.Uint16(buff[8:10]))
+
+	// global color table
+	i := 13
+	if buff[10]&0x80 != 0 {
+		i += 3 << (buff[10]&0x07 + 1)
+	}
+
+	for i < len(buff) {
+		switch buff[i] {
+		case 0x21: // extension
+			if i, ok = skipBlocks(buff, i+2); !ok {
+				return 0, 0, 0, false
+			}
+		case 0x2C: // image descriptor
+			if i+10 > len(buff) {
+				return 0, 0, 0, false
+			}
+
+			frames++
+
+			// local color table then LZW minimum code size
+			flags := buff[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+
+			if i, ok = skipBlocks(buff, i+1); !ok {
+				return 0, 0, 0, false
+			}
+		case 0x3B: // trailer
+			return width, height, frames, true
+		default:
+			return 0, 0, 0, false
+		}
+	}
+
+	// some GIF has no trailer
+	return width, height, frames, true
+}
+
+// Function to decode animated GIF, it would return nil
+// when the image is not GIF or only has one frame.
+// Size of the GIF is checked before its frames is decoded
+func decodeAnimated(buff []byte) (*gif.GIF, error) {
+	width, height, frames, ok := scanGIF(buff)
+
+	if !ok || frames < 2 {
+		return nil, nil
+	}
+
+	if frames > maxFrames {
+		return nil, errTooManyFrames
+	}
+
+	if width*height > maxCanvas || width*height*frames > maxPixels {
+		return nil, errTooLarge
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(buff))
+
+	if err != nil || len(g.Image) < 2 {
+		return nil, nil
+	}
+
+	return g, nil
+}
+
+// Copy the canvas so it could be restored
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	c := image.NewRGBA(img.Rect)
+	copy(c.Pix, img.Pix)
+	return c
+}
+
+// Function to render each GIF frame to full canvas and pass it to fn,
+// GIF frame could only contain changed area so it need to be drawn on
+// top of previous frame based on its disposal method. The canvas is
+// reused so fn must not keep it
+func renderFrames(g *gif.GIF, fn func(i int, frame *image.RGBA) error) error {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+
+	// some GIF has no logical screen size
+	if bounds.Empty() {
+		for _, v := range g.Image {
+			bounds = bounds.Union(v.Bounds())
+		}
+	}
+
+	if bounds.Dx()*bounds.Dy() > maxCanvas {
+		return errTooLarge
+	}
+
+	canvas := image.NewRGBA(bounds)
+
+	for i, img := range g.Image {
+		var (
+			disposal byte
+			previous *image.RGBA
+		)
+
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, img.Bounds(), img, img.Bounds().Min, draw.Over)
+
+		if err := fn(i, canvas); err != nil {
+			return err
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return nil
+}
+
+// Function to resize animated GIF to animated WebP,
+// it return the animated WebP and the first frame
+// as still WebP that used as poster
+func resizeAnimated(g *gif.GIF) (animated, poster []byte, err error) {
+	if len(g.Image) > maxFrames {
+		return nil, nil, errTooManyFrames
+	}
+
+	var (
+		resized   = make([][]byte, len(g.Image))
+		durations = make([]int, len(g.Image))
+	)
+
+	// each frame is resized once it's rendered so only
+	// one full canvas is kept in memory
+	err = renderFrames(g, func(i int, frame *image.RGBA) error {
+		var buff bytes.Buffer
+
+		// bimg only accept encoded image
+		if err := png.Encode(&buff, frame); err != nil {
+			return err
+		}
+
+		v, err := bimg.Resize(buff.Bytes(), resizeOptions)
+
+		if err != nil {
+			return err
+		}
+
+		resized[i] = v
+
+		// GIF delay is in 100ths of second, very short delay
+		// is played as 100ms by browser so do the same
+		durations[i] = 100
+		if i < len(g.Delay) && g.Delay[i] > 1 {
+			durations[i] = g.Delay[i] * 10
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// GIF loop count 0 is forever and -1 is played once,
+	// WebP loop count is the number of play and 0 is forever
+	loop := 0
+	switch {
+	case g.LoopCount < 0:
+		loop = 1
+	case g.LoopCount > 0:
+		loop = g.LoopCount + 1
+	}
+
+	animated, err = muxAnimated(resized, durations, loop)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return animated, resized[0], nil
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// Palette of test GIF, index 0 is transparent
+var testPalette = color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}}
+
+// Create frame of GIF filled with the palette index
+func gifFrame(rect image.Rectangle, index uint8) *image.Paletted {
+	img := image.NewPaletted(rect, testPalette)
+
+	for i := range img.Pix {
+		img.Pix[i] = index
+	}
+
+	return img
+}
+
+// Encode GIF of frames on the canvas
+func encodeGIF(t *testing.T, width, height int, frames []*image.Paletted, disposal []byte) []byte {
+	t.Helper()
+
+	g := &gif.GIF{
+		Image:    frames,
+		Delay:    make([]int, len(frames)),
+		Disposal: disposal,
+		Config:   image.Config{Width: width, Height: height, ColorModel: testPalette},
+	}
+
+	var buff bytes.Buffer
+
+	if err := gif.EncodeAll(&buff, g); err != nil {
+		t.Fatal(err)
+	}
+
+	return buff.Bytes()
+}
+
+// Declare canvas size of GIF without changing its frames
+func withCanvas(data []byte, width, height int) []byte {
+	out := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint16(out[6:8], uint16(width))
+	binary.LittleEndian.PutUint16(out[8:10], uint16(height))
+
+	return out
+}
+
+func TestScanGIF(t *testing.T) {
+	rect := image.Rect(0, 0, 4, 3)
+	two := encodeGIF(t, 4, 3, []*image.Paletted{gifFrame(rect, 1), gifFrame(rect, 2)}, nil)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantWidth  int
+		wantHeight int
+		wantFrames int
+		wantOK     bool
+	}{
+		{"two frames", two, 4, 3, 2, true},
+		{"one frame", encodeGIF(t, 4, 3, []*image.Paletted{gifFrame(rect, 1)}, nil), 4, 3, 1, true},
+		{"without trailer", two[:len(two)-1], 4, 3, 2, true},
+		{"declared canvas", withCanvas(two, 65535, 65535), 65535, 65535, 2, true},
+		{"truncated frame", two[:len(two)-8], 0, 0, 0, false},
+		{"not gif", []byte("\x89PNG\r\n\x1a\n0000000000"), 0, 0, 0, false},
+		{"too short", []byte("GIF89a"), 0, 0, 0, false},
+		{"unknown block", append(append([]byte(nil), two[:len(two)-1]...), 0x00), 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, frames, ok := scanGIF(tt.data)
+
+			if width != tt.wantWidth || height != tt.wantHeight || frames != tt.wantFrames || ok != tt.wantOK {
+				t.Errorf("scanGIF() = %d, %d, %d, %v, want %d, %d, %d, %v",
+					width, height, frames, ok, tt.wantWidth, tt.wantHeight, tt.wantFrames, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecodeAnimated(t *testing.T) {
+	rect := image.Rect(0, 0, 4, 3)
+	two := encodeGIF(t, 4, 3, []*image.Paletted{gifFrame(rect, 1), gifFrame(rect, 2)}, nil)
+
+	many := make([]*image.Paletted, maxFrames+1)
+	for i := range many {
+		many[i] = gifFrame(image.Rect(0, 0, 1, 1), 1)
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantFrames int
+		wantErr    error
+	}{
+		{"animated", two, 2, nil},
+		{"still", encodeGIF(t, 4, 3, []*image.Paletted{gifFrame(rect, 1)}, nil), 0, nil},
+		{"not gif", []byte("not gif at all"), 0, nil},
+		{"huge canvas", withCanvas(two, 65535, 65535), 0, errTooLarge},
+		{"too many frames", encodeGIF(t, 1, 1, many, nil), 0, errTooManyFrames},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := decodeAnimated(tt.data)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decodeAnimated() error = %v, want %v", err, tt.wantErr)
+			}
+
+			frames := 0
+			if g != nil {
+				frames = len(g.Image)
+			}
+
+			if frames != tt.wantFrames {
+				t.Errorf("frames = %d, want %d", frames, tt.wantFrames)
+			}
+		})
+	}
+}
+
+func TestRenderFrames(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	full, corner := image.Rect(0, 0, 2, 2), image.Rect(0, 0, 1, 1)
+
+	tests := []struct {
+		name     string
+		disposal byte
+		want     []color.RGBA // pixel at 0,0 and 1,1 of the third frame
+	}{
+		{"none", gif.DisposalNone, []color.RGBA{blue, red}},
+		{"background", gif.DisposalBackground, []color.RGBA{{}, red}},
+		{"previous", gif.DisposalPrevious, []color.RGBA{red, red}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// red canvas, blue corner with the disposal, transparent frame
+			g := &gif.GIF{
+				Image:    []*image.Paletted{gifFrame(full, 1), gifFrame(corner, 2), gifFrame(full, 0)},
+				Disposal: []byte{gif.DisposalNone, tt.disposal, gif.DisposalNone},
+				Config:   image.Config{Width: 2, Height: 2},
+			}
+
+			var got []color.RGBA
+
+			err := renderFrames(g, func(i int, frame *image.RGBA) error {
+				if i == 1 && frame.RGBAAt(0, 0) != blue {
+					t.Errorf("second frame corner = %v, want %v", frame.RGBAAt(0, 0), blue)
+				}
+
+				if i == 2 {
+					got = []color.RGBA{frame.RGBAAt(0, 0), frame.RGBAAt(1, 1)}
+				}
+
+				return nil
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != 2 || got[0] != tt.want[0] || got[1] != tt.want[1] {
+				t.Errorf("third frame = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResizeAnimatedFrames(t *testing.T) {
+	g := &gif.GIF{Config: image.Config{Width: 1, Height: 1}}
+
+	for i := 0; i <= maxFrames; i++ {
+		g.Image = append(g.Image, gifFrame(image.Rect(0, 0, 1, 1), 1))
+		g.Delay = append(g.Delay, 10)
+	}
+
+	if _, _, err := resizeAnimated(g); !errors.Is(err, errTooManyFrames) {
+		t.Errorf("resizeAnimated() error = %v, want %v", err, errTooManyFrames)
+	}
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+)
+
+// How to work:
+// - API key in X-API-Key header is checked first, request with key act
+//   as user with "integration" role and is only allowed to do what
+//   the scope of key allow, it doesn't need CSRF token
+// - Bearer token in Authorization header is checked next, invalid
+//   token is rejected since the client explicitly sent it
+// - Otherwise session cookie is checked, unsafe method must send
+//   the CSRF token of the session as X-CSRF-Token header
+// - Session and user is read from tenant of request, so session
+//   of other tenant is treated as unknown session
+// - User and session is passed via context to controller,
+//   request without them is served as anonymous
+
+// Name of header that carry api key
+const APIKeyHeader = "X-API-Key"
+
+// Check if method would not change anything
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+// Get the bearer token of request
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(header[7:]), true
+}
+
+// Function that act as middleware to authenticate request
+// and put the current user into the context, it must be
+// used after Tenant middleware
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			session *auth.Session
+			err     error
+			t       = CurrentTenant(r)
+		)
+
+		if token := r.Header.Get(APIKeyHeader); token != "" {
+			key, err := auth.LookupKey(t, token)
+
+			if errors.Is(err, auth.ErrKey) {
+				problem.Unauthorized(w, r, "error "+err.Error())
+				return
+			}
+
+			if err != nil {
+				problem.Internal(w, r, "error reading api key")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), KeyUser, key.User())
+			ctx = context.WithValue(ctx, KeyAPIKey, key)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		if token, ok := bearerToken(r); ok {
+			if session, err = auth.Lookup(t, token, auth.KindToken); err != nil {
+				problem.Unauthorized(w, r, "error invalid or expired token")
+				return
+			}
+		} else if cookie, err := r.Cookie(auth.CookieName(t, auth.SessionCookie)); err == nil {
+			if session, err = auth.Lookup(t, cookie.Value, auth.KindSession); err != nil {
+				// stale cookie is removed and the
+				// request is served as anonymous
+				auth.ClearCookies(w, t)
+				session = nil
+			} else if !safeMethod(r.Method) && !session.CheckCSRF(r.Header.Get(auth.CSRFHeader)) {
+				problem.Forbidden(w, r, "error invalid CSRF token")
+				return
+			}
+		}
+
+		if session == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// user might be deleted after the session is created
+		user, err := auth.GetUser(t, session.UserID.String())
+
+		if err != nil {
+			problem.Unauthorized(w, r, "error user of session not found")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), KeyUser, user)
+		ctx = context.WithValue(ctx, KeySession, session)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Function that act as middleware to reject anonymous request
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if CurrentUser(r) == nil {
+			problem.Unauthorized(w, r, "error authentication required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Function that create middleware to check if current user is allowed
+// to do the action, anonymous request that is not allowed would need
+// to login first and logged in user would be forbidden
+func Authorize(action policy.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := CurrentUser(r)
+
+			if key := CurrentKey(r); key != nil {
+				if policy.KeyAllowed(key, action) {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				problem.Forbidden(w, r, fmt.Sprintf("error api key with %q scope is not allowed to %s", key.Scope, action))
+				return
+			}
+
+			if policy.Allowed(user, action) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if user == nil {
+				problem.Unauthorized(w, r, "error authentication required")
+				return
+			}
+
+			problem.Forbidden(w, r, fmt.Sprintf("error role %q is not allowed to %s", policy.RoleOf(user), action))
+		})
+	}
+}
+
+// Function to get the authenticated user, nil for anonymous
+func CurrentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(KeyUser).(*models.User)
+	return user
+}
+
+// Function to get the api key of request, nil for request without key
+func CurrentKey(r *http.Request) *models.APIKey {
+	key, _ := r.Context().Value(KeyAPIKey).(*models.APIKey)
+	return key
+}
+
+// Function to get the session of authenticated user
+func CurrentSession(r *http.Request) *auth.Session {
+	session, _ := r.Context().Value(KeySession).(*auth.Session)
+	return session
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestAuthenticateTenantCookie(t *testing.T) {
+	paws := &tenant.Tenant{ID: "paws", Root: t.TempDir()}
+	meow := &tenant.Tenant{ID: "meow", Root: t.TempDir()}
+
+	user := &models.User{Email: "staff@paws.org", Name: "Staff"}
+
+	if err := auth.CreateUser(paws, user, "password1"); err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := auth.NewSession(paws, user.ID, auth.KindSession)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		tenant      *tenant.Tenant
+		cookie      string
+		value       string
+		wantUser    bool
+		wantCleared string
+	}{
+		{"session of the tenant", paws, "session_paws", token, true, ""},
+		{"session of other tenant", meow, "session_paws", token, false, ""},
+		{"session of tenant with default name", paws, "session", token, false, ""},
+		{"stale session", meow, "session_meow", token, false, "session_meow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *models.User
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = CurrentUser(r)
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/cats", nil)
+			r.AddCookie(&http.Cookie{Name: tt.cookie, Value: tt.value})
+			r = r.WithContext(context.WithValue(r.Context(), KeyTenant, tt.tenant))
+
+			w := httptest.NewRecorder()
+			Authenticate(next).ServeHTTP(w, r)
+
+			if (got != nil) != tt.wantUser {
+				t.Errorf("user = %v, want user %v", got, tt.wantUser)
+			}
+
+			cleared := ""
+			for _, c := range (&http.Response{Header: w.Header()}).Cookies() {
+				if c.MaxAge < 0 && c.Name == auth.CookieName(tt.tenant, auth.SessionCookie) {
+					cleared = c.Name
+				}
+
+				// cookie of other tenant is never touched
+				if c.Name == "session_paws" && tt.tenant != paws {
+					t.Errorf("cookie %q of other tenant is changed", c.Name)
+				}
+			}
+
+			if cleared != tt.wantCleared {
+				t.Errorf("cleared = %q, want %q", cleared, tt.wantCleared)
+			}
+		})
+	}
+}
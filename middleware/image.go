@@ -2,59 +2,78 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
-	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/h2non/bimg"
-	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/storage"
 )
 
 // How to work:
 // - Get the file image form
-// - Resize the file image
-// - Save it as .webp
+// - Resize the file image, animated GIF is resized to animated WebP
+// - Save it as .webp named by its content hash
 // - Pass image filename via context to controller
 
+// Maximum size of upload request, body larger than it
+// is rejected before the image is decoded
+const maxUpload = 20 << 20
+
 type Key int
 
-// KeyName is variable with custom type to assign inside
-// context so filename could be accessed.
-// KeyName would be exported so controller could get the key
-// for filename context
-const KeyName Key = iota
+const (
+	// KeyName is variable with custom type to assign inside
+	// context so filename could be accessed.
+	// KeyName would be exported so controller could get the key
+	// for filename context
+	KeyName Key = iota
+
+	// KeyPoster is key for filename of still image of animated image,
+	// it's only assigned when the uploaded image is animated
+	KeyPoster
+
+	// KeyUser and KeySession is key for authenticated user and its
+	// session, assigned by Authenticate middleware
+	KeyUser
+	KeySession
+
+	// KeyTenant is key for tenant of request, assigned by Tenant middleware
+	KeyTenant
+
+	// KeyAPIKey is key for api key of request, assigned by Authenticate middleware
+	KeyAPIKey
+)
 
 // Function that act as middleware for file request,
 // this middleware would read the requested file, write it to
 // static folder as image in webp format
 func SetImage(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// get the requset url path
-		// dir would be specific directory where image
-		// would be saved
-		url := r.URL.Path
-		dir := strings.Split(url, "/")[2] + "/"
-
-		// generate xid for filename
-		// later it would be used to create the ID
-		filename := xid.New().String()
-
 		// get the requsted id
 		id := chi.URLParam(r, "id")
 
 		if len(id) == 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("error getting param id"))
+			problem.BadRequest(w, r, "error getting param id")
+			return
+		}
+
+		if r.ContentLength > maxUpload {
+			problem.New(http.StatusRequestEntityTooLarge, "error image is too large").Write(w, r)
 			return
 		}
 
+		// body without content length is limited while it's read
+		r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+
 		// get the requsted body
 		err := r.ParseForm()
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error parsing form"))
+			problem.BadRequest(w, r, "error parsing form")
 			return
 		}
 
@@ -62,43 +81,69 @@ func SetImage(next http.Handler) http.Handler {
 		file, _, err := r.FormFile("image")
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error getting form file"))
+			problem.BadRequest(w, r, "error getting form file")
 			return
 		}
 
 		buff, err := io.ReadAll(file)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error read form file"))
+			problem.BadRequest(w, r, "error read form file")
 			return
 		}
 
-		// resize image buffer
-		buff, err = bimg.Resize(buff, bimg.Options{
-			Width:       800,
-			Height:      0,
-			Quality:     80,
-			Compression: 80,
-		})
+		var (
+			poster []byte
+			wd     = CurrentTenant(r).Root
+			ctx    = r.Context()
+		)
+
+		// resize image buffer, animated GIF would be flattened
+		// by bimg so each frame is resized instead
+		g, err := decodeAnimated(buff)
+
+		if err == nil && g != nil {
+			buff, poster, err = resizeAnimated(g)
+		} else if err == nil {
+			buff, err = bimg.Resize(buff, resizeOptions)
+		}
+
+		if errors.Is(err, errTooLarge) || errors.Is(err, errTooManyFrames) {
+			problem.New(http.StatusRequestEntityTooLarge, "error "+err.Error()).Write(w, r)
+			return
+		}
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error resize buffer"))
+			problem.New(http.StatusUnprocessableEntity, "error resize buffer").Write(w, r)
 			return
 		}
 
-		// write the buffer to file and save it as webp
-		err = bimg.Write("static/"+dir+string(filename)+"."+bimg.ImageTypeName(bimg.WEBP), buff)
+		// write the poster of animated image
+		if poster != nil {
+			posterName, err := storage.Save(wd, poster)
+
+			if err != nil {
+				problem.Internal(w, r, "error write image")
+				return
+			}
+
+			ctx = context.WithValue(ctx, KeyPoster, posterName)
+		}
+
+		// write the buffer to file, the filename is the
+		// content hash so the same image is only stored once
+		filename, err := storage.Save(wd, buff)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("error write image"))
+			if poster != nil {
+				storage.Discard(wd, ctx.Value(KeyPoster).(string))
+			}
+
+			problem.Internal(w, r, "error write image")
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), KeyName, filename)
+		ctx = context.WithValue(ctx, KeyName, filename)
 
 		// next to controller
 		next.ServeHTTP(w, r.WithContext(ctx))
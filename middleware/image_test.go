@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestSetImageLimit(t *testing.T) {
+	many := make([]*image.Paletted, maxFrames+1)
+	for i := range many {
+		many[i] = gifFrame(image.Rect(0, 0, 1, 1), 1)
+	}
+
+	two := encodeGIF(t, 4, 3, []*image.Paletted{gifFrame(image.Rect(0, 0, 4, 3), 1), gifFrame(image.Rect(0, 0, 4, 3), 2)}, nil)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"too many frames", encodeGIF(t, 1, 1, many, nil), http.StatusRequestEntityTooLarge},
+		{"huge canvas", withCanvas(two, 65535, 65535), http.StatusRequestEntityTooLarge},
+	}
+
+	tn := &tenant.Tenant{ID: "test", Root: t.TempDir()}
+
+	router := chi.NewRouter()
+	router.With(SetImage).Post("/cats/{id}/image", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("image over the limit is passed to controller")
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("image", "cat.gif")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			part.Write(tt.data)
+			form.Close()
+
+			r := httptest.NewRequest(http.MethodPost, "/cats/1/image", &body)
+			r.Header.Set("Content-Type", form.FormDataContentType())
+			r = r.WithContext(context.WithValue(r.Context(), KeyTenant, tn))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("SetImage() = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
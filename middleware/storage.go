@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ArkjuniorK/store_app/storage"
+)
+
+// Function that act as middleware to hold data lock of storage
+// while request change data, so maintenance task would wait
+// until link of cat or shelter and its reference is written
+func HoldStorage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !safeMethod(r.Method) {
+			defer storage.Share()()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
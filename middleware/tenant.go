@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ArkjuniorK/store_app/problem"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// How to work:
+// - Tenant is resolved from X-Tenant header sent by api client,
+//   otherwise from host of request (custom domain or subdomain)
+// - Header could not choose other tenant than tenant of the host
+// - Tenant is passed via context so controller and other middleware
+//   read and write data inside the tenant root only
+
+// Header to choose tenant by api client
+const TenantHeader = "X-Tenant"
+
+// Function that act as middleware to resolve tenant of request,
+// unknown tenant is sent as not found
+func Tenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, err := tenant.FromHost(r.Host)
+
+		if v := r.Header.Get(TenantHeader); v != "" && err == nil {
+			if t.ID != tenant.DefaultID && t.ID != v {
+				problem.BadRequest(w, r, "error tenant header does not match host")
+				return
+			}
+
+			t, err = tenant.Get(v)
+		}
+
+		if errors.Is(err, tenant.ErrNotFound) {
+			problem.NotFound(w, r, "tenant not found")
+			return
+		}
+
+		if err != nil {
+			problem.Internal(w, r, "error resolve tenant")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), KeyTenant, t)))
+	})
+}
+
+// Function to get tenant of request, request
+// without resolved tenant use the default tenant
+func CurrentTenant(r *http.Request) *tenant.Tenant {
+	if t, ok := r.Context().Value(KeyTenant).(*tenant.Tenant); ok {
+		return t
+	}
+
+	return tenant.Default()
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestTenant(t *testing.T) {
+	wd := t.TempDir()
+
+	if err := ioutil.WriteFile(filepath.Join(wd, "tenants.json"), []byte(`[{"id":"paws","hosts":["paws.org"]},{"id":"meow"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tenant.Load(wd); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		host   string
+		header string
+		want   int
+		wantID string
+	}{
+		{"default host", "example.com", "", http.StatusOK, tenant.DefaultID},
+		{"custom host", "paws.org", "", http.StatusOK, "paws"},
+		{"header on default host", "example.com", "meow", http.StatusOK, "meow"},
+		{"header of the host", "paws.org", "paws", http.StatusOK, "paws"},
+		{"header of other tenant", "paws.org", "meow", http.StatusBadRequest, ""},
+		{"unknown header", "example.com", "other", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = CurrentTenant(r).ID
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/cats", nil)
+			r.Host = tt.host
+
+			if tt.header != "" {
+				r.Header.Set(TenantHeader, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			Tenant(next).ServeHTTP(w, r)
+
+			if w.Code != tt.want || got != tt.wantID {
+				t.Errorf("Tenant() = %d %q, want %d %q", w.Code, got, tt.want, tt.wantID)
+			}
+		})
+	}
+}
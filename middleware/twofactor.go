@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ArkjuniorK/store_app/policy"
+	"github.com/ArkjuniorK/store_app/problem"
+)
+
+// Function that act as middleware to reject request of staff that
+// has not enabled two-factor when the tenant require it, so the
+// only thing they could do is enroll at "/auth/2fa". Session of
+// OIDC provider is only allowed when ID token prove the provider
+// checked the second factor and api key is limited by its scope instead
+func RequireTwoFactor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := CurrentUser(r)
+
+		if user == nil || CurrentKey(r) != nil || user.TwoFactor {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if session := CurrentSession(r); session != nil && session.MultiFactor {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if policy.RequiresTwoFactor(user, CurrentTenant(r).RequireTwoFactor) {
+			problem.Forbidden(w, r, "error two-factor authentication must be enabled at /api/auth/2fa/enroll")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+func TestRequireTwoFactor(t *testing.T) {
+	staff := &models.User{Role: models.RoleShelterStaff}
+	enabled := &models.User{Role: models.RoleShelterStaff, TwoFactor: true}
+	adopter := &models.User{Role: models.RoleAdopter}
+
+	tests := []struct {
+		name     string
+		required bool
+		user     *models.User
+		session  *auth.Session
+		key      *models.APIKey
+		want     int
+	}{
+		{"not required", false, staff, &auth.Session{}, nil, http.StatusOK},
+		{"staff without two-factor", true, staff, &auth.Session{}, nil, http.StatusForbidden},
+		{"staff with two-factor", true, enabled, &auth.Session{}, nil, http.StatusOK},
+		{"adopter", true, adopter, &auth.Session{}, nil, http.StatusOK},
+		{"anonymous", true, nil, nil, nil, http.StatusOK},
+		{"api key", true, staff, nil, &models.APIKey{}, http.StatusOK},
+		{"provider with second factor", true, staff, &auth.Session{Issuer: "https://idp.example.com", MultiFactor: true}, nil, http.StatusOK},
+		{"provider without second factor", true, staff, &auth.Session{Issuer: "https://idp.example.com"}, nil, http.StatusForbidden},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), KeyTenant, &tenant.Tenant{RequireTwoFactor: tt.required})
+
+			if tt.user != nil {
+				ctx = context.WithValue(ctx, KeyUser, tt.user)
+			}
+
+			if tt.session != nil {
+				ctx = context.WithValue(ctx, KeySession, tt.session)
+			}
+
+			if tt.key != nil {
+				ctx = context.WithValue(ctx, KeyAPIKey, tt.key)
+			}
+
+			w := httptest.NewRecorder()
+			RequireTwoFactor(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cats", nil).WithContext(ctx))
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
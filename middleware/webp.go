@@ -0,0 +1,216 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// Animated WebP is RIFF container with VP8X chunk that has
+// animation flag, ANIM chunk for loop count and one ANMF chunk
+// for each frame. Since bimg (libvips) could only write still
+// WebP, each frame is encoded as still WebP first then the
+// bitstream chunks is moved to ANMF chunk.
+// See https://developers.google.com/speed/webp/docs/riff_container
+
+// VP8X flags
+const (
+	flagAnimation = 0x02
+	flagAlpha     = 0x10
+)
+
+// ANMF flags, frame would not be blended to previous
+// frame since each frame is full canvas
+const anmfNoBlend = 0x02
+
+// chunk type store one RIFF chunk
+type chunk struct {
+	fourCC  string
+	payload []byte
+}
+
+// frame type store the bitstream chunks of still WebP
+type frame struct {
+	chunks []chunk
+	width  int
+	height int
+	alpha  bool
+}
+
+// Read little endian 24 bit integer
+func uint24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+// Write little endian 24 bit integer
+func putUint24(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
+
+// Write chunk to buffer, payload is padded to even size
+func writeChunk(buff *bytes.Buffer, fourCC string, payload []byte) {
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(len(payload)))
+
+	buff.WriteString(fourCC)
+	buff.Write(size)
+	buff.Write(payload)
+
+	if len(payload)%2 == 1 {
+		buff.WriteByte(0)
+	}
+}
+
+// Parse still WebP and take its bitstream chunks
+func parseFrame(data []byte) (*frame, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errors.New("error frame is not webp")
+	}
+
+	f := new(frame)
+	data = data[12:]
+
+	for len(data) >= 8 {
+		fourCC := string(data[0:4])
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+
+		if size > len(data)-8 {
+			return nil, errors.New("error frame is truncated")
+		}
+
+		payload := data[8 : 8+size]
+
+		switch fourCC {
+		case "VP8X":
+			if size < 10 {
+				return nil, errors.New("error invalid VP8X chunk")
+			}
+
+			f.width = uint24(payload[4:7]) + 1
+			f.height = uint24(payload[7:10]) + 1
+		case "ALPH":
+			f.alpha = true
+			f.chunks = append(f.chunks, chunk{fourCC, payload})
+		case "VP8 ":
+			// frame header is started after 3 bytes frame tag
+			// and 3 bytes start code
+			if size < 10 {
+				return nil, errors.New("error invalid VP8 chunk")
+			}
+
+			if f.width == 0 {
+				f.width = int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3fff)
+				f.height = int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3fff)
+			}
+
+			f.chunks = append(f.chunks, chunk{fourCC, payload})
+		case "VP8L":
+			// header is 1 byte signature then 14 bit width,
+			// 14 bit height and 1 bit alpha
+			if size < 5 {
+				return nil, errors.New("error invalid VP8L chunk")
+			}
+
+			bits := binary.LittleEndian.Uint32(payload[1:5])
+
+			if f.width == 0 {
+				f.width = int(bits&0x3fff) + 1
+				f.height = int((bits>>14)&0x3fff) + 1
+			}
+
+			f.alpha = f.alpha || bits>>28&1 == 1
+			f.chunks = append(f.chunks, chunk{fourCC, payload})
+		}
+
+		// skip the chunk and its padding
+		next := 8 + size + size%2
+		if next > len(data) {
+			break
+		}
+
+		data = data[next:]
+	}
+
+	if len(f.chunks) == 0 || f.width == 0 || f.height == 0 {
+		return nil, errors.New("error frame has no image")
+	}
+
+	return f, nil
+}
+
+// Function to combine still WebP frames into animated WebP,
+// durations is in millisecond and loop 0 means forever
+func muxAnimated(frames [][]byte, durations []int, loop int) ([]byte, error) {
+	var (
+		body   bytes.Buffer
+		width  int
+		height int
+		alpha  bool
+	)
+
+	parsed := make([]*frame, len(frames))
+
+	for i, v := range frames {
+		f, err := parseFrame(v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if f.width > width {
+			width = f.width
+		}
+
+		if f.height > height {
+			height = f.height
+		}
+
+		alpha = alpha || f.alpha
+		parsed[i] = f
+	}
+
+	// VP8X chunk
+	vp8x := make([]byte, 10)
+	vp8x[0] = flagAnimation
+	if alpha {
+		vp8x[0] |= flagAlpha
+	}
+	putUint24(vp8x[4:7], width-1)
+	putUint24(vp8x[7:10], height-1)
+	writeChunk(&body, "VP8X", vp8x)
+
+	// ANIM chunk, background is transparent
+	anim := make([]byte, 6)
+	binary.LittleEndian.PutUint16(anim[4:6], uint16(loop))
+	writeChunk(&body, "ANIM", anim)
+
+	// ANMF chunk for each frame
+	for i, f := range parsed {
+		var anmf bytes.Buffer
+
+		header := make([]byte, 16)
+		putUint24(header[6:9], f.width-1)
+		putUint24(header[9:12], f.height-1)
+		putUint24(header[12:15], durations[i])
+		header[15] = anmfNoBlend
+		anmf.Write(header)
+
+		for _, c := range f.chunks {
+			writeChunk(&anmf, c.fourCC, c.payload)
+		}
+
+		writeChunk(&body, "ANMF", anmf.Bytes())
+	}
+
+	// RIFF header, size is counted from "WEBP"
+	var out bytes.Buffer
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(4+body.Len()))
+
+	out.WriteString("RIFF")
+	out.Write(size)
+	out.WriteString("WEBP")
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// Create still WebP of VP8L bitstream with the size
+func losslessFrame(width, height int, alpha bool) []byte {
+	payload := make([]byte, 8)
+	payload[0] = 0x2f
+
+	bits := uint32(width-1) | uint32(height-1)<<14
+	if alpha {
+		bits |= 1 << 28
+	}
+	binary.LittleEndian.PutUint32(payload[1:5], bits)
+
+	return riff(func(b *bytes.Buffer) { writeChunk(b, "VP8L", payload) })
+}
+
+// Create still WebP of VP8 bitstream with extended header and alpha
+func lossyFrame(width, height int) []byte {
+	vp8x := make([]byte, 10)
+	vp8x[0] = flagAlpha
+	putUint24(vp8x[4:7], width-1)
+	putUint24(vp8x[7:10], height-1)
+
+	// odd size so the chunk is padded
+	vp8 := make([]byte, 11)
+	binary.LittleEndian.PutUint16(vp8[6:8], uint16(width))
+	binary.LittleEndian.PutUint16(vp8[8:10], uint16(height))
+
+	return riff(func(b *bytes.Buffer) {
+		writeChunk(b, "VP8X", vp8x)
+		writeChunk(b, "ALPH", []byte{1, 2, 3})
+		writeChunk(b, "VP8 ", vp8)
+	})
+}
+
+// Wrap chunks in RIFF WebP header
+func riff(chunks func(b *bytes.Buffer)) []byte {
+	var body bytes.Buffer
+	chunks(&body)
+
+	out := []byte("RIFF\x00\x00\x00\x00WEBP")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(4+body.Len()))
+
+	return append(out, body.Bytes()...)
+}
+
+// Split RIFF payload into chunks
+func readChunks(t *testing.T, data []byte) []chunk {
+	t.Helper()
+
+	var chunks []chunk
+
+	for len(data) >= 8 {
+		size := int(binary.LittleEndian.Uint32(data[4:8]))
+
+		if 8+size > len(data) {
+			t.Fatalf("chunk %q is truncated", data[0:4])
+		}
+
+		chunks = append(chunks, chunk{string(data[0:4]), data[8 : 8+size]})
+		data = data[8+size+size%2:]
+	}
+
+	if len(data) != 0 {
+		t.Fatalf("%d bytes left after the last chunk", len(data))
+	}
+
+	return chunks
+}
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		wantWidth  int
+		wantHeight int
+		wantAlpha  bool
+		wantChunks []string
+		wantErr    bool
+	}{
+		{"lossless", losslessFrame(320, 200, false), 320, 200, false, []string{"VP8L"}, false},
+		{"lossless with alpha", losslessFrame(16, 9, true), 16, 9, true, []string{"VP8L"}, false},
+		{"lossy extended", lossyFrame(640, 480), 640, 480, true, []string{"ALPH", "VP8 "}, false},
+		{"not webp", []byte("RIFF\x04\x00\x00\x00WAVE"), 0, 0, false, nil, true},
+		{"too short", []byte("RIFF"), 0, 0, false, nil, true},
+		{"truncated chunk", losslessFrame(16, 9, false)[:20], 0, 0, false, nil, true},
+		{"no bitstream", riff(func(b *bytes.Buffer) { writeChunk(b, "EXIF", []byte{0}) }), 0, 0, false, nil, true},
+		{"short VP8X", riff(func(b *bytes.Buffer) { writeChunk(b, "VP8X", []byte{0}) }), 0, 0, false, nil, true},
+		{"short VP8L", riff(func(b *bytes.Buffer) { writeChunk(b, "VP8L", []byte{0x2f}) }), 0, 0, false, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseFrame(tt.data)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFrame() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if f.width != tt.wantWidth || f.height != tt.wantHeight || f.alpha != tt.wantAlpha {
+				t.Errorf("frame = %dx%d alpha %v, want %dx%d alpha %v", f.width, f.height, f.alpha, tt.wantWidth, tt.wantHeight, tt.wantAlpha)
+			}
+
+			var names []string
+			for _, c := range f.chunks {
+				names = append(names, c.fourCC)
+			}
+
+			if !equalNames(names, tt.wantChunks) {
+				t.Errorf("chunks = %q, want %q", names, tt.wantChunks)
+			}
+		})
+	}
+}
+
+// Compare chunk names
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestMuxAnimated(t *testing.T) {
+	frames := [][]byte{losslessFrame(100, 50, false), lossyFrame(80, 60), losslessFrame(100, 60, false)}
+	durations := []int{100, 250, 70}
+
+	out, err := muxAnimated(frames, durations, 3)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(out[0:4]) != "RIFF" || string(out[8:12]) != "WEBP" {
+		t.Fatalf("header = %q, want RIFF WEBP", out[0:12])
+	}
+
+	if size := int(binary.LittleEndian.Uint32(out[4:8])); size != len(out)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(out)-8)
+	}
+
+	chunks := readChunks(t, out[12:])
+
+	if len(chunks) != 2+len(frames) || chunks[0].fourCC != "VP8X" || chunks[1].fourCC != "ANIM" {
+		t.Fatalf("chunks = %d starting with %q, want VP8X, ANIM and %d ANMF", len(chunks), chunks[0].fourCC, len(frames))
+	}
+
+	// canvas is the largest frame and alpha of any frame
+	vp8x := chunks[0].payload
+	if vp8x[0] != flagAnimation|flagAlpha {
+		t.Errorf("VP8X flags = %#x, want %#x", vp8x[0], flagAnimation|flagAlpha)
+	}
+
+	if w, h := uint24(vp8x[4:7])+1, uint24(vp8x[7:10])+1; w != 100 || h != 60 {
+		t.Errorf("canvas = %dx%d, want 100x60", w, h)
+	}
+
+	if loop := binary.LittleEndian.Uint16(chunks[1].payload[4:6]); loop != 3 {
+		t.Errorf("loop = %d, want 3", loop)
+	}
+
+	for i, c := range chunks[2:] {
+		if c.fourCC != "ANMF" {
+			t.Fatalf("chunk %d = %q, want ANMF", i+2, c.fourCC)
+		}
+
+		f, err := parseFrame(frames[i])
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		header := c.payload[:16]
+
+		if w, h := uint24(header[6:9])+1, uint24(header[9:12])+1; w != f.width || h != f.height {
+			t.Errorf("frame %d = %dx%d, want %dx%d", i, w, h, f.width, f.height)
+		}
+
+		if d := uint24(header[12:15]); d != durations[i] {
+			t.Errorf("frame %d duration = %d, want %d", i, d, durations[i])
+		}
+
+		if header[15] != anmfNoBlend {
+			t.Errorf("frame %d flags = %#x, want %#x", i, header[15], anmfNoBlend)
+		}
+
+		// bitstream chunks is moved to the frame unchanged
+		inner := readChunks(t, c.payload[16:])
+
+		if len(inner) != len(f.chunks) {
+			t.Fatalf("frame %d has %d chunks, want %d", i, len(inner), len(f.chunks))
+		}
+
+		for j := range inner {
+			if inner[j].fourCC != f.chunks[j].fourCC || !bytes.Equal(inner[j].payload, f.chunks[j].payload) {
+				t.Errorf("frame %d chunk %d = %q, want %q", i, j, inner[j].fourCC, f.chunks[j].fourCC)
+			}
+		}
+	}
+}
+
+func TestMuxAnimatedInvalidFrame(t *testing.T) {
+	if _, err := muxAnimated([][]byte{losslessFrame(10, 10, false), []byte("not webp")}, []int{100, 100}, 0); err == nil {
+		t.Error("muxAnimated() error = nil, want error of invalid frame")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/maintenance"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Command to migrate cat data to current format,
+// usage: store_app migrate [-tenant id] [-shelter id] [-dry-run]
+func migrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dry := fs.Bool("dry-run", false, "report cat data to be migrated without writing it")
+	id := fs.String("tenant", tenant.DefaultID, "tenant to be migrated")
+	shelterID := fs.String("shelter", "", "shelter to be assigned to cat without shelter")
+	fs.Parse(args)
+
+	t, err := tenant.Get(*id)
+
+	if err != nil {
+		log.Fatalf("migrate: %v", err)
+	}
+
+	shelter := xid.NilID()
+
+	if *shelterID != "" {
+		if shelter, err = xid.FromString(*shelterID); err != nil {
+			log.Fatalf("migrate: invalid shelter id %q", *shelterID)
+		}
+
+		// cat must not be assigned to shelter of other tenant
+		if _, err = os.Stat(t.Path("data/shelters", shelter.String()+".json")); err != nil {
+			log.Fatalf("migrate: shelter %s not found in tenant %s", shelter, t.ID)
+		}
+	}
+
+	migration, err := maintenance.Migrate(t.Root, *dry, shelter)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// print the result as json the same as fsck
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(migration)
+
+	if len(migration.Failed) != 0 {
+		os.Exit(1)
+	}
+}
+
+// Migrate cat data of each tenant on server start, failed cat
+// is only logged so the rest of cats could still be served
+func startMigrate() {
+	for _, t := range tenant.All() {
+		migration, err := maintenance.Migrate(t.Root, false, xid.NilID())
+
+		if err != nil {
+			log.Printf("migrate %s: %v", t.ID, err)
+			continue
+		}
+
+		if n := len(migration.Migrated); n != 0 {
+			log.Printf("migrate %s: %d cat migrated, %d need to be reviewed", t.ID, n, len(migration.Incomplete))
+		}
+
+		if len(migration.Failed) != 0 {
+			log.Printf("migrate %s: error migrating cat %v", t.ID, migration.Failed)
+		}
+
+		if n := len(migration.Unassigned); n != 0 {
+			log.Printf("migrate %s: %d cat has no shelter, run migrate -shelter id to assign it", t.ID, n)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/ArkjuniorK/store_app/oidc"
+)
+
+// Command to serve local OpenID Connect provider, used to try
+// OIDC login on development without real provider. Every login is
+// approved as the given user, groups is sent as "groups" claim and
+// amr is sent as "amr" claim to try login with second factor,
+// usage: store_app mockoidc -addr :9000 -client-id store_app -email staff@example.com -groups staff -amr pwd,mfa
+func mockOIDC(args []string) {
+	fs := flag.NewFlagSet("mockoidc", flag.ExitOnError)
+	addr := fs.String("addr", ":9000", "address to listen on")
+	issuer := fs.String("issuer", "", "issuer url, default to http://localhost{addr}")
+	clientID := fs.String("client-id", "store_app", "client id of the app")
+	secret := fs.String("client-secret", "", "client secret of the app, empty for public client")
+	subject := fs.String("sub", "mock-user", "subject of the user")
+	email := fs.String("email", "user@example.com", "email of the user")
+	name := fs.String("name", "Mock User", "name of the user")
+	verified := fs.Bool("email-verified", true, "whether the email is verified")
+	groups := fs.String("groups", "", "comma separated groups of the user")
+	amr := fs.String("amr", "pwd", "comma separated authentication methods of the login")
+	fs.Parse(args)
+
+	if *issuer == "" {
+		*issuer = "http://localhost" + *addr
+	}
+
+	claims := oidc.Claims{
+		"sub":            *subject,
+		"email":          *email,
+		"email_verified": *verified,
+		"name":           *name,
+		"groups":         []string{},
+	}
+
+	if *groups != "" {
+		claims["groups"] = strings.Split(*groups, ",")
+	}
+
+	if *amr != "" {
+		claims["amr"] = strings.Split(*amr, ",")
+	}
+
+	mock, err := oidc.NewMock(*issuer, *clientID, *secret, claims)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("mock oidc provider %s listening on %s", *issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, mock.Handler()))
+}
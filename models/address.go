@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/ArkjuniorK/store_app/geo"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Address type store structured address of cat, postal code
+// is stored as string in canonical form of its country
+type Address struct {
+	Street     string `json:"street" validate:"required,max=200"`
+	City       string `json:"city" validate:"required,max=100"`
+	Region     string `json:"region" validate:"max=100"`
+	PostalCode string `json:"postal_code" validate:"required,max=10"`
+	Country    string `json:"country" validate:"required"`
+}
+
+// String would return the address in one line,
+// ex: "1 Main St, Boston, MA 02108, US"
+func (a Address) String() string {
+	var parts []string
+
+	for _, v := range []string{a.Street, a.City, strings.TrimSpace(a.Region + " " + a.PostalCode), a.Country} {
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Normalize would trim the address and format country and postal
+// code into canonical form, invalid postal code is kept as it is
+// so it could be reported by Validate
+func (a *Address) Normalize() {
+	a.Street = strings.TrimSpace(a.Street)
+	a.City = strings.TrimSpace(a.City)
+	a.Region = strings.TrimSpace(a.Region)
+
+	country, code, err := geo.NormalizePostalCode(a.Country, a.PostalCode)
+
+	if err != geo.ErrCountry {
+		a.Country = country
+	}
+
+	if err == nil {
+		a.PostalCode = code
+	}
+}
+
+// Validate postal code against its country
+func (a Address) Validate() validation.Errors {
+	if a.Country == "" || a.PostalCode == "" {
+		return nil
+	}
+
+	switch _, _, err := geo.NormalizePostalCode(a.Country, a.PostalCode); err {
+	case geo.ErrCountry:
+		return validation.Errors{validation.Error("country", "%s", err)}
+	case geo.ErrPostalCode:
+		return validation.Errors{validation.Error("postal_code", "%s", err)}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import "testing"
+
+func TestAddressNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		address Address
+		want    Address
+	}{
+		{
+			"canonical postal code",
+			Address{Street: " 10 Downing St ", City: "London ", PostalCode: "sw1a2aa", Country: "gb"},
+			Address{Street: "10 Downing St", City: "London", PostalCode: "SW1A 2AA", Country: "GB"},
+		},
+		{
+			"invalid postal code is kept",
+			Address{Street: "1 Main St", City: "Boston", PostalCode: "2108", Country: "us"},
+			Address{Street: "1 Main St", City: "Boston", PostalCode: "2108", Country: "US"},
+		},
+		{
+			"invalid country is kept",
+			Address{Street: "1 Main St", City: "Boston", PostalCode: "02108", Country: "usa"},
+			Address{Street: "1 Main St", City: "Boston", PostalCode: "02108", Country: "usa"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.address
+			a.Normalize()
+
+			if a != tt.want {
+				t.Errorf("Normalize() = %+v, want %+v", a, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddressValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		address Address
+		want    string // invalid field
+	}{
+		{"valid", Address{PostalCode: "02108", Country: "US"}, ""},
+		{"invalid postal code", Address{PostalCode: "2108", Country: "US"}, "postal_code"},
+		{"invalid country", Address{PostalCode: "02108", Country: "USA"}, "country"},
+		{"missing postal code", Address{Country: "US"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.address.Validate()
+
+			got := ""
+			if len(errs) != 0 {
+				got = errs[0].Field
+			}
+
+			if got != tt.want || len(errs) > 1 {
+				t.Errorf("Validate() = %v, want violation of %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddressString(t *testing.T) {
+	tests := []struct {
+		address Address
+		want    string
+	}{
+		{Address{Street: "1 Main St", City: "Boston", Region: "MA", PostalCode: "02108", Country: "US"}, "1 Main St, Boston, MA 02108, US"},
+		{Address{Street: "10 Downing St", City: "London", PostalCode: "SW1A 2AA", Country: "GB"}, "10 Downing St, London, SW1A 2AA, GB"},
+		{Address{City: "Paris"}, "Paris"},
+		{Address{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.address.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
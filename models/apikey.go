@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Scope of api key
+const (
+	KeyScopeRead     = "read"     // read cats and shelters only
+	KeyScopeShelters = "shelters" // also manage cats of the shelters
+)
+
+func init() {
+	validation.RegisterEnum("key_scope", KeyScopeRead, KeyScopeShelters)
+}
+
+// APIKey type store key of partner site or shelter system,
+// the secret is stored hashed by auth package and only
+// sent once when the key is created
+type APIKey struct {
+	ID       xid.ID     `json:"id" validate:"readonly"`
+	Name     string     `json:"name" validate:"required,max=100"`
+	Scope    string     `json:"scope" validate:"required,enum=key_scope"`
+	Shelters []xid.ID   `json:"shelter_ids"`
+	Expires  *time.Time `json:"expires_at"`
+
+	CreatedBy xid.ID     `json:"created_by" validate:"readonly"`
+	Create    time.Time  `json:"created_at" validate:"readonly"`
+	LastUsed  *time.Time `json:"last_used_at" validate:"readonly"`
+}
+
+// Validate shelters of the scope and expiry, key
+// without expiry is valid until it's revoked
+func (k APIKey) Validate() validation.Errors {
+	var errs validation.Errors
+
+	switch {
+	case k.Scope == KeyScopeShelters && len(k.Shelters) == 0:
+		errs = append(errs, validation.Error("shelter_ids", "is required for %q scope", KeyScopeShelters))
+	case k.Scope == KeyScopeRead && len(k.Shelters) != 0:
+		errs = append(errs, validation.Error("shelter_ids", "must be empty for %q scope", KeyScopeRead))
+	}
+
+	if k.Expires != nil && !k.Expires.After(time.Now()) {
+		errs = append(errs, validation.Error("expires_at", "must be in the future"))
+	}
+
+	return errs
+}
+
+// Expired report whether the key could not be used anymore
+func (k *APIKey) Expired() bool {
+	return k.Expires != nil && time.Now().After(*k.Expires)
+}
+
+// User would return the user that act on behalf of the key,
+// it's only allowed to do what the scope of key allow
+func (k *APIKey) User() *User {
+	return &User{
+		ID:       k.ID,
+		Name:     k.Name,
+		Role:     RoleIntegration,
+		Create:   k.Create,
+		Shelters: k.Shelters,
+	}
+}
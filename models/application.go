@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Status of adoption application
+const (
+	Submitted = "submitted"
+	InReview  = "in_review"
+	Approved  = "approved"
+	Rejected  = "rejected"
+	Withdrawn = "withdrawn" // withdrawn by applicant
+)
+
+// Allowed status of application
+var ApplicationStatuses = []string{Submitted, InReview, Approved, Rejected, Withdrawn}
+
+// Allowed transitions of application, keyed by the current status.
+// Approved application could still be withdrawn until it's finalized
+var ApplicationTransitions = map[string][]string{
+	Submitted: {InReview, Rejected, Withdrawn},
+	InReview:  {Approved, Rejected, Withdrawn},
+	Approved:  {Withdrawn},
+	Rejected:  {},
+	Withdrawn: {},
+}
+
+func init() {
+	validation.RegisterEnum("application_status", ApplicationStatuses...)
+}
+
+// Applicant type store contact of the adopter
+type Applicant struct {
+	Name  string `json:"name" validate:"required,max=100"`
+	Email string `json:"email" validate:"required,email,max=254"`
+	Phone string `json:"phone" validate:"max=30"`
+}
+
+// Questionnaire type store answers of the adopter
+// that would be reviewed by staff
+type Questionnaire struct {
+	Housing    string `json:"housing" validate:"required,oneof=house apartment other"`
+	OwnsHome   bool   `json:"owns_home"`
+	PetsPolicy bool   `json:"landlord_allows_pets"`
+	Household  int    `json:"household_size" validate:"required,min=1,max=20"`
+	Children   bool   `json:"has_children"`
+	OtherPets  string `json:"other_pets" validate:"max=500"`
+	Experience string `json:"experience" validate:"max=1000"`
+	Reason     string `json:"reason" validate:"required,max=1000"`
+}
+
+// Note type store note of staff on application
+type Note struct {
+	ID   xid.ID    `json:"id" validate:"readonly"`
+	Text string    `json:"text" validate:"required,max=2000"`
+	At   time.Time `json:"at" validate:"readonly"`
+}
+
+// Application type store adoption application of one cat
+type Application struct {
+	ID            xid.ID        `json:"id" validate:"readonly"`
+	CatID         xid.ID        `json:"cat_id" validate:"required"`
+	UserID        xid.ID        `json:"user_id" validate:"readonly"`
+	Applicant     Applicant     `json:"applicant" validate:"required"`
+	Questionnaire Questionnaire `json:"questionnaire" validate:"required"`
+	Create        time.Time     `json:"created_at" validate:"readonly"`
+	Update        time.Time     `json:"updated_at" validate:"readonly"`
+
+	// Status is only changed by transition, application
+	// is finalized when the cat is handed to the adopter
+	Status    string        `json:"status" validate:"readonly"`
+	History   []*Transition `json:"status_history" validate:"readonly"`
+	Notes     []*Note       `json:"notes" validate:"readonly"`
+	Finalized *time.Time    `json:"finalized_at" validate:"readonly"`
+}
+
+// Applications type store multiple Application entities
+type Applications []*Application
+
+// Transition would change status of application and add it to the
+// history, TransitionError is returned when it's not allowed
+func (a *Application) Transition(to, reason string, at time.Time) error {
+	allowed := ApplicationTransitions[a.Status]
+
+	for _, v := range allowed {
+		if v == to {
+			a.History = append(a.History, &Transition{From: a.Status, To: to, Reason: reason, At: at})
+			a.Status = to
+
+			return nil
+		}
+	}
+
+	return &TransitionError{From: a.Status, To: to, Allowed: allowed}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplicationTransition(t *testing.T) {
+	tests := []struct {
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{Submitted, InReview, false},
+		{Submitted, Withdrawn, false},
+		{InReview, Approved, false},
+		{InReview, Rejected, false},
+		{Approved, Withdrawn, false},
+		{Submitted, Approved, true},
+		{Approved, Rejected, true},
+		{Rejected, InReview, true},
+		{Withdrawn, Submitted, true},
+	}
+
+	at := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			app := &Application{Status: tt.from}
+			err := app.Transition(tt.to, "test", at)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrTransition) {
+					t.Errorf("Transition() error = %v, want %v", err, ErrTransition)
+				}
+
+				if app.Status != tt.from || len(app.History) != 0 {
+					t.Errorf("application = %q with %d history, want unchanged", app.Status, len(app.History))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Transition() error = %v", err)
+			}
+
+			want := Transition{From: tt.from, To: tt.to, Reason: "test", At: at}
+
+			if app.Status != tt.to || len(app.History) != 1 || *app.History[0] != want {
+				t.Errorf("application = %q with history %v, want %q with %+v", app.Status, app.History, tt.to, want)
+			}
+		})
+	}
+}
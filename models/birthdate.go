@@ -0,0 +1,133 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Maximum age of cat in year
+const MaxAge = 30
+
+// Accepted layouts of birthdate, date without day or
+// month is treated as approximate birthdate
+var birthdateLayouts = []string{"2006-01-02", "2006-01", "2006"}
+
+// Life stage of cat, ordered from the youngest
+const (
+	Kitten = "kitten" // less than 1 year
+	Young  = "young"  // 1 to 2 years
+	Adult  = "adult"  // 3 to 10 years
+	Senior = "senior" // 11 years and older
+)
+
+// Allowed life stages of cat
+var LifeStages = []string{Kitten, Young, Adult, Senior}
+
+func init() {
+	validation.RegisterEnum("life_stage", LifeStages...)
+}
+
+// Birthdate type store birthdate of cat, Date could be
+// written as "2020-05-17", "2020-05" or "2020" when
+// only the month or year of birth is known
+type Birthdate struct {
+	Date        string `json:"date" validate:"required"`
+	Approximate bool   `json:"approximate"`
+}
+
+// Age type store age of cat computed from its birthdate,
+// Months is the whole age in months not the remainder
+type Age struct {
+	Years  int `json:"years"`
+	Months int `json:"months"`
+}
+
+// Parse the birthdate, it return the earliest possible
+// time of birth and the layout that is matched
+func (b Birthdate) parse() (time.Time, string, bool) {
+	for _, layout := range birthdateLayouts {
+		if t, err := time.Parse(layout, b.Date); err == nil {
+			return t, layout, true
+		}
+	}
+
+	return time.Time{}, "", false
+}
+
+// Time would return the birthdate as time, date without day is
+// the middle of the month and date without month is the middle of
+// the year so the computed age is off by half of the precision
+func (b Birthdate) Time() (time.Time, bool) {
+	t, layout, ok := b.parse()
+
+	switch layout {
+	case "2006-01":
+		t = t.AddDate(0, 0, 14)
+	case "2006":
+		t = t.AddDate(0, 6, 0)
+	}
+
+	return t, ok
+}
+
+// Normalize would mark birthdate without day as approximate
+func (b *Birthdate) Normalize() {
+	b.Date = strings.TrimSpace(b.Date)
+
+	if _, layout, ok := b.parse(); ok && layout != birthdateLayouts[0] {
+		b.Approximate = true
+	}
+}
+
+// Validate birthdate is a date in the past within MaxAge
+func (b Birthdate) Validate() validation.Errors {
+	if b.Date == "" {
+		return nil
+	}
+
+	t, _, ok := b.parse()
+
+	switch {
+	case !ok:
+		return validation.Errors{validation.Error("date", "must be formatted as YYYY-MM-DD, YYYY-MM or YYYY")}
+	case t.After(time.Now()):
+		return validation.Errors{validation.Error("date", "must not be in the future")}
+	case t.Before(time.Now().AddDate(-MaxAge-1, 0, 0)):
+		return validation.Errors{validation.Error("date", "must be within %d years", MaxAge)}
+	}
+
+	return nil
+}
+
+// Function to compute age at now from the birthdate
+func (b Birthdate) AgeAt(now time.Time) Age {
+	t, ok := b.Time()
+
+	if !ok || now.Before(t) {
+		return Age{}
+	}
+
+	months := (now.Year()-t.Year())*12 + int(now.Month()-t.Month())
+
+	if now.Day() < t.Day() {
+		months--
+	}
+
+	return Age{Years: months / 12, Months: months}
+}
+
+// Stage would return life stage of the age
+func (a Age) Stage() string {
+	switch {
+	case a.Years < 1:
+		return Kitten
+	case a.Years <= 2:
+		return Young
+	case a.Years <= 10:
+		return Adult
+	}
+
+	return Senior
+}
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// Parse date for test
+func date(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	d, err := time.Parse("2006-01-02", value)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func TestAgeAt(t *testing.T) {
+	now := date(t, "2024-06-15")
+
+	tests := []struct {
+		date string
+		want Age
+	}{
+		{"2024-06-15", Age{0, 0}},
+		{"2024-05-16", Age{0, 0}},
+		{"2024-05-15", Age{0, 1}},
+		{"2023-06-15", Age{1, 12}},
+		{"2023-06-16", Age{0, 11}},
+		{"2020-02-29", Age{4, 51}},
+		{"2024-05", Age{0, 1}}, // middle of may
+		{"2024-06", Age{0, 0}}, // middle of june is today
+		{"2024-07", Age{0, 0}}, // in the future
+		{"2020", Age{3, 47}},   // middle of 2020
+		{"invalid", Age{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			if got := (Birthdate{Date: tt.date}).AgeAt(now); got != tt.want {
+				t.Errorf("AgeAt() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStage(t *testing.T) {
+	tests := []struct {
+		years int
+		want  string
+	}{
+		{0, Kitten},
+		{1, Young},
+		{2, Young},
+		{3, Adult},
+		{10, Adult},
+		{11, Senior},
+		{25, Senior},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := (Age{Years: tt.years}).Stage(); got != tt.want {
+				t.Errorf("Stage() of %d years = %q, want %q", tt.years, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBirthdateNormalize(t *testing.T) {
+	tests := []struct {
+		date            string
+		wantDate        string
+		wantApproximate bool
+	}{
+		{"2020-05-17", "2020-05-17", false},
+		{" 2020-05 ", "2020-05", true},
+		{"2020", "2020", true},
+		{"May 2020", "May 2020", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			b := Birthdate{Date: tt.date}
+			b.Normalize()
+
+			if b.Date != tt.wantDate || b.Approximate != tt.wantApproximate {
+				t.Errorf("Normalize() = %+v, want %q approximate %v", b, tt.wantDate, tt.wantApproximate)
+			}
+		})
+	}
+}
+
+func TestBirthdateValidate(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		date    string
+		wantErr bool
+	}{
+		{"day", now.AddDate(-2, 0, 0).Format("2006-01-02"), false},
+		{"month", now.AddDate(-2, 0, 0).Format("2006-01"), false},
+		{"year", now.AddDate(-2, 0, 0).Format("2006"), false},
+		{"future", now.AddDate(0, 0, 2).Format("2006-01-02"), true},
+		{"max age", now.AddDate(-MaxAge, 0, 0).Format("2006-01-02"), false},
+		{"too old", now.AddDate(-MaxAge-2, 0, 0).Format("2006-01-02"), true},
+		{"invalid format", "17/05/2020", true},
+		{"empty is left to required", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errs := (Birthdate{Date: tt.date}).Validate(); (len(errs) != 0) != tt.wantErr {
+				t.Errorf("Validate(%q) = %v, want error %v", tt.date, errs, tt.wantErr)
+			}
+		})
+	}
+}
@@ -9,25 +9,75 @@ import (
 	"time"
 
 	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/geo"
+	"github.com/ArkjuniorK/store_app/validation"
 )
 
+// Allowed variety of cat
+var Varieties = []string{
+	"Abyssinian",
+	"American Shorthair",
+	"Bengal",
+	"Birman",
+	"British Shorthair",
+	"Domestic Longhair",
+	"Domestic Shorthair",
+	"Maine Coon",
+	"Mixed",
+	"Norwegian Forest",
+	"Persian",
+	"Ragdoll",
+	"Russian Blue",
+	"Scottish Fold",
+	"Siamese",
+	"Sphynx",
+}
+
+func init() {
+	validation.RegisterEnum("variety", Varieties...)
+}
+
 // Cat type store an object for cat entity
 type Cat struct {
-	ID      xid.ID    `json:"id"`
-	Name    string    `json:"name"`
-	Variety string    `json:"variety"`
-	Gender  string    `json:"gender"`
-	Age     int16     `json:"age"`
-	Address string    `json:"address"`
-	ZipCode int16     `json:"zip_code"`
-	Create  time.Time `json:"created_at"`
-	Update  time.Time `json:"updated_at"`
-	Image   *Picture  `json:"image"`
+	ID      xid.ID    `json:"id" validate:"immutable"`
+	Name    string    `json:"name" validate:"required,max=50"`
+	Variety string    `json:"variety" validate:"required,enum=variety"`
+	Gender  string    `json:"gender" validate:"required,oneof=male female"`
+	Birth   Birthdate `json:"birthdate" validate:"required"`
+	Address Address   `json:"address" validate:"required"`
+	Shelter xid.ID    `json:"shelter_id" validate:"required"`
+	Create  time.Time `json:"created_at" validate:"immutable"`
+	Update  time.Time `json:"updated_at" validate:"immutable"`
+	Image   *Picture  `json:"image" validate:"immutable"`
+
+	// Status is only changed by transition,
+	// History store all transitions of the cat
+	Status  string        `json:"status" validate:"readonly"`
+	History []*Transition `json:"status_history" validate:"readonly"`
+
+	// Location is geocoded from postal code when cat is written
+	// and Distance is computed on listing, it's never stored
+	Location *geo.Point `json:"location,omitempty" validate:"readonly"`
+	Distance *float64   `json:"distance_km,omitempty" validate:"readonly"`
+
+	// Age and LifeStage is computed from birthdate by SetAge
+	// when cat is sent to client, it's never stored
+	Age       *Age   `json:"age,omitempty" validate:"readonly"`
+	LifeStage string `json:"life_stage,omitempty" validate:"readonly"`
+
+	// Favorites is number of user that favorite the cat, it's
+	// only sent to staff that manage the cat and never stored
+	Favorites *int `json:"favorite_count,omitempty" validate:"readonly"`
 }
 
-// Cat type to store data as map
-// so it could be iterate using for loop
-type CatMap map[string]interface{}
+// SetAge would compute age and life stage of cat at now
+func (c *Cat) SetAge(now time.Time) {
+	age := c.Birth.AgeAt(now)
+
+	c.Age = &age
+	c.LifeStage = age.Stage()
+}
 
 // Cats type store multiple Cat entities
 type Cats []*Cat
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/geo"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Day of opening hours
+var Weekdays = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// Layout of opening and closing time
+const ClockLayout = "15:04"
+
+func init() {
+	validation.RegisterEnum("weekday", Weekdays...)
+}
+
+// Contact type store how shelter could be reached
+type Contact struct {
+	Email   string `json:"email" validate:"required,email,max=254"`
+	Phone   string `json:"phone" validate:"max=30"`
+	Website string `json:"website" validate:"max=200"`
+}
+
+// Hours type store opening hours of shelter on one day,
+// open and close is written as "HH:MM" on local time
+type Hours struct {
+	Day   string `json:"day" validate:"required,enum=weekday"`
+	Open  string `json:"open" validate:"required"`
+	Close string `json:"close" validate:"required"`
+}
+
+// Validate open and close time, shelter must be
+// closed after it's opened on the same day
+func (h Hours) Validate() validation.Errors {
+	var errs validation.Errors
+
+	open, err := time.Parse(ClockLayout, h.Open)
+
+	if h.Open != "" && err != nil {
+		errs = append(errs, validation.Error("open", "must be a time in HH:MM format"))
+	}
+
+	close, err := time.Parse(ClockLayout, h.Close)
+
+	if h.Close != "" && err != nil {
+		errs = append(errs, validation.Error("close", "must be a time in HH:MM format"))
+	}
+
+	if len(errs) == 0 && h.Open != "" && h.Close != "" && !close.After(open) {
+		errs = append(errs, validation.Error("close", "must be after open"))
+	}
+
+	return errs
+}
+
+// Shelter type store an object for shelter entity that own cats
+type Shelter struct {
+	ID      xid.ID    `json:"id" validate:"readonly"`
+	Name    string    `json:"name" validate:"required,max=100"`
+	Contact Contact   `json:"contact" validate:"required"`
+	Address Address   `json:"address" validate:"required"`
+	Hours   []*Hours  `json:"opening_hours" validate:"max=14"`
+	Logo    *Link     `json:"logo" validate:"readonly"`
+	Create  time.Time `json:"created_at" validate:"readonly"`
+	Update  time.Time `json:"updated_at" validate:"readonly"`
+
+	// Location is geocoded from postal code when shelter is written
+	Location *geo.Point `json:"location,omitempty" validate:"readonly"`
+}
+
+// Validate each opening hours since slice is not validated by tag
+func (s Shelter) Validate() validation.Errors {
+	var errs validation.Errors
+
+	for i, h := range s.Hours {
+		name := fmt.Sprintf("opening_hours[%d]", i)
+
+		if h == nil {
+			errs = append(errs, validation.Error(name, "is required"))
+			continue
+		}
+
+		for _, e := range validation.Struct(h) {
+			errs = append(errs, validation.Error(name+"."+e.Field, "%s", e.Message))
+		}
+	}
+
+	return errs
+}
+
+// Shelters type store multiple Shelter entities
+type Shelters []*Shelter
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Adoption status of cat
+const (
+	Intake          = "intake"           // just arrived, not ready for adoption
+	Available       = "available"        // could be adopted
+	OnHold          = "on_hold"          // temporary not available, ex: medical
+	PendingAdoption = "pending_adoption" // adoption is being processed
+	Adopted         = "adopted"
+	Returned        = "returned" // returned by adopter
+	Deceased        = "deceased"
+)
+
+// Allowed status of cat
+var Statuses = []string{Intake, Available, OnHold, PendingAdoption, Adopted, Returned, Deceased}
+
+// Allowed transitions, keyed by the current status.
+// Deceased has no transition since it's the final status
+var Transitions = map[string][]string{
+	Intake:          {Available, OnHold, Deceased},
+	Available:       {OnHold, PendingAdoption, Deceased},
+	OnHold:          {Available, Deceased},
+	PendingAdoption: {Available, OnHold, Adopted},
+	Adopted:         {Returned},
+	Returned:        {Intake, Available, OnHold, Deceased},
+	Deceased:        {},
+}
+
+// Error of disallowed transition
+var ErrTransition = errors.New("transition is not allowed")
+
+func init() {
+	validation.RegisterEnum("status", Statuses...)
+}
+
+// Transition type store change of cat status, it's also used as
+// requested body of transition where only To and Reason is sent
+type Transition struct {
+	From   string    `json:"from,omitempty" validate:"readonly"`
+	To     string    `json:"to" validate:"required,enum=status"`
+	Reason string    `json:"reason,omitempty" validate:"max=500"`
+	At     time.Time `json:"at" validate:"readonly"`
+}
+
+// TransitionError type store disallowed transition
+// with the transitions that is allowed instead
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("%s from %q to %q, %q is final status", ErrTransition, e.From, e.To, e.From)
+	}
+
+	return fmt.Sprintf("%s from %q to %q, allowed: %s", ErrTransition, e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrTransition
+}
+
+// Function to check if cat could be changed from status to status
+func CanTransition(from, to string) bool {
+	for _, v := range Transitions[from] {
+		if v == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Transition would change status of cat and add it to the history,
+// TransitionError is returned when the transition is not allowed
+func (c *Cat) Transition(to, reason string, at time.Time) error {
+	if !CanTransition(c.Status, to) {
+		return &TransitionError{From: c.Status, To: to, Allowed: Transitions[c.Status]}
+	}
+
+	c.History = append(c.History, &Transition{From: c.Status, To: to, Reason: reason, At: at})
+	c.Status = to
+
+	return nil
+}
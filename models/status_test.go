@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCatTransition(t *testing.T) {
+	tests := []struct {
+		from        string
+		to          string
+		wantErr     bool
+		wantAllowed []string
+	}{
+		{Intake, Available, false, nil},
+		{Available, PendingAdoption, false, nil},
+		{PendingAdoption, Adopted, false, nil},
+		{Adopted, Returned, false, nil},
+		{Returned, Available, false, nil},
+		{OnHold, Available, false, nil},
+		{Available, Adopted, true, []string{OnHold, PendingAdoption, Deceased}},
+		{Adopted, Available, true, []string{Returned}},
+		{Available, Available, true, []string{OnHold, PendingAdoption, Deceased}},
+		{Deceased, Available, true, []string{}},
+		{Available, "lost", true, []string{OnHold, PendingAdoption, Deceased}},
+	}
+
+	at := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			cat := &Cat{Status: tt.from}
+			err := cat.Transition(tt.to, "test", at)
+
+			if tt.wantErr {
+				var transition *TransitionError
+
+				if !errors.As(err, &transition) || !errors.Is(err, ErrTransition) {
+					t.Fatalf("Transition() error = %v, want TransitionError", err)
+				}
+
+				if len(transition.Allowed) != len(tt.wantAllowed) {
+					t.Errorf("Allowed = %v, want %v", transition.Allowed, tt.wantAllowed)
+				}
+
+				if cat.Status != tt.from || len(cat.History) != 0 {
+					t.Errorf("cat = %q with %d history, want unchanged", cat.Status, len(cat.History))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Transition() error = %v", err)
+			}
+
+			if cat.Status != tt.to || len(cat.History) != 1 {
+				t.Fatalf("cat = %q with %d history, want %q with 1 history", cat.Status, len(cat.History), tt.to)
+			}
+
+			want := Transition{From: tt.from, To: tt.to, Reason: "test", At: at}
+
+			if got := *cat.History[0]; got != want {
+				t.Errorf("history = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTransitionErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *TransitionError
+		want string
+	}{
+		{
+			&TransitionError{From: Available, To: Adopted, Allowed: Transitions[Available]},
+			`transition is not allowed from "available" to "adopted", allowed: on_hold, pending_adoption, deceased`,
+		},
+		{
+			&TransitionError{From: Deceased, To: Available},
+			`transition is not allowed from "deceased" to "available", "deceased" is final status`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.From, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransitionsAreKnown(t *testing.T) {
+	for _, status := range Statuses {
+		allowed, ok := Transitions[status]
+
+		if !ok {
+			t.Errorf("status %q has no transitions", status)
+		}
+
+		for _, to := range allowed {
+			if _, ok := Transitions[to]; !ok {
+				t.Errorf("transition from %q to unknown status %q", status, to)
+			}
+		}
+	}
+}
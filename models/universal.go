@@ -8,11 +8,25 @@ import (
 	"github.com/rs/xid"
 )
 
-// Object to hold information of image url
+// Object to hold information of image url,
+// animated image has still image as poster
 type Link struct {
-	ID  xid.ID `json:"id"`
-	URL string `json:"url"`
+	ID       xid.ID `json:"id"`
+	URL      string `json:"url"`
+	Animated bool   `json:"animated"`
+	Poster   string `json:"poster,omitempty"`
 }
 
 // Wrapper for Link object
 type Picture []*Link
+
+// Page type is envelope of paginated list,
+// next and prev is link to the adjacent page
+type Page struct {
+	Items interface{} `json:"items"`
+	Total int         `json:"total"`
+	Size  int         `json:"size"`
+	Page  int         `json:"page,omitempty"`
+	Next  *string     `json:"next"`
+	Prev  *string     `json:"prev"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Role of user, anonymous is role of request without user
+const (
+	RoleAdmin        = "admin"
+	RoleShelterStaff = "shelter_staff"
+	RoleFoster       = "foster"
+	RoleAdopter      = "adopter"
+	RoleAnonymous    = "anonymous"
+
+	// RoleIntegration is role of request with api key,
+	// it's not role of user so it's not in Roles
+	RoleIntegration = "integration"
+)
+
+// Allowed roles of user
+var Roles = []string{RoleAdmin, RoleShelterStaff, RoleFoster, RoleAdopter, RoleAnonymous}
+
+func init() {
+	validation.RegisterEnum("role", RoleAdmin, RoleShelterStaff, RoleFoster, RoleAdopter)
+}
+
+// User type store an object for user entity,
+// password is stored by auth package
+type User struct {
+	ID     xid.ID    `json:"id" validate:"readonly"`
+	Email  string    `json:"email" validate:"required,email,max=254"`
+	Name   string    `json:"name" validate:"required,max=100"`
+	Role   string    `json:"role" validate:"readonly"`
+	Create time.Time `json:"created_at" validate:"readonly"`
+	Update time.Time `json:"updated_at" validate:"readonly"`
+
+	// Shelters is shelter that is managed by the staff
+	Shelters []xid.ID `json:"shelter_ids" validate:"readonly"`
+
+	// TwoFactor report whether user login with TOTP code
+	TwoFactor bool `json:"two_factor" validate:"readonly"`
+
+	// Identities is account of identity provider linked to the user
+	Identities []*Identity `json:"identities,omitempty" validate:"readonly"`
+}
+
+// Identity type store account of user on OpenID Connect provider,
+// subject is only unique for the same issuer
+type Identity struct {
+	Issuer  string `json:"issuer"`
+	Subject string `json:"subject"`
+}
+
+// Registration type store requested body of registering user
+type Registration struct {
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// Credentials type store requested body of login
+type Credentials struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
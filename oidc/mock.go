@@ -0,0 +1,283 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How to work:
+// - Mock is local OpenID Connect provider used to try the login
+//   without real provider, it must never be used on production
+// - Authorization endpoint approve every request with the configured
+//   claims, so browser is redirected back to the callback at once
+// - Token endpoint check the code, client, redirect url and PKCE
+//   verifier the same as real provider and return signed ID token
+
+// Mock type store local provider and its pending codes
+type Mock struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Claims       Claims
+
+	key   *rsa.PrivateKey
+	kid   string
+	codes map[string]*mockCode
+	mu    sync.Mutex
+}
+
+// Code that is waiting to be exchanged
+type mockCode struct {
+	redirectURL string
+	challenge   string
+	nonce       string
+	expires     time.Time
+}
+
+// Function to create mock provider, signing key
+// is generated so it only live with the process
+func NewMock(issuer, clientID, clientSecret string, claims Claims) (*Mock, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// key id is taken from the key so client that
+	// cached the old key would refetch after restart
+	sum := sha256.Sum256(key.N.Bytes())
+
+	return &Mock{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Claims:       claims,
+		key:          key,
+		kid:          hex.EncodeToString(sum[:8]),
+		codes:        make(map[string]*mockCode),
+	}, nil
+}
+
+// Handler would return http handler of the mock endpoints
+func (m *Mock) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/jwks", m.jwks)
+
+	return mux
+}
+
+// Write JSON response of mock
+func mockJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Write OAuth error response of mock
+func mockError(w http.ResponseWriter, status int, code, description string) {
+	mockJSON(w, status, map[string]string{"error": code, "error_description": description})
+}
+
+// Serve discovery document
+func (m *Mock) discovery(w http.ResponseWriter, r *http.Request) {
+	mockJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.Issuer,
+		"authorization_endpoint":                m.Issuer + "/authorize",
+		"token_endpoint":                        m.Issuer + "/token",
+		"jwks_uri":                              m.Issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// Serve public key of the mock
+func (m *Mock) jwks(w http.ResponseWriter, r *http.Request) {
+	mockJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": m.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+// Approve the login and redirect back with code
+func (m *Mock) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("client_id") != m.ClientID {
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+
+	if err != nil || !redirect.IsAbs() {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "code flow with S256 PKCE is required", http.StatusBadRequest)
+		return
+	}
+
+	code, err := random()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	m.mu.Lock()
+	m.codes[code] = &mockCode{
+		redirectURL: redirect.String(),
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		expires:     time.Now().Add(time.Minute),
+	}
+	m.mu.Unlock()
+
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// Exchange code to signed ID token
+func (m *Mock) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		mockError(w, http.StatusMethodNotAllowed, "invalid_request", "method must be POST")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		mockError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+
+	if id, secret, ok := r.BasicAuth(); ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+
+		if id != m.ClientID || subtle.ConstantTimeCompare([]byte(secret), []byte(m.ClientSecret)) != 1 {
+			mockError(w, http.StatusUnauthorized, "invalid_client", "bad client credentials")
+			return
+		}
+
+		clientID = id
+	} else if m.ClientSecret != "" {
+		mockError(w, http.StatusUnauthorized, "invalid_client", "client secret is required")
+		return
+	}
+
+	if clientID != m.ClientID {
+		mockError(w, http.StatusUnauthorized, "invalid_client", "unknown client")
+		return
+	}
+
+	// code could only be exchanged once
+	m.mu.Lock()
+	pending := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	if pending == nil || time.Now().After(pending.expires) || pending.redirectURL != r.PostForm.Get("redirect_uri") {
+		mockError(w, http.StatusBadRequest, "invalid_grant", "unknown or expired code")
+		return
+	}
+
+	if challenge(r.PostForm.Get("code_verifier")) != pending.challenge {
+		mockError(w, http.StatusBadRequest, "invalid_grant", "code verifier does not match")
+		return
+	}
+
+	now := time.Now()
+	claims := Claims{}
+
+	for k, v := range m.Claims {
+		claims[k] = v
+	}
+
+	claims["iss"] = m.Issuer
+	claims["aud"] = m.ClientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(5 * time.Minute).Unix()
+
+	if pending.nonce != "" {
+		claims["nonce"] = pending.nonce
+	}
+
+	token, err := m.sign(claims)
+
+	if err != nil {
+		mockError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	// access token is not used by the login
+	access, err := random()
+
+	if err != nil {
+		mockError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	mockJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": access,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     token,
+	})
+}
+
+// Sign claims as RS256 JWT
+func (m *Mock) sign(claims Claims) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": m.kid})
+
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, sum[:])
+
+	if err != nil {
+		return "", err
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,369 @@
+// ======================
+// This package is package to sign in user with OpenID Connect provider
+// of the tenant. The flow is authorization code with PKCE:
+// - Login redirect browser to the provider with code challenge, the
+//   verifier, state and nonce is kept on the server
+// - Provider redirect back with code that is exchanged with the
+//   verifier to ID token, ID token is verified using provider's keys
+// - Claims of ID token is mapped to local user and role
+//
+// Each tenant could have its own provider written in "oidc.json"
+// inside the tenant root, tenant without the file has no OIDC login.
+// ======================
+
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Name of config file inside tenant root
+const configFile = "oidc.json"
+
+// How long discovery document is cached
+const discoveryTTL = time.Hour
+
+// Errors of OIDC login
+var (
+	ErrNotConfigured = errors.New("oidc is not configured")
+	ErrProvider      = errors.New("oidc provider error")
+)
+
+// Client used to request the provider
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Config type store OIDC provider of tenant.
+// Roles map value of RoleClaim to local role, the most privileged
+// matched role is given. DefaultRole is given when nothing is matched,
+// empty DefaultRole keep the role of the user
+type Config struct {
+	Issuer       string            `json:"issuer"`
+	ClientID     string            `json:"client_id"`
+	ClientSecret string            `json:"client_secret"`
+	RedirectURL  string            `json:"redirect_url"`
+	Scopes       []string          `json:"scopes"`
+	RoleClaim    string            `json:"role_claim"`
+	Roles        map[string]string `json:"roles"`
+	DefaultRole  string            `json:"default_role"`
+
+	// MultiFactorAMR and MultiFactorACR is "amr" and "acr" value of
+	// ID token that prove the provider checked the second factor,
+	// "mfa" amr is used when neither of them is configured
+	MultiFactorAMR []string `json:"mfa_amr"`
+	MultiFactorACR []string `json:"mfa_acr"`
+}
+
+// Default amr value of login with second factor (RFC 8176)
+var defaultMultiFactorAMR = []string{"mfa"}
+
+// Function to load OIDC config of tenant,
+// ErrNotConfigured is returned when there is no config
+func Load(t *tenant.Tenant) (*Config, error) {
+	var config Config
+
+	data, err := ioutil.ReadFile(t.Path(configFile))
+
+	if os.IsNotExist(err) {
+		return nil, ErrNotConfigured
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+
+	if config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("oidc: issuer and client_id is required")
+	}
+
+	for _, role := range append(mapValues(config.Roles), config.DefaultRole) {
+		if role != "" && !validRole(role) {
+			return nil, fmt.Errorf("oidc: invalid role %q", role)
+		}
+	}
+
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	return &config, nil
+}
+
+// Get values of map
+func mapValues(m map[string]string) []string {
+	values := make([]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// Check if role could be given to user
+func validRole(role string) bool {
+	for _, v := range models.Roles {
+		if v == role && v != models.RoleAnonymous {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Provider type store endpoints of the provider
+// taken from its discovery document
+type Provider struct {
+	Issuer        string `json:"issuer"`
+	Authorization string `json:"authorization_endpoint"`
+	Token         string `json:"token_endpoint"`
+	JWKS          string `json:"jwks_uri"`
+
+	fetched time.Time
+}
+
+// Discovered providers keyed by issuer
+var (
+	providers   = make(map[string]*Provider)
+	providersMu sync.Mutex
+)
+
+// Function to get provider of the config, discovery
+// document is cached so it's not requested on each login
+func (c *Config) Provider() (*Provider, error) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if p, ok := providers[c.Issuer]; ok && time.Since(p.fetched) < discoveryTTL {
+		return p, nil
+	}
+
+	p := new(Provider)
+
+	if err := getJSON(c.Issuer+"/.well-known/openid-configuration", p); err != nil {
+		return nil, err
+	}
+
+	// issuer of the document must be the configured issuer
+	// so other provider could not be used to sign in
+	if strings.TrimSuffix(p.Issuer, "/") != c.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrProvider, p.Issuer, c.Issuer)
+	}
+
+	if p.Authorization == "" || p.Token == "" || p.JWKS == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrProvider)
+	}
+
+	p.fetched = time.Now()
+	providers[c.Issuer] = p
+
+	return p, nil
+}
+
+// Request JSON document of the provider
+func getJSON(url string, v interface{}) error {
+	res, err := client.Get(url)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned %s", ErrProvider, url, res.Status)
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	return nil
+}
+
+// Create random string encoded as url safe string
+func random() (string, error) {
+	buff := make([]byte, 32)
+
+	if _, err := rand.Read(buff); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buff), nil
+}
+
+// Get S256 code challenge of the verifier
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthURL would return url of the provider that the browser is
+// redirected to, state carry the verifier and nonce of the login
+func (c *Config) AuthURL(p *Provider, state *State) string {
+	scopes := append([]string{"openid"}, c.Scopes...)
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.ClientID},
+		"redirect_uri":          {state.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state.Token},
+		"nonce":                 {state.Nonce},
+		"code_challenge":        {challenge(state.Verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(p.Authorization, "?") {
+		sep = "&"
+	}
+
+	return p.Authorization + sep + query.Encode()
+}
+
+// Exchange would exchange code of the callback to ID token,
+// the verifier prove the code is requested by the same login
+func (c *Config) Exchange(p *Provider, code string, state *State) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {state.RedirectURL},
+		"client_id":     {c.ClientID},
+		"code_verifier": {state.Verifier},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.Token, strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	// public client only send the verifier
+	if c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	defer res.Body.Close()
+
+	var token struct {
+		IDToken     string `json:"id_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+
+	if err = json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	if token.Error != "" || res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s %s", ErrProvider, token.Error, token.Description)
+	}
+
+	if token.IDToken == "" {
+		return "", fmt.Errorf("%w: id_token is missing", ErrProvider)
+	}
+
+	return token.IDToken, nil
+}
+
+// Role would map claims to local role, empty role means
+// the role of user is not changed by the provider
+func (c *Config) Role(claims Claims) string {
+	if c.RoleClaim == "" {
+		return ""
+	}
+
+	matched := make(map[string]bool)
+	for _, v := range claims.Strings(c.RoleClaim) {
+		if role, ok := c.Roles[v]; ok {
+			matched[role] = true
+		}
+	}
+
+	// models.Roles is ordered from the most privileged role
+	for _, role := range models.Roles {
+		if matched[role] {
+			return role
+		}
+	}
+
+	return c.DefaultRole
+}
+
+// Profile would map claims to identity and user of the login,
+// verified report whether email is verified by the provider
+func (c *Config) Profile(claims Claims) (identity *models.Identity, profile *models.User, verified bool) {
+	identity = &models.Identity{Issuer: c.Issuer, Subject: claims.String("sub")}
+
+	profile = &models.User{
+		Email: claims.String("email"),
+		Name:  claims.String("name"),
+		Role:  c.Role(claims),
+	}
+
+	if profile.Name == "" {
+		profile.Name = claims.String("preferred_username")
+	}
+
+	return identity, profile, claims.Bool("email_verified")
+}
+
+// MultiFactor would report whether the provider authenticated the
+// login with second factor, it's only trusted when the ID token
+// has the configured amr or acr value
+func (c *Config) MultiFactor(claims Claims) bool {
+	amr := c.MultiFactorAMR
+	if len(amr) == 0 && len(c.MultiFactorACR) == 0 {
+		amr = defaultMultiFactorAMR
+	}
+
+	for _, v := range claims.Strings("amr") {
+		if contains(amr, v) {
+			return true
+		}
+	}
+
+	acr := claims.String("acr")
+
+	return acr != "" && contains(c.MultiFactorACR, acr)
+}
+
+// Callback would return the callback url of the login,
+// it's derived from the request when it's not configured
+func (c *Config) Callback(r *http.Request) string {
+	if c.RedirectURL != "" {
+		return c.RedirectURL
+	}
+
+	scheme := "https"
+	if r.TLS == nil && os.Getenv("APP_ENV") == "dev" {
+		scheme = "http"
+	}
+
+	return scheme + "://" + r.Host + cookiePath + "/callback"
+}
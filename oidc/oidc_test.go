@@ -0,0 +1,31 @@
+package oidc
+
+import "testing"
+
+func TestMultiFactor(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		claims Claims
+		want   bool
+	}{
+		{"default mfa amr", Config{}, Claims{"amr": []interface{}{"pwd", "mfa"}}, true},
+		{"password only", Config{}, Claims{"amr": []interface{}{"pwd"}}, false},
+		{"no amr", Config{}, Claims{}, false},
+		{"amr as string", Config{}, Claims{"amr": "mfa"}, true},
+		{"configured amr", Config{MultiFactorAMR: []string{"otp", "hwk"}}, Claims{"amr": []interface{}{"pwd", "hwk"}}, true},
+		{"configured amr replace default", Config{MultiFactorAMR: []string{"otp"}}, Claims{"amr": []interface{}{"mfa"}}, false},
+		{"configured acr", Config{MultiFactorACR: []string{"urn:mace:incommon:iap:silver"}}, Claims{"acr": "urn:mace:incommon:iap:silver"}, true},
+		{"other acr", Config{MultiFactorACR: []string{"urn:mace:incommon:iap:silver"}}, Claims{"acr": "0"}, false},
+		{"acr without config", Config{}, Claims{"acr": "urn:mace:incommon:iap:silver"}, false},
+		{"acr config keep default amr", Config{MultiFactorACR: []string{"gold"}}, Claims{"amr": []interface{}{"mfa"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.MultiFactor(tt.claims); got != tt.want {
+				t.Errorf("MultiFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
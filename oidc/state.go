@@ -0,0 +1,160 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/tenant"
+)
+
+// Directory of pending login inside root of the tenant
+const statesDir = "data/oidc"
+
+// How long the browser has to come back from the provider
+const stateTTL = 10 * time.Minute
+
+// Name of cookie that bind the login to the browser
+const StateCookie = "oidc_state"
+
+// Path of cookie, it's only sent to the callback
+const cookiePath = "/api/auth/oidc"
+
+// Error of unknown, expired or used login
+var ErrState = errors.New("invalid or expired login state")
+
+// State type store pending login. Token is sent as state parameter
+// and as cookie, the file is named by hash of the token so
+// verifier and nonce could only be read by the same login
+type State struct {
+	Token       string    `json:"-"`
+	Verifier    string    `json:"verifier"`
+	Nonce       string    `json:"nonce"`
+	RedirectURL string    `json:"redirect_url"`
+	ReturnTo    string    `json:"return_to"`
+	Expires     time.Time `json:"expires_at"`
+
+	// Link is id of logged in user that link the provider
+	// to own account, it's empty when the user is logging in
+	Link string `json:"link,omitempty"`
+}
+
+// Get path of state file
+func statePath(t *tenant.Tenant, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return t.Path(statesDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Function to create pending login of tenant, redirectURL is
+// the callback, returnTo is where browser go after login and
+// link is id of user that link the provider or empty on login
+func NewState(t *tenant.Tenant, redirectURL, returnTo, link string) (*State, error) {
+	var (
+		state = &State{RedirectURL: redirectURL, ReturnTo: returnTo, Link: link, Expires: time.Now().Add(stateTTL)}
+		err   error
+	)
+
+	for _, v := range []*string{&state.Token, &state.Verifier, &state.Nonce} {
+		if *v, err = random(); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(t.Path(statesDir), 0700); err != nil {
+		return nil, err
+	}
+
+	if err = ioutil.WriteFile(statePath(t, state.Token), data, 0600); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// Function to take pending login of the token, the state is
+// removed so the same callback could not be replayed
+func TakeState(t *tenant.Tenant, token string) (*State, error) {
+	var state State
+
+	if token == "" {
+		return nil, ErrState
+	}
+
+	file := statePath(t, token)
+	data, err := ioutil.ReadFile(file)
+
+	if os.IsNotExist(err) {
+		return nil, ErrState
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.Remove(file); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(state.Expires) {
+		return nil, ErrState
+	}
+
+	state.Token = token
+
+	return &state, nil
+}
+
+// Function to check if state parameter of callback is the login
+// started by this browser, compared in constant time
+func CheckState(r *http.Request, token string) bool {
+	cookie, err := r.Cookie(StateCookie)
+
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) == 1
+}
+
+// Function to set cookie of pending login,
+// it's only sent over https except on dev mode
+func SetStateCookie(w http.ResponseWriter, state *State) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookie,
+		Value:    state.Token,
+		Path:     cookiePath,
+		Expires:  state.Expires,
+		HttpOnly: true,
+		Secure:   os.Getenv("APP_ENV") != "dev",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Function to remove cookie of pending login
+func ClearStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     StateCookie,
+		Value:    "",
+		Path:     cookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   os.Getenv("APP_ENV") != "dev",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,282 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How to work:
+// - ID token is JWT signed by the provider with RS256, other
+//   algorithm is rejected so "none" or HMAC token could not be used
+// - Keys of the provider is taken from its JWKS and cached by key id,
+//   unknown key id refetch the JWKS at most once per minute since
+//   provider rotate its keys
+// - Issuer, audience, expiry and nonce is checked after the signature
+
+// Allowed clock difference with the provider
+const leeway = time.Minute
+
+// How often JWKS could be refetched for unknown key
+const jwksRefetch = time.Minute
+
+// Error of ID token that could not be trusted
+var ErrToken = errors.New("invalid id token")
+
+// Cached keys of each JWKS url
+type keySet struct {
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+var (
+	keySets   = make(map[string]*keySet)
+	keySetsMu sync.Mutex
+)
+
+// JSON Web Key of the provider, only RSA key is read
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Get public key of JWK
+func (k *jwk) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// Get key of the provider by key id,
+// JWKS is refetched when the key is not known
+func (p *Provider) key(kid string) (*rsa.PublicKey, error) {
+	keySetsMu.Lock()
+	defer keySetsMu.Unlock()
+
+	set := keySets[p.JWKS]
+
+	if set != nil {
+		if key, ok := set.keys[kid]; ok {
+			return key, nil
+		}
+
+		if time.Since(set.fetched) < jwksRefetch {
+			return nil, fmt.Errorf("%w: unknown key %q", ErrToken, kid)
+		}
+	}
+
+	var doc struct {
+		Keys []*jwk `json:"keys"`
+	}
+
+	if err := getJSON(p.JWKS, &doc); err != nil {
+		return nil, err
+	}
+
+	set = &keySet{keys: make(map[string]*rsa.PublicKey), fetched: time.Now()}
+
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		key, err := k.publicKey()
+
+		if err != nil {
+			continue
+		}
+
+		set.keys[k.Kid] = key
+	}
+
+	keySets[p.JWKS] = set
+
+	if key, ok := set.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown key %q", ErrToken, kid)
+}
+
+// Claims type store claims of verified ID token
+type Claims map[string]interface{}
+
+// Get string claim, empty when it's not string
+func (c Claims) String(name string) string {
+	v, _ := c[name].(string)
+	return v
+}
+
+// Get claim as list of string, single
+// string claim is treated as list of one
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+
+	return nil
+}
+
+// Get boolean claim, some provider
+// send email_verified as string
+func (c Claims) Bool(name string) bool {
+	switch v := c[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+
+	return false
+}
+
+// Get time of numeric claim
+func (c Claims) time(name string) (time.Time, bool) {
+	v, ok := c[name].(float64)
+
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(v), 0), true
+}
+
+// Verify would verify ID token of the login and return its claims
+func (c *Config) Verify(p *Provider, token, nonce string) (Claims, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrToken, header.Alg)
+	}
+
+	key, err := p.key(header.Kid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrToken)
+	}
+
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrToken)
+	}
+
+	var claims Claims
+
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(claims.String("iss"), "/") != c.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrToken)
+	}
+
+	audience := claims.Strings("aud")
+
+	if !contains(audience, c.ClientID) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrToken)
+	}
+
+	// token for several client must be authorized for this client
+	if len(audience) > 1 && claims.String("azp") != c.ClientID {
+		return nil, fmt.Errorf("%w: unexpected authorized party", ErrToken)
+	}
+
+	now := time.Now()
+
+	if exp, ok := claims.time("exp"); !ok || now.After(exp.Add(leeway)) {
+		return nil, fmt.Errorf("%w: token is expired", ErrToken)
+	}
+
+	if iat, ok := claims.time("iat"); ok && iat.After(now.Add(leeway)) {
+		return nil, fmt.Errorf("%w: token is issued in the future", ErrToken)
+	}
+
+	if claims.String("nonce") != nonce {
+		return nil, fmt.Errorf("%w: unexpected nonce", ErrToken)
+	}
+
+	if claims.String("sub") == "" {
+		return nil, fmt.Errorf("%w: subject is missing", ErrToken)
+	}
+
+	return claims, nil
+}
+
+// Decode base64 JSON segment of token
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrToken)
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrToken)
+	}
+
+	return nil
+}
+
+// Check if list contains value
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
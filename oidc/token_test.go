@@ -0,0 +1,152 @@
+package oidc
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Create token without signature of the mock
+func unsignedToken(alg string, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"` + alg + `","typ":"JWT"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + "."
+}
+
+func TestVerify(t *testing.T) {
+	mock, err := NewMock("http://provider.test", "client", "secret", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(mock.Handler())
+	defer server.Close()
+
+	config := &Config{Issuer: mock.Issuer, ClientID: "client"}
+	provider := &Provider{Issuer: mock.Issuer, JWKS: server.URL + "/jwks"}
+	now := time.Now().Unix()
+
+	// valid claims with the change of the case, nil remove the claim
+	claims := func(change Claims) Claims {
+		c := Claims{
+			"iss":   mock.Issuer,
+			"aud":   "client",
+			"sub":   "user-1",
+			"nonce": "nonce",
+			"iat":   now,
+			"exp":   now + 300,
+		}
+
+		for k, v := range change {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+
+			c[k] = v
+		}
+
+		return c
+	}
+
+	sign := func(c Claims) string {
+		token, err := mock.sign(c)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	valid := sign(claims(nil))
+	parts := strings.Split(valid, ".")
+	other := strings.Split(sign(claims(Claims{"sub": "user-2"})), ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr bool
+	}{
+		{"valid", valid, "nonce", false},
+		{"issuer with slash", sign(claims(Claims{"iss": mock.Issuer + "/"})), "nonce", false},
+		{"audience list with azp", sign(claims(Claims{"aud": []string{"client", "other"}, "azp": "client"})), "nonce", false},
+		{"expired within leeway", sign(claims(Claims{"exp": now - 30})), "nonce", false},
+		{"malformed", "token", "nonce", true},
+		{"alg none", unsignedToken("none", `{"iss":"http://provider.test","aud":"client","sub":"user-1"}`), "nonce", true},
+		{"alg HS256", unsignedToken("HS256", `{"iss":"http://provider.test","aud":"client","sub":"user-1"}`), "nonce", true},
+		{"signature of other token", parts[0] + "." + other[1] + "." + parts[2], "nonce", true},
+		{"without signature", parts[0] + "." + parts[1] + ".", "nonce", true},
+		{"wrong issuer", sign(claims(Claims{"iss": "http://other.test"})), "nonce", true},
+		{"wrong audience", sign(claims(Claims{"aud": "other"})), "nonce", true},
+		{"audience list without azp", sign(claims(Claims{"aud": []string{"client", "other"}})), "nonce", true},
+		{"expired", sign(claims(Claims{"exp": now - 120})), "nonce", true},
+		{"without expiry", sign(claims(Claims{"exp": nil})), "nonce", true},
+		{"issued in the future", sign(claims(Claims{"iat": now + 120})), "nonce", true},
+		{"wrong nonce", valid, "other", true},
+		{"without nonce", sign(claims(Claims{"nonce": nil})), "nonce", true},
+		{"without subject", sign(claims(Claims{"sub": nil})), "nonce", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.Verify(provider, tt.token, tt.nonce)
+
+			if tt.wantErr {
+				if !errors.Is(err, ErrToken) {
+					t.Errorf("Verify() error = %v, want %v", err, ErrToken)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Verify() error = %v, want nil", err)
+			}
+
+			if got.String("sub") != "user-1" {
+				t.Errorf("Verify() subject = %q, want %q", got.String("sub"), "user-1")
+			}
+		})
+	}
+}
+
+func TestVerifyUnknownKey(t *testing.T) {
+	mock, err := NewMock("http://provider.test", "client", "secret", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewMock("http://provider.test", "client", "secret", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(mock.Handler())
+	defer server.Close()
+
+	config := &Config{Issuer: mock.Issuer, ClientID: "client"}
+	provider := &Provider{Issuer: mock.Issuer, JWKS: server.URL + "/jwks"}
+
+	// token signed by key that is not in the JWKS of the provider
+	token, err := other.sign(Claims{
+		"iss":   mock.Issuer,
+		"aud":   "client",
+		"sub":   "user-1",
+		"nonce": "nonce",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = config.Verify(provider, token, "nonce"); !errors.Is(err, ErrToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrToken)
+	}
+}
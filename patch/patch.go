@@ -0,0 +1,520 @@
+// ======================
+// This package is package to apply patch document to JSON document.
+// Two format is supported:
+// - JSON Merge Patch (RFC 7396) with "application/merge-patch+json"
+// - JSON Patch (RFC 6902) with "application/json-patch+json"
+//
+// Document is processed as generic JSON value so the result
+// should be decoded and validated against the model after patched.
+// ======================
+
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Content type of each patch format
+const (
+	MergePatch = "application/merge-patch+json"
+	JSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalid is returned when the patch document is malformed
+	ErrInvalid = errors.New("invalid patch document")
+
+	// ErrTestFailed is returned when "test" operation is not fulfilled
+	ErrTestFailed = errors.New("test operation failed")
+
+	// ErrPath is returned when operation could not be applied to the path
+	ErrPath = errors.New("invalid path")
+)
+
+// Error type store the failed operation of JSON Patch
+type Error struct {
+	Index int    // index of operation
+	Op    string // name of operation
+	Path  string // path of operation
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Decode json keeping number as json.Number
+// so it would not lose precision
+func decode(data []byte) (interface{}, error) {
+	var v interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Function to apply JSON Merge Patch to doc, null value
+// in the patch would remove the member from doc
+func Merge(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := decode(patch)
+
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	return json.Marshal(merge(target, p))
+}
+
+// Merge patch recursively as described by RFC 7396
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+
+	// non object patch replace the whole target
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+
+		t[k] = merge(t[k], v)
+	}
+
+	return t
+}
+
+// Operation type store one operation of JSON Patch, Value is empty
+// when it's missing and "null" when the value is null
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Function to apply JSON Patch to doc, operations is applied
+// in order and the whole patch fail when one of them failed
+func Apply(doc, patch []byte) ([]byte, error) {
+	var ops []*Operation
+
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, ErrInvalid
+	}
+
+	target, err := decode(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		// "null" operation is decoded as nil
+		if op == nil {
+			return nil, &Error{i, "", "", ErrInvalid}
+		}
+
+		if op.Path == nil {
+			return nil, &Error{i, op.Op, "", ErrInvalid}
+		}
+
+		target, err = apply(target, op)
+
+		if err != nil {
+			return nil, &Error{i, op.Op, *op.Path, err}
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// Apply one operation to doc and return the new doc
+func apply(doc interface{}, op *Operation) (interface{}, error) {
+	var value interface{}
+
+	// "value" is required by add, replace and test
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, ErrInvalid
+		}
+
+		v, err := decode(op.Value)
+
+		if err != nil {
+			return nil, ErrInvalid
+		}
+
+		value = v
+	case "move", "copy":
+		if op.From == nil {
+			return nil, ErrInvalid
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, *op.Path, value)
+	case "remove":
+		doc, _, err := remove(doc, *op.Path)
+		return doc, err
+	case "replace":
+		doc, _, err := remove(doc, *op.Path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, *op.Path, value)
+	case "move":
+		// value could not be moved to its own child
+		if strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, ErrPath
+		}
+
+		doc, v, err := remove(doc, *op.From)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, *op.Path, v)
+	case "copy":
+		v, err := get(doc, *op.From)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, *op.Path, clone(v))
+	case "test":
+		v, err := get(doc, *op.Path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal(v, value) {
+			return nil, ErrTestFailed
+		}
+
+		return doc, nil
+	}
+
+	return nil, ErrInvalid
+}
+
+// Parse JSON Pointer (RFC 6901) to its tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, ErrPath
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+
+	for i, v := range tokens {
+		v = strings.ReplaceAll(v, "~1", "/")
+		tokens[i] = strings.ReplaceAll(v, "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// Parse array index, "-" is index after the last element
+// and only allowed when allowEnd is true
+func parseIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" && allowEnd {
+		return length, nil
+	}
+
+	// leading zero is not allowed
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrPath
+	}
+
+	i, err := strconv.Atoi(token)
+
+	if err != nil || i < 0 {
+		return 0, ErrPath
+	}
+
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+
+	if i > max {
+		return 0, ErrPath
+	}
+
+	return i, nil
+}
+
+// Get the value at pointer
+func get(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := parsePointer(pointer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tokens {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[t]
+
+			if !ok {
+				return nil, ErrPath
+			}
+
+			doc = child
+		case []interface{}:
+			i, err := parseIndex(t, len(v), false)
+
+			if err != nil {
+				return nil, err
+			}
+
+			doc = v[i]
+		default:
+			return nil, ErrPath
+		}
+	}
+
+	return doc, nil
+}
+
+// Split pointer to the pointer of parent and the last token
+func splitPointer(pointer string) (string, string, error) {
+	i := strings.LastIndex(pointer, "/")
+
+	if i < 0 {
+		return "", "", ErrPath
+	}
+
+	last := strings.ReplaceAll(pointer[i+1:], "~1", "/")
+	return pointer[:i], strings.ReplaceAll(last, "~0", "~"), nil
+}
+
+// Set the child of parent at pointer, slice could be
+// reallocated so the parent need to be set again
+func set(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	if pointer == "" {
+		return value, nil
+	}
+
+	parentPointer, token, err := splitPointer(pointer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := get(doc, parentPointer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[token] = value
+		return doc, nil
+	case []interface{}:
+		i, err := parseIndex(token, len(v), false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		v[i] = value
+		return doc, nil
+	}
+
+	return nil, ErrPath
+}
+
+// Add value at pointer, value inserted to array
+// would shift the elements after it
+func add(doc interface{}, pointer string, value interface{}) (interface{}, error) {
+	if pointer == "" {
+		return value, nil
+	}
+
+	parentPointer, token, err := splitPointer(pointer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parent, err := get(doc, parentPointer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[token] = value
+		return doc, nil
+	case []interface{}:
+		i, err := parseIndex(token, len(v), true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		v = append(v, nil)
+		copy(v[i+1:], v[i:])
+		v[i] = value
+
+		return set(doc, parentPointer, v)
+	}
+
+	return nil, ErrPath
+}
+
+// Remove value at pointer and return the removed value
+func remove(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	if pointer == "" {
+		return nil, doc, nil
+	}
+
+	parentPointer, token, err := splitPointer(pointer)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parent, err := get(doc, parentPointer)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		value, ok := v[token]
+
+		if !ok {
+			return nil, nil, ErrPath
+		}
+
+		delete(v, token)
+		return doc, value, nil
+	case []interface{}:
+		i, err := parseIndex(token, len(v), false)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := v[i]
+		v = append(v[:i:i], v[i+1:]...)
+
+		doc, err = set(doc, parentPointer, v)
+		return doc, value, err
+	}
+
+	return nil, nil, ErrPath
+}
+
+// Deep copy of json value
+func clone(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			c[k] = clone(child)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, child := range v {
+			c[i] = clone(child)
+		}
+		return c
+	}
+
+	return v
+}
+
+// Compare two json value, number is compared by its value
+// so 1 and 1.0 is equal
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for k, v := range a {
+			child, ok := b[k]
+			if !ok || !equal(v, child) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+
+		if !ok {
+			return false
+		}
+
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+
+		return errA == nil && errB == nil && x == y
+	}
+
+	return a == b
+}
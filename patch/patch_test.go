@@ -0,0 +1,180 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Compare two JSON document ignoring the order of members
+func equalJSON(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+
+	var g, w interface{}
+
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid result %s: %v", got, err)
+	}
+
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid expected %s: %v", want, err)
+	}
+
+	return reflect.DeepEqual(g, w)
+}
+
+func TestMerge(t *testing.T) {
+	// cases are taken from appendix A of RFC 7396
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null remove member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"null keep other member", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"array is replaced", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"value replaced by array", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"nested null remove", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"array member is not merged", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"array replace array", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"object replaced by array", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"object replaced by null", `{"a":"foo"}`, `null`, `null`},
+		{"object replaced by string", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null member is kept", `{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{"array replaced by object", `[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{"null inside new object is dropped", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{"number keep precision", `{"n":1}`, `{"n":12345678901234567890}`, `{"n":12345678901234567890}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Merge([]byte(tt.doc), []byte(tt.patch))
+
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+
+			if !equalJSON(t, got, tt.want) {
+				t.Errorf("Merge() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeInvalid(t *testing.T) {
+	if _, err := Merge([]byte(`{}`), []byte(`{`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Merge() error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestApply(t *testing.T) {
+	// most cases are taken from appendix A of RFC 6902
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"add to end of array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":"qux"}]`, `{"foo":["bar","qux"]}`},
+		{"add nested member", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"add array value", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"add replace root", `{"foo":"bar"}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"move to sibling with same prefix", `{"a":1}`, `[{"op":"move","from":"/a","path":"/ab"}]`, `{"ab":1}`},
+		{"copy value", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{"test value", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"test ignore member order", `{"a":{"x":1,"y":2}}`, `[{"op":"test","path":"/a","value":{"y":2,"x":1}}]`, `{"a":{"x":1,"y":2}}`},
+		{"escaped pointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`},
+		{"empty patch", `{"a":1}`, `[]`, `{"a":1}`},
+		{"add null", `{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+		{"add null to array", `{"a":[1]}`, `[{"op":"add","path":"/a/-","value":null}]`, `{"a":[1,null]}`},
+		{"replace with null", `{"a":"b"}`, `[{"op":"replace","path":"/a","value":null}]`, `{"a":null}`},
+		{"test null", `{"a":null}`, `[{"op":"test","path":"/a","value":null}]`, `{"a":null}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			if !equalJSON(t, got, tt.want) {
+				t.Errorf("Apply() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		index int
+		err   error
+	}{
+		{"null operation", `{}`, `[null]`, 0, ErrInvalid},
+		{"missing path", `{}`, `[{"op":"add","value":1}]`, 0, ErrInvalid},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`, 0, ErrInvalid},
+		{"missing from", `{"a":1}`, `[{"op":"move","path":"/b"}]`, 0, ErrInvalid},
+		{"unknown operation", `{}`, `[{"op":"merge","path":"/a","value":1}]`, 0, ErrInvalid},
+		{"test failed", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, 0, ErrTestFailed},
+		{"test null with value", `{"a":1}`, `[{"op":"test","path":"/a","value":null}]`, 0, ErrTestFailed},
+		{"test number with string", `{"baz":1}`, `[{"op":"test","path":"/baz","value":"1"}]`, 0, ErrTestFailed},
+		{"move into own child", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, 0, ErrPath},
+		{"add to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, 0, ErrPath},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, 0, ErrPath},
+		{"index out of range", `{"foo":[1]}`, `[{"op":"add","path":"/foo/2","value":2}]`, 0, ErrPath},
+		{"leading zero index", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/01"}]`, 0, ErrPath},
+		{"negative index", `{"foo":[1,2]}`, `[{"op":"remove","path":"/foo/-1"}]`, 0, ErrPath},
+		{"end index is only for add", `{"foo":[1]}`, `[{"op":"remove","path":"/foo/-"}]`, 0, ErrPath},
+		{"pointer without slash", `{"a":1}`, `[{"op":"remove","path":"a"}]`, 0, ErrPath},
+		{"later operation failed", `{"a":1}`, `[{"op":"remove","path":"/a"},{"op":"test","path":"/a","value":1}]`, 1, ErrPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply([]byte(tt.doc), []byte(tt.patch))
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.err)
+			}
+
+			var e *Error
+			if !errors.As(err, &e) || e.Index != tt.index {
+				t.Errorf("Apply() error = %v, want operation %d", err, tt.index)
+			}
+		})
+	}
+}
+
+func TestApplyNotArray(t *testing.T) {
+	if _, err := Apply([]byte(`{}`), []byte(`{"op":"add"}`)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Apply() error = %v, want %v", err, ErrInvalid)
+	}
+}
+
+func TestApplyKeepDocument(t *testing.T) {
+	doc := []byte(`{"a":[1,2]}`)
+
+	// failed patch must not change the document
+	if _, err := Apply(doc, []byte(`[{"op":"add","path":"/a/-","value":3},{"op":"test","path":"/b","value":1}]`)); err == nil {
+		t.Fatal("Apply() error = nil, want error")
+	}
+
+	if string(doc) != `{"a":[1,2]}` {
+		t.Errorf("document changed to %s", doc)
+	}
+}
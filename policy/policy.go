@@ -0,0 +1,159 @@
+// ======================
+// This package is package to decide what user could do.
+// Each action is allowed for some roles, user without role is
+// treated as adopter and request without user is anonymous.
+// Used by Authorize middleware on each route and by controller
+// when the decision depends on the resource, ex: own application
+// or cat of shelter that is managed by the staff. Request with api
+// key is checked against the scope of the key instead of the role.
+// ======================
+
+package policy
+
+import (
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Action type is something user could do to a resource
+type Action string
+
+const (
+	ReadCat       Action = "cat:read"
+	CreateCat     Action = "cat:create"
+	UpdateCat     Action = "cat:update"
+	DeleteCat     Action = "cat:delete"
+	TransitionCat Action = "cat:transition"
+	UploadImage   Action = "image:upload"
+	DeleteImage   Action = "image:delete"
+
+	SubmitApplication Action = "application:submit"
+	ReviewApplication Action = "application:review"
+
+	ReadShelter   Action = "shelter:read"
+	CreateShelter Action = "shelter:create"
+	UpdateShelter Action = "shelter:update"
+	DeleteShelter Action = "shelter:delete"
+
+	ManageUsers Action = "user:manage"
+	ManageKeys  Action = "key:manage"
+
+	FavoriteCat       Action = "favorite:create"
+	ReadFavoriteCount Action = "favorite:count"
+)
+
+// Staff roles that manage cats of the shelter
+var staff = []string{models.RoleAdmin, models.RoleShelterStaff}
+
+// Roles that is allowed for each action
+var Rules = map[Action][]string{
+	ReadCat:       models.Roles,
+	CreateCat:     staff,
+	UpdateCat:     staff,
+	DeleteCat:     staff,
+	TransitionCat: staff,
+	UploadImage:   staff,
+	DeleteImage:   staff,
+
+	SubmitApplication: {models.RoleAdmin, models.RoleShelterStaff, models.RoleFoster, models.RoleAdopter},
+	ReviewApplication: staff,
+
+	ReadShelter:   models.Roles,
+	CreateShelter: {models.RoleAdmin},
+	UpdateShelter: staff,
+	DeleteShelter: {models.RoleAdmin},
+
+	ManageUsers: {models.RoleAdmin},
+	ManageKeys:  staff,
+
+	FavoriteCat:       {models.RoleAdmin, models.RoleShelterStaff, models.RoleFoster, models.RoleAdopter},
+	ReadFavoriteCount: staff,
+}
+
+// Actions that is allowed for each scope of api key, request with
+// api key is never allowed to do action outside of its scope
+var KeyRules = map[string][]Action{
+	models.KeyScopeRead: {ReadCat, ReadShelter},
+	models.KeyScopeShelters: {
+		ReadCat, ReadShelter,
+		CreateCat, UpdateCat, DeleteCat, TransitionCat,
+		UploadImage, DeleteImage,
+		UpdateShelter,
+	},
+}
+
+// Roles that must use two-factor when it's required by the tenant
+var TwoFactorRoles = staff
+
+// Function to check if user must use two-factor,
+// required is the setting of the tenant
+func RequiresTwoFactor(user *models.User, required bool) bool {
+	if !required {
+		return false
+	}
+
+	role := RoleOf(user)
+
+	for _, v := range TwoFactorRoles {
+		if v == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Function to check if api key with the scope is allowed to do the action
+func KeyAllowed(key *models.APIKey, action Action) bool {
+	for _, v := range KeyRules[key.Scope] {
+		if v == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Function to get role of user
+func RoleOf(user *models.User) string {
+	switch {
+	case user == nil:
+		return models.RoleAnonymous
+	case user.Role == "":
+		return models.RoleAdopter
+	}
+
+	return user.Role
+}
+
+// Function to check if user is allowed to do the action
+func Allowed(user *models.User, action Action) bool {
+	role := RoleOf(user)
+
+	for _, v := range Rules[action] {
+		if v == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Function to check if user manage the shelter, admin manage all
+// shelters and staff only manage shelters that is assigned to them,
+// api key manage shelters of its scope
+func Manages(user *models.User, shelter xid.ID) bool {
+	switch RoleOf(user) {
+	case models.RoleAdmin:
+		return true
+	case models.RoleShelterStaff, models.RoleIntegration:
+		for _, v := range user.Shelters {
+			if v == shelter {
+				return true
+			}
+		}
+	}
+
+	return false
+}
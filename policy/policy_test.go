@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+func TestManages(t *testing.T) {
+	own, other := xid.New(), xid.New()
+
+	tests := []struct {
+		name    string
+		user    *models.User
+		shelter xid.ID
+		want    bool
+	}{
+		{"admin any shelter", &models.User{Role: models.RoleAdmin}, other, true},
+		{"admin cat without shelter", &models.User{Role: models.RoleAdmin}, xid.NilID(), true},
+		{"staff own shelter", &models.User{Role: models.RoleShelterStaff, Shelters: []xid.ID{own}}, own, true},
+		{"staff other shelter", &models.User{Role: models.RoleShelterStaff, Shelters: []xid.ID{own}}, other, false},
+		{"staff cat without shelter", &models.User{Role: models.RoleShelterStaff, Shelters: []xid.ID{own}}, xid.NilID(), false},
+		{"staff without shelter", &models.User{Role: models.RoleShelterStaff}, own, false},
+		{"key own shelter", (&models.APIKey{Scope: models.KeyScopeShelters, Shelters: []xid.ID{own}}).User(), own, true},
+		{"key other shelter", (&models.APIKey{Scope: models.KeyScopeShelters, Shelters: []xid.ID{own}}).User(), other, false},
+		{"adopter assigned shelter", &models.User{Role: models.RoleAdopter, Shelters: []xid.ID{own}}, own, false},
+		{"foster", &models.User{Role: models.RoleFoster}, own, false},
+		{"anonymous", nil, own, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Manages(tt.user, tt.shelter); got != tt.want {
+				t.Errorf("Manages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	tests := []struct {
+		name string
+		user *models.User
+		want string
+	}{
+		{"anonymous", nil, models.RoleAnonymous},
+		{"without role", &models.User{}, models.RoleAdopter},
+		{"staff", &models.User{Role: models.RoleShelterStaff}, models.RoleShelterStaff},
+		{"api key", (&models.APIKey{Scope: models.KeyScopeRead}).User(), models.RoleIntegration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoleOf(tt.user); got != tt.want {
+				t.Errorf("RoleOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	admin := &models.User{Role: models.RoleAdmin}
+	staff := &models.User{Role: models.RoleShelterStaff}
+	foster := &models.User{Role: models.RoleFoster}
+	adopter := &models.User{}
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		action Action
+		want   bool
+	}{
+		{"anonymous read cat", nil, ReadCat, true},
+		{"anonymous create cat", nil, CreateCat, false},
+		{"anonymous submit application", nil, SubmitApplication, false},
+		{"adopter submit application", adopter, SubmitApplication, true},
+		{"adopter update cat", adopter, UpdateCat, false},
+		{"foster review application", foster, ReviewApplication, false},
+		{"staff create cat", staff, CreateCat, true},
+		{"staff upload image", staff, UploadImage, true},
+		{"staff create shelter", staff, CreateShelter, false},
+		{"staff manage users", staff, ManageUsers, false},
+		{"admin manage users", admin, ManageUsers, true},
+		{"admin delete shelter", admin, DeleteShelter, true},
+		{"unknown role", &models.User{Role: "owner"}, ReadCat, false},
+		{"unknown action", admin, Action("cat:sell"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allowed(tt.user, tt.action); got != tt.want {
+				t.Errorf("Allowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyAllowed(t *testing.T) {
+	read := &models.APIKey{Scope: models.KeyScopeRead}
+	shelters := &models.APIKey{Scope: models.KeyScopeShelters}
+
+	tests := []struct {
+		name   string
+		key    *models.APIKey
+		action Action
+		want   bool
+	}{
+		{"read scope read cat", read, ReadCat, true},
+		{"read scope update cat", read, UpdateCat, false},
+		{"shelters scope update cat", shelters, UpdateCat, true},
+		{"shelters scope upload image", shelters, UploadImage, true},
+		{"shelters scope review application", shelters, ReviewApplication, false},
+		{"shelters scope manage keys", shelters, ManageKeys, false},
+		{"unknown scope", &models.APIKey{Scope: "admin"}, ReadCat, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeyAllowed(tt.key, tt.action); got != tt.want {
+				t.Errorf("KeyAllowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiresTwoFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *models.User
+		required bool
+		want     bool
+	}{
+		{"admin", &models.User{Role: models.RoleAdmin}, true, true},
+		{"staff", &models.User{Role: models.RoleShelterStaff}, true, true},
+		{"staff not required", &models.User{Role: models.RoleShelterStaff}, false, false},
+		{"adopter", &models.User{}, true, false},
+		{"foster", &models.User{Role: models.RoleFoster}, true, false},
+		{"anonymous", nil, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiresTwoFactor(tt.user, tt.required); got != tt.want {
+				t.Errorf("RequiresTwoFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// ======================
+// This package is package to send error response to client as
+// problem details (RFC 7807) with "application/problem+json" content type.
+// Every controller and middleware should use function from this package
+// instead of writing plain text error, so client always get the same shape:
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Not Found",
+//	  "status": 404,
+//	  "detail": "cat not found",
+//	  "request_id": "host/abc-000001"
+//	}
+//
+// ======================
+
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Content type of problem response
+const ContentType = "application/problem+json"
+
+// Type of validation problem
+const TypeValidation = "/problems/validation"
+
+// FieldError type store one invalid field of requested body or query
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Problem type store the problem details that would be sent to client
+type Problem struct {
+	Type      string        `json:"type"`
+	Title     string        `json:"title"`
+	Status    int           `json:"status"`
+	Detail    string        `json:"detail,omitempty"`
+	Instance  string        `json:"instance,omitempty"`
+	RequestID string        `json:"request_id,omitempty"`
+	Errors    []*FieldError `json:"errors,omitempty"`
+}
+
+// Function to create new problem with given status,
+// title is taken from the status text
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Write would send the problem to client
+func (p *Problem) Write(w http.ResponseWriter, r *http.Request) {
+	p.Instance = r.URL.Path
+	p.RequestID = middleware.GetReqID(r.Context())
+
+	data, err := json.Marshal(p)
+
+	if err != nil {
+		http.Error(w, p.Detail, p.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	w.Write(data)
+}
+
+// Send 400 problem, used when request could not be read
+func BadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	New(http.StatusBadRequest, detail).Write(w, r)
+}
+
+// Send 401 problem, used when request is not authenticated
+func Unauthorized(w http.ResponseWriter, r *http.Request, detail string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	New(http.StatusUnauthorized, detail).Write(w, r)
+}
+
+// Send 403 problem, used when user is not allowed to do the request
+func Forbidden(w http.ResponseWriter, r *http.Request, detail string) {
+	New(http.StatusForbidden, detail).Write(w, r)
+}
+
+// Send 404 problem, used when requested resource is not exist
+func NotFound(w http.ResponseWriter, r *http.Request, detail string) {
+	New(http.StatusNotFound, detail).Write(w, r)
+}
+
+// Send 422 problem with the invalid fields
+func Invalid(w http.ResponseWriter, r *http.Request, detail string, errs []*FieldError) {
+	p := New(http.StatusUnprocessableEntity, detail)
+	p.Type = TypeValidation
+	p.Errors = errs
+	p.Write(w, r)
+}
+
+// Send 500 problem, used when storage is failing
+func Internal(w http.ResponseWriter, r *http.Request, detail string) {
+	New(http.StatusInternalServerError, detail).Write(w, r)
+}
+
+// Send problem based on storage error, missing file
+// would be 404 and other error would be 500
+func Storage(w http.ResponseWriter, r *http.Request, err error, notFound, detail string) {
+	if os.IsNotExist(err) {
+		NotFound(w, r, notFound)
+		return
+	}
+
+	Internal(w, r, detail)
+}
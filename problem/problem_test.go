@@ -0,0 +1,69 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		send       func(w http.ResponseWriter, r *http.Request)
+		wantStatus int
+		wantType   string
+		wantErrors int
+	}{
+		{"bad request", func(w http.ResponseWriter, r *http.Request) { BadRequest(w, r, "bad") }, http.StatusBadRequest, "about:blank", 0},
+		{"unauthorized", func(w http.ResponseWriter, r *http.Request) { Unauthorized(w, r, "login") }, http.StatusUnauthorized, "about:blank", 0},
+		{"invalid", func(w http.ResponseWriter, r *http.Request) {
+			Invalid(w, r, "invalid", []*FieldError{{"name", "is required"}, {"gender", "is invalid"}})
+		}, http.StatusUnprocessableEntity, TypeValidation, 2},
+		{"storage missing", func(w http.ResponseWriter, r *http.Request) {
+			Storage(w, r, &os.PathError{Op: "open", Path: "cat.json", Err: os.ErrNotExist}, "cat not found", "error reading cat")
+		}, http.StatusNotFound, "about:blank", 0},
+		{"storage failing", func(w http.ResponseWriter, r *http.Request) {
+			Storage(w, r, errors.New("disk failure"), "cat not found", "error reading cat")
+		}, http.StatusInternalServerError, "about:blank", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/cats/1", nil)
+
+			middleware.RequestID(http.HandlerFunc(tt.send)).ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != ContentType {
+				t.Errorf("Content-Type = %q, want %q", got, ContentType)
+			}
+
+			var p Problem
+
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+
+			if p.Status != tt.wantStatus || p.Title != http.StatusText(tt.wantStatus) || p.Type != tt.wantType {
+				t.Errorf("problem = %d %q %q, want %d %q %q", p.Status, p.Title, p.Type, tt.wantStatus, http.StatusText(tt.wantStatus), tt.wantType)
+			}
+
+			if p.Instance != "/api/cats/1" || p.RequestID == "" {
+				t.Errorf("instance = %q, request id = %q, want path and request id", p.Instance, p.RequestID)
+			}
+
+			if len(p.Errors) != tt.wantErrors {
+				t.Errorf("errors = %d, want %d", len(p.Errors), tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/ArkjuniorK/store_app/auth"
+	"github.com/ArkjuniorK/store_app/tenant"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Command to change role of registered user, used to create the
+// first admin since role could only be changed by admin on api,
+// usage: store_app role [-tenant id] -email user@example.com -role admin
+func role(args []string) {
+	fs := flag.NewFlagSet("role", flag.ExitOnError)
+	email := fs.String("email", "", "email of registered user")
+	name := fs.String("role", "", "role to be given, one of admin, shelter_staff, foster or adopter")
+	id := fs.String("tenant", tenant.DefaultID, "tenant of the user")
+	fs.Parse(args)
+
+	t, err := tenant.Get(*id)
+
+	if err != nil {
+		log.Fatalf("role: %v", err)
+	}
+
+	// validate the role the same as role endpoint
+	body := struct {
+		Role string `json:"role" validate:"required,enum=role"`
+	}{*name}
+
+	if errs := validation.Struct(body); len(errs) != 0 {
+		log.Fatalf("role: invalid role %q", *name)
+	}
+
+	user, err := auth.FindUser(t, *email)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if user == nil {
+		log.Fatalf("role: user %q is not registered", *email)
+	}
+
+	user.Role = *name
+
+	if err = auth.UpdateUser(t, user); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%s is now %s\n", user.Email, user.Role)
+}
@@ -0,0 +1,249 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Node is node of parsed query, each node
+// report whether the cat is matched
+type Node interface {
+	Match(cat *models.Cat) bool
+	String() string
+}
+
+// Mode of text matching
+type Mode int
+
+const (
+	Exact     Mode = iota // whole value, case insensitive
+	Substring             // value is part of the text
+	Prefix                // text is started with value, ex: "tom*"
+	Fuzzy                 // value is close to one of word in text, ex: "tom~"
+)
+
+// All match every cat, used for empty query
+type All struct{}
+
+func (All) Match(cat *models.Cat) bool { return true }
+func (All) String() string             { return "*" }
+
+// And match when both node is matched
+type And struct{ Left, Right Node }
+
+func (n *And) Match(cat *models.Cat) bool { return n.Left.Match(cat) && n.Right.Match(cat) }
+func (n *And) String() string             { return "(" + n.Left.String() + " AND " + n.Right.String() + ")" }
+
+// Or match when one of node is matched
+type Or struct{ Left, Right Node }
+
+func (n *Or) Match(cat *models.Cat) bool { return n.Left.Match(cat) || n.Right.Match(cat) }
+func (n *Or) String() string             { return "(" + n.Left.String() + " OR " + n.Right.String() + ")" }
+
+// Not match when node is not matched
+type Not struct{ Node Node }
+
+func (n *Not) Match(cat *models.Cat) bool { return !n.Node.Match(cat) }
+func (n *Not) String() string             { return "NOT " + n.Node.String() }
+
+// TextValue is one value of Text node, each value has its own
+// mode so "tom*,felix" only match "tom" as prefix
+type TextValue struct {
+	Value string // lower cased
+	Mode  Mode
+}
+
+// Text match text field with one of the values
+type Text struct {
+	Field  string
+	Values []TextValue
+}
+
+// Create text node that match all values with the same mode
+func newTextNode(field string, mode Mode, values ...string) *Text {
+	node := &Text{Field: field}
+
+	for _, v := range values {
+		node.Values = append(node.Values, TextValue{v, mode})
+	}
+
+	return node
+}
+
+func (n *Text) Match(cat *models.Cat) bool {
+	text := strings.ToLower(Fields[n.Field].Text(cat))
+
+	for _, v := range n.Values {
+		if matchText(text, v.Value, v.Mode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (n *Text) String() string {
+	var (
+		suffix = map[Mode]string{Exact: "=", Substring: ":", Prefix: ":*", Fuzzy: ":~"}
+		values = make([]string, len(n.Values))
+		same   = true
+	)
+
+	for i, v := range n.Values {
+		values[i] = v.Value
+		same = same && v.Mode == n.Values[0].Mode
+	}
+
+	if len(n.Values) == 0 || same {
+		mode := Exact
+		if len(n.Values) != 0 {
+			mode = n.Values[0].Mode
+		}
+
+		return n.Field + suffix[mode] + strconv.Quote(strings.Join(values, ","))
+	}
+
+	// values with different mode is written as OR
+	for i, v := range n.Values {
+		values[i] = n.Field + suffix[v.Mode] + strconv.Quote(v.Value)
+	}
+
+	return "(" + strings.Join(values, " OR ") + ")"
+}
+
+// Number match number field using comparison operator,
+// equal operator match one of the values
+type Number struct {
+	Field  string
+	Op     string // "=", ">", ">=", "<", "<="
+	Values []float64
+}
+
+func (n *Number) Match(cat *models.Cat) bool {
+	value := Fields[n.Field].Number(cat)
+
+	switch n.Op {
+	case ">":
+		return value > n.Values[0]
+	case ">=":
+		return value >= n.Values[0]
+	case "<":
+		return value < n.Values[0]
+	case "<=":
+		return value <= n.Values[0]
+	}
+
+	for _, v := range n.Values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (n *Number) String() string {
+	values := make([]string, len(n.Values))
+	for i, v := range n.Values {
+		values[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return n.Field + n.Op + strings.Join(values, ",")
+}
+
+// Check if text is matched with value based on mode
+func matchText(text, value string, mode Mode) bool {
+	switch mode {
+	case Substring:
+		return strings.Contains(text, value)
+	case Prefix:
+		if strings.HasPrefix(text, value) {
+			return true
+		}
+
+		// prefix of any word, so "coon*" match "maine coon"
+		for _, word := range strings.Fields(text) {
+			if strings.HasPrefix(word, value) {
+				return true
+			}
+		}
+
+		return false
+	case Fuzzy:
+		// longer word could have more typo
+		tolerance := 1
+		if utf8.RuneCountInString(value) > 5 {
+			tolerance = 2
+		}
+
+		if distance(text, value) <= tolerance {
+			return true
+		}
+
+		for _, word := range strings.Fields(text) {
+			if distance(word, value) <= tolerance {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return text == value
+}
+
+// Levenshtein distance between two string
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// Minimum of integers
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// Function to filter cats using node
+func Filter(cats models.Cats, node Node) models.Cats {
+	var filtered models.Cats
+
+	for _, v := range cats {
+		if node.Match(v) {
+			filtered = append(filtered, v)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,173 @@
+package search
+
+import (
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Bucket type store range of number facet, Max is nil for open range
+type Bucket struct {
+	Label string
+	Min   int
+	Max   *int
+}
+
+// FacetValue type store count of one value of facet
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	Min   *int   `json:"min,omitempty"`
+	Max   *int   `json:"max,omitempty"`
+}
+
+// Facet type store how cats is grouped, Param is the filter
+// parameter of the same field that would be ignored when counting
+// so other value of selected filter is still counted
+type Facet struct {
+	Param   string
+	Buckets []Bucket
+	Value   func(cat *models.Cat) string
+
+	// value of Param that disable the filter, used when
+	// missing parameter is filtered by default value
+	Unset string
+}
+
+// Create pointer of number
+func ptr(n int) *int {
+	return &n
+}
+
+// Age buckets in year
+var AgeBuckets = []Bucket{
+	{"0", 0, ptr(0)},
+	{"1-2", 1, ptr(2)},
+	{"3-6", 3, ptr(6)},
+	{"7-10", 7, ptr(10)},
+	{"11+", 11, nil},
+}
+
+// Facets that could be counted, keyed by its name
+var Facets = map[string]*Facet{
+	"variety": {
+		Param: "variety",
+		Value: func(cat *models.Cat) string { return cat.Variety },
+	},
+	"gender": {
+		Param: "gender",
+		Value: func(cat *models.Cat) string { return cat.Gender },
+	},
+	"age": {
+		Param:   "age",
+		Buckets: AgeBuckets,
+		Value: func(cat *models.Cat) string {
+			age := cat.Birth.AgeAt(time.Now()).Years
+
+			for _, b := range AgeBuckets {
+				if age >= b.Min && (b.Max == nil || age <= *b.Max) {
+					return b.Label
+				}
+			}
+
+			return ""
+		},
+	},
+	"shelter": {
+		Param: "shelter_id",
+		Value: func(cat *models.Cat) string {
+			if cat.Shelter.IsNil() {
+				return ""
+			}
+
+			return cat.Shelter.String()
+		},
+	},
+	"status": {
+		Param: "status",
+		Value: func(cat *models.Cat) string { return cat.Status },
+		Unset: AnyStatus,
+	},
+	"life_stage": {
+		Param: "life_stage",
+		Value: func(cat *models.Cat) string { return cat.Birth.AgeAt(time.Now()).Stage() },
+	},
+	"zip_code": {
+		Param: "zip_code",
+		Value: func(cat *models.Cat) string { return cat.Address.PostalCode },
+	},
+	"country": {
+		Param: "country",
+		Value: func(cat *models.Cat) string { return cat.Address.Country },
+	},
+}
+
+// Function to count cats by facet value, cats is filtered by query
+// without the facet own parameter so the UI could show count of
+// other option of the same filter
+func Count(cats models.Cats, query url.Values) (map[string][]*FacetValue, error) {
+	result := make(map[string][]*FacetValue, len(Facets))
+
+	for name, facet := range Facets {
+		// copy query without the facet parameter
+		q := make(url.Values, len(query))
+		for k, v := range query {
+			if k != facet.Param {
+				q[k] = v
+			}
+		}
+
+		if facet.Unset != "" {
+			q.Set(facet.Param, facet.Unset)
+		}
+
+		node, err := FromQuery(q)
+
+		if err != nil {
+			return nil, err
+		}
+
+		counts := make(map[string]int)
+		for _, cat := range Filter(cats, node) {
+			if v := facet.Value(cat); v != "" {
+				counts[v]++
+			}
+		}
+
+		values := make([]*FacetValue, 0, len(counts))
+
+		// bucket is kept in its order including the empty one
+		if facet.Buckets != nil {
+			for _, b := range facet.Buckets {
+				values = append(values, &FacetValue{
+					Value: b.Label,
+					Count: counts[b.Label],
+					Min:   ptr(b.Min),
+					Max:   b.Max,
+				})
+			}
+
+			result[name] = values
+			continue
+		}
+
+		for v, n := range counts {
+			values = append(values, &FacetValue{Value: v, Count: n})
+		}
+
+		// most common value first
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+
+			return values[i].Value < values[j].Value
+		})
+
+		result[name] = values
+	}
+
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Get count of facet value, -1 when the value is not counted
+func facetCount(values []*FacetValue, value string) int {
+	for _, v := range values {
+		if v.Value == value {
+			return v.Count
+		}
+	}
+
+	return -1
+}
+
+func TestCount(t *testing.T) {
+	var (
+		tom   = newCat("Tom", "Persian", "male", 0)
+		felix = newCat("Felix", "Siamese", "male", 5)
+		bella = newCat("Bella", "Persian", "female", 12)
+		oscar = newCat("Oscar", "Persian", "male", 2)
+		cats  = models.Cats{tom, felix, bella, oscar}
+	)
+
+	oscar.Status = models.Adopted
+
+	tests := []struct {
+		query string
+		facet string
+		value string
+		want  int
+	}{
+		// adopted cat is not counted by default
+		{"", "variety", "Persian", 2},
+		{"", "variety", "Siamese", 1},
+		{"", "gender", "male", 2},
+		// own filter is ignored so other option is still counted
+		{"variety=siamese", "variety", "Persian", 2},
+		{"variety=siamese", "gender", "female", -1},
+		{"variety=siamese", "gender", "male", 1},
+		// status facet count every status
+		{"", "status", models.Adopted, 1},
+		{"status=adopted", "status", models.Available, 3},
+		{"status=adopted", "variety", "Persian", 1},
+		// every age bucket is kept even when it's empty
+		{"", "age", "0", 1},
+		{"", "age", "3-6", 1},
+		{"", "age", "7-10", 0},
+		{"", "age", "11+", 1},
+		{"", "life_stage", models.Senior, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.facet+"/"+tt.value, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result, err := Count(cats, query)
+
+			if err != nil {
+				t.Fatalf("Count() error = %v", err)
+			}
+
+			if got := facetCount(result[tt.facet], tt.value); got != tt.want {
+				t.Errorf("Count() %s %q = %d, want %d", tt.facet, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountOrder(t *testing.T) {
+	cats := models.Cats{
+		newCat("a", "Siamese", "male", 1),
+		newCat("b", "Persian", "male", 1),
+		newCat("c", "Persian", "male", 1),
+		newCat("d", "Bengal", "male", 1),
+	}
+
+	result, err := Count(cats, url.Values{})
+
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+
+	// most common first then by value
+	var got []string
+	for _, v := range result["variety"] {
+		got = append(got, v.Value)
+	}
+
+	if want := []string{"Persian", "Bengal", "Siamese"}; !equalStrings(got, want) {
+		t.Errorf("Count() variety = %v, want %v", got, want)
+	}
+}
+
+func TestCountInvalidQuery(t *testing.T) {
+	if _, err := Count(nil, url.Values{"age": {"NaN"}}); err == nil {
+		t.Error("Count() error = nil, want error")
+	}
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/geo"
+	"github.com/ArkjuniorK/store_app/models"
+	"github.com/ArkjuniorK/store_app/validation"
+)
+
+// Kind of field
+type Kind int
+
+const (
+	KindText Kind = iota
+	KindNumber
+)
+
+// Field type store how field of cat could be searched
+type Field struct {
+	Kind Kind
+
+	// default mode of ":" operator for text field
+	Mode Mode
+
+	// optional normalization of lower cased value
+	// of text field before it's matched
+	Normalize func(value string) string
+
+	// optional enum registered in validation package,
+	// exact value of the field must be one of it
+	Enum string
+
+	Text   func(cat *models.Cat) string
+	Number func(cat *models.Cat) float64
+}
+
+// Fields that could be used in query, keyed by its name
+var Fields = map[string]*Field{
+	"name": {
+		Kind: KindText,
+		Mode: Substring,
+		Text: func(cat *models.Cat) string { return cat.Name },
+	},
+	"variety": {
+		Kind: KindText,
+		Mode: Exact,
+		Text: func(cat *models.Cat) string { return cat.Variety },
+	},
+	"gender": {
+		Kind: KindText,
+		Mode: Exact,
+		Text: func(cat *models.Cat) string { return cat.Gender },
+	},
+	"address": {
+		Kind: KindText,
+		Mode: Substring,
+		Text: func(cat *models.Cat) string { return cat.Address.String() },
+	},
+	"city": {
+		Kind: KindText,
+		Mode: Exact,
+		Text: func(cat *models.Cat) string { return cat.Address.City },
+	},
+	"country": {
+		Kind: KindText,
+		Mode: Exact,
+		Text: func(cat *models.Cat) string { return cat.Address.Country },
+	},
+	"status": {
+		Kind: KindText,
+		Mode: Exact,
+		Enum: "status",
+		Text: func(cat *models.Cat) string { return cat.Status },
+	},
+	// age is computed from birthdate when it's matched
+	"age": {
+		Kind:   KindNumber,
+		Number: func(cat *models.Cat) float64 { return float64(cat.Birth.AgeAt(time.Now()).Years) },
+	},
+	"age_months": {
+		Kind:   KindNumber,
+		Number: func(cat *models.Cat) float64 { return float64(cat.Birth.AgeAt(time.Now()).Months) },
+	},
+	"life_stage": {
+		Kind: KindText,
+		Mode: Exact,
+		Enum: "life_stage",
+		Text: func(cat *models.Cat) string { return cat.Birth.AgeAt(time.Now()).Stage() },
+	},
+	"shelter_id": {
+		Kind: KindText,
+		Mode: Exact,
+		Text: func(cat *models.Cat) string { return cat.Shelter.String() },
+	},
+	"zip_code": {
+		Kind:      KindText,
+		Mode:      Exact,
+		Text:      func(cat *models.Cat) string { return geo.Compact(cat.Address.PostalCode) },
+		Normalize: compactValue,
+	},
+}
+
+// Check if lower cased value is allowed by enum of the field,
+// it return allowed values when it's not
+func (f *Field) allowed(value string) ([]string, bool) {
+	if f.Enum == "" {
+		return nil, true
+	}
+
+	values := validation.Enum(f.Enum)
+
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return nil, true
+		}
+	}
+
+	return values, false
+}
+
+// Compact postal code value so "sw1a 1aa" match "SW1A 1AA"
+func compactValue(value string) string {
+	return strings.ToLower(geo.Compact(value))
+}
@@ -0,0 +1,92 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/ArkjuniorK/store_app/geo"
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Default and maximum radius of near search in km
+const (
+	DefaultRadius = 25.0
+	MaxRadius     = 500.0
+)
+
+// Near match cat within radius km from origin
+type Near struct {
+	Origin *geo.Point
+	Radius float64
+}
+
+func (n *Near) Match(cat *models.Cat) bool {
+	loc := Locate(cat)
+	return loc != nil && geo.Distance(n.Origin, loc) <= n.Radius
+}
+
+func (n *Near) String() string {
+	return fmt.Sprintf("near(%g,%g,%gkm)", n.Origin.Lat, n.Origin.Lon, n.Radius)
+}
+
+// Function to get location of cat, cat that is written
+// before geocoding is geocoded from its postal code
+func Locate(cat *models.Cat) *geo.Point {
+	if cat.Location != nil {
+		return cat.Location
+	}
+
+	if p, ok := geo.Lookup(cat.Address.Country, cat.Address.PostalCode); ok {
+		return p
+	}
+
+	return nil
+}
+
+// Function to get origin of near search from "country" and "near"
+// parameter, nil is returned when near is not present
+func Origin(country, near string) (*geo.Point, error) {
+	if near == "" {
+		return nil, nil
+	}
+
+	p, ok := geo.Lookup(country, near)
+
+	if !ok {
+		return nil, &ParamError{"near", fmt.Sprintf("unknown postal code %q", near)}
+	}
+
+	return p, nil
+}
+
+// Create near node from "country", "near" and "radius_km" parameter
+func newNear(country, near, radius string) (Node, error) {
+	origin, err := Origin(country, near)
+
+	if err != nil {
+		return nil, err
+	}
+
+	node := &Near{Origin: origin, Radius: DefaultRadius}
+
+	if radius != "" {
+		r, ok := parseNumber(radius)
+
+		if !ok || r <= 0 || r > MaxRadius {
+			return nil, &ParamError{"radius_km", fmt.Sprintf("must be between 0 and %g", MaxRadius)}
+		}
+
+		node.Radius = r
+	}
+
+	return node, nil
+}
+
+// Function to set distance from origin of each cat
+func SetDistance(cats models.Cats, origin *geo.Point) {
+	for _, cat := range cats {
+		if loc := Locate(cat); loc != nil {
+			d := geo.Distance(origin, loc)
+			cat.Distance = &d
+		}
+	}
+}
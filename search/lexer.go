@@ -0,0 +1,161 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// kind of token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenComma
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+// token type store one token of query
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int // 1 based position in query
+}
+
+// SyntaxError type store the error of parsing query
+// so it could be sent to client as bad request
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Check if rune could be part of word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.*~-'", r)
+}
+
+// Split the query into tokens
+func lex(query string) ([]*token, error) {
+	var (
+		tokens []*token
+		runes  = []rune(query)
+	)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, &token{tokenLParen, "(", pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, &token{tokenRParen, ")", pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, &token{tokenComma, ",", pos})
+			i++
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return nil, &SyntaxError{pos, fmt.Sprintf("unexpected %q, use %q", r, string([]rune{r, r}))}
+			}
+
+			kind := tokenAnd
+			if r == '|' {
+				kind = tokenOr
+			}
+
+			tokens = append(tokens, &token{kind, string([]rune{r, r}), pos})
+			i += 2
+		case r == ':' || r == '=':
+			tokens = append(tokens, &token{tokenOp, string(r), pos})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			// operator could be followed by "="
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, &token{tokenOp, string(r) + "=", pos})
+				i += 2
+				continue
+			}
+
+			if r == '!' {
+				tokens = append(tokens, &token{tokenNot, "!", pos})
+			} else {
+				tokens = append(tokens, &token{tokenOp, string(r), pos})
+			}
+
+			i++
+		case r == '"':
+			// quoted string, backslash escape the next rune
+			var sb strings.Builder
+
+			i++
+			closed := false
+
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+
+				sb.WriteRune(runes[i])
+				i++
+			}
+
+			if !closed {
+				return nil, &SyntaxError{pos, "unterminated string"}
+			}
+
+			tokens = append(tokens, &token{tokenString, sb.String(), pos})
+		case r == '-' && (i+1 < len(runes) && (runes[i+1] == '(' || unicode.IsLetter(runes[i+1]))) &&
+			(i == 0 || !isWordRune(runes[i-1])):
+			// "-" before term is negation
+			tokens = append(tokens, &token{tokenNot, "-", pos})
+			i++
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+
+			word := string(runes[start:i])
+
+			switch word {
+			case "AND":
+				tokens = append(tokens, &token{tokenAnd, word, pos})
+			case "OR":
+				tokens = append(tokens, &token{tokenOr, word, pos})
+			case "NOT":
+				tokens = append(tokens, &token{tokenNot, word, pos})
+			default:
+				tokens = append(tokens, &token{tokenWord, word, pos})
+			}
+		default:
+			return nil, &SyntaxError{pos, fmt.Sprintf("unexpected %q", r)}
+		}
+	}
+
+	tokens = append(tokens, &token{tokenEOF, "", len(runes) + 1})
+
+	return tokens, nil
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Direction of cursor
+const (
+	Next = "next"
+	Prev = "prev"
+)
+
+// Cursor type store position in sorted cats. It keep the sortable
+// value of the cat at the position instead of the index so the
+// position is still correct when cat is added or deleted.
+// Cursor is sent to client as opaque base64 string
+type Cursor struct {
+	Sort   string    `json:"s"` // sort parameter used to create cursor
+	Dir    string    `json:"d"` // "next" or "prev"
+	ID     string    `json:"id"`
+	Create time.Time `json:"c"`
+	Update time.Time `json:"u"`
+	Birth  string    `json:"b"`
+	Name   string    `json:"n"`
+	Dist   *float64  `json:"dt,omitempty"`
+
+	// id is parsed from ID, xid could panic
+	// when it's decoded from invalid json
+	id xid.ID
+}
+
+// Function to create cursor at the position of cat,
+// next cursor point to cats after it and prev cursor
+// point to cats before it
+func NewCursor(cat *models.Cat, sort, dir string) *Cursor {
+	return &Cursor{
+		Sort:   sort,
+		Dir:    dir,
+		ID:     cat.ID.String(),
+		id:     cat.ID,
+		Create: cat.Create,
+		Update: cat.Update,
+		Birth:  cat.Birth.Date,
+		Name:   cat.Name,
+		Dist:   cat.Distance,
+	}
+}
+
+// Encode would return the cursor as opaque string
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Function to decode cursor from opaque string
+func DecodeCursor(value string) (*Cursor, error) {
+	var c Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, &ParamError{"cursor", "invalid cursor"}
+	}
+
+	if err = json.Unmarshal(data, &c); err != nil || (c.Dir != Next && c.Dir != Prev) {
+		return nil, &ParamError{"cursor", "invalid cursor"}
+	}
+
+	if c.id, err = xid.FromString(c.ID); err != nil {
+		return nil, &ParamError{"cursor", "invalid cursor"}
+	}
+
+	return &c, nil
+}
+
+// Get cat that has the same sortable value as cursor
+func (c *Cursor) cat() *models.Cat {
+	return &models.Cat{
+		ID:       c.id,
+		Create:   c.Create,
+		Update:   c.Update,
+		Birth:    models.Birthdate{Date: c.Birth},
+		Name:     c.Name,
+		Distance: c.Dist,
+	}
+}
+
+// Function to find the range of cats for cursor, cats must be
+// sorted by the same keys as the cursor was created.
+// Next cursor return up to size cats after the cursor and
+// prev cursor return up to size cats before the cursor
+func Seek(cats models.Cats, keys []SortKey, cursor *Cursor, size int) (start, end int) {
+	var (
+		less = Less(keys)
+		at   = cursor.cat()
+	)
+
+	if cursor.Dir == Prev {
+		// first cat that is not before the cursor
+		end = sort.Search(len(cats), func(i int) bool {
+			return !less(cats[i], at)
+		})
+
+		start = end - size
+		if start < 0 {
+			start = 0
+		}
+
+		return start, end
+	}
+
+	// first cat that is after the cursor
+	start = sort.Search(len(cats), func(i int) bool {
+		return less(at, cats[i])
+	})
+
+	end = start + size
+	if end > len(cats) {
+		end = len(cats)
+	}
+
+	return start, end
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+func TestCursorEncode(t *testing.T) {
+	var (
+		dist = 2.5
+		cat  = newCat("Tom", "Persian", "male", 3)
+	)
+
+	cat.Create = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cat.Distance = &dist
+
+	cursor, err := DecodeCursor(NewCursor(cat, "-age", Next).Encode())
+
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+
+	if cursor.id != cat.ID || cursor.Name != "Tom" || cursor.Sort != "-age" || cursor.Dir != Next ||
+		!cursor.Create.Equal(cat.Create) || cursor.Birth != cat.Birth.Date || *cursor.Dist != dist {
+		t.Errorf("DecodeCursor() = %+v", cursor)
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"not base64!",
+		"bm90IGpzb24",    // "not json"
+		"eyJkIjoidXAifQ", // {"d":"up"}
+		"eyJpZCI6MX0",    // {"id":1}
+	}
+
+	for _, v := range tests {
+		var e *ParamError
+
+		if _, err := DecodeCursor(v); !errors.As(err, &e) || e.Param != "cursor" {
+			t.Errorf("DecodeCursor(%q) error = %v, want cursor ParamError", v, err)
+		}
+	}
+}
+
+func TestSeek(t *testing.T) {
+	var (
+		keys = []SortKey{{"name", false}}
+		cats = models.Cats{
+			newCat("a", "Persian", "male", 1),
+			newCat("b", "Persian", "male", 1),
+			newCat("c", "Persian", "male", 1),
+			newCat("d", "Persian", "male", 1),
+			newCat("e", "Persian", "male", 1),
+		}
+	)
+
+	tests := []struct {
+		name       string
+		at         int
+		dir        string
+		size       int
+		start, end int
+	}{
+		{"next from first", 0, Next, 2, 1, 3},
+		{"next near the end", 3, Next, 2, 4, 5},
+		{"next from last", 4, Next, 2, 5, 5},
+		{"prev from last", 4, Prev, 2, 2, 4},
+		{"prev near the start", 1, Prev, 2, 0, 1},
+		{"prev from first", 0, Prev, 2, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := Seek(cats, keys, NewCursor(cats[tt.at], "name", tt.dir), tt.size)
+
+			if start != tt.start || end != tt.end {
+				t.Errorf("Seek() = %d, %d, want %d, %d", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestSeekDeletedCat(t *testing.T) {
+	var (
+		keys = []SortKey{{"name", false}}
+		a    = newCat("a", "Persian", "male", 1)
+		b    = newCat("b", "Persian", "male", 1)
+		c    = newCat("c", "Persian", "male", 1)
+	)
+
+	// cursor is still valid when its cat is deleted
+	cursor := NewCursor(b, "name", Next)
+	start, end := Seek(models.Cats{a, c}, keys, cursor, 10)
+
+	if start != 1 || end != 2 {
+		t.Errorf("Seek() = %d, %d, want 1, 2", start, end)
+	}
+}
@@ -0,0 +1,326 @@
+// ======================
+// This package is package to search cats using query language.
+// Query is parsed into tree of Node that could be matched to cat.
+//
+// Syntax:
+// - field:value        match field, name is matched by substring and
+//                      other text field is matched as whole value
+// - field=value        match whole value
+// - field!=value       does not match whole value
+// - field:a,b          match one of the values
+// - field:val*         match value as prefix
+// - field:val~         fuzzy match, allowing small typo
+// - age>=2, age<5      compare number field
+// - a AND b, a b       both is matched (also "&&")
+// - a OR b             one of them is matched (also "||")
+// - NOT a, -a, !a      negation
+// - ( ... )            grouping
+// - tom                bare word is searched on name
+//
+// Text is matched case insensitively. Value with space could be
+// quoted, ex: variety:"Maine Coon"
+// ======================
+
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Maximum length and nesting depth of query
+// so slow query could not be sent
+const (
+	MaxLength = 500
+	MaxDepth  = 32
+)
+
+// parser type store state of parsing
+type parser struct {
+	tokens []*token
+	pos    int
+	depth  int
+}
+
+// Function to parse query into node,
+// empty query would match all cats
+func Parse(query string) (Node, error) {
+	if len(query) > MaxLength {
+		return nil, &SyntaxError{MaxLength, fmt.Sprintf("query is longer than %d characters", MaxLength)}
+	}
+
+	tokens, err := lex(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	if p.peek().kind == tokenEOF {
+		return All{}, nil
+	}
+
+	node, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.value)}
+	}
+
+	return node, nil
+}
+
+// Get the current token
+func (p *parser) peek() *token {
+	return p.tokens[p.pos]
+}
+
+// Get the current token and move to next token
+func (p *parser) next() *token {
+	t := p.tokens[p.pos]
+
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// or := and ("OR" and)*
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+
+		right, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Or{left, right}
+	}
+
+	return left, nil
+}
+
+// and := unary (["AND"] unary)*
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek().kind {
+		case tokenAnd:
+			p.next()
+		case tokenWord, tokenString, tokenLParen, tokenNot:
+			// implicit AND
+		default:
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &And{left, right}
+	}
+}
+
+// unary := "NOT" unary | primary
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().kind == tokenNot {
+		t := p.next()
+
+		if p.depth++; p.depth > MaxDepth {
+			return nil, &SyntaxError{t.pos, "query is nested too deep"}
+		}
+
+		node, err := p.parseUnary()
+		p.depth--
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &Not{node}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// primary := "(" or ")" | term
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.peek()
+
+	switch t.kind {
+	case tokenLParen:
+		p.next()
+
+		if p.depth++; p.depth > MaxDepth {
+			return nil, &SyntaxError{t.pos, "query is nested too deep"}
+		}
+
+		node, err := p.parseOr()
+		p.depth--
+
+		if err != nil {
+			return nil, err
+		}
+
+		if r := p.next(); r.kind != tokenRParen {
+			return nil, &SyntaxError{r.pos, "expected \")\""}
+		}
+
+		return node, nil
+	case tokenWord, tokenString:
+		return p.parseTerm()
+	case tokenEOF:
+		return nil, &SyntaxError{t.pos, "unexpected end of query"}
+	}
+
+	return nil, &SyntaxError{t.pos, fmt.Sprintf("unexpected %q", t.value)}
+}
+
+// term := field op value ("," value)* | value
+func (p *parser) parseTerm() (Node, error) {
+	first := p.next()
+
+	// bare word is searched on name
+	if first.kind == tokenString || p.peek().kind != tokenOp {
+		return newText("name", ":", []*token{first})
+	}
+
+	name := first.value
+	if _, ok := Fields[name]; !ok {
+		return nil, &SyntaxError{first.pos, fmt.Sprintf("unknown field %q", name)}
+	}
+
+	op := p.next()
+
+	var values []*token
+
+	for {
+		v := p.next()
+
+		if v.kind != tokenWord && v.kind != tokenString {
+			return nil, &SyntaxError{v.pos, fmt.Sprintf("expected value for %q", name)}
+		}
+
+		values = append(values, v)
+
+		if p.peek().kind != tokenComma {
+			break
+		}
+
+		p.next()
+	}
+
+	if Fields[name].Kind == KindNumber {
+		return newNumber(name, op, values)
+	}
+
+	return newText(name, op.value, values)
+}
+
+// Create text node, mode of each value is taken from suffix of unquoted value
+func newText(name, op string, values []*token) (Node, error) {
+	var (
+		field = Fields[name]
+		node  = &Text{Field: name}
+		mode  = field.Mode
+	)
+
+	switch op {
+	case ":":
+	case "=", "!=":
+		mode = Exact
+	default:
+		return nil, &SyntaxError{values[0].pos - len(op), fmt.Sprintf("operator %q is not supported for %q", op, name)}
+	}
+
+	for _, v := range values {
+		var (
+			value     = strings.ToLower(v.value)
+			valueMode = mode
+		)
+
+		if v.kind == tokenWord && op == ":" {
+			switch {
+			case strings.HasSuffix(value, "*"):
+				valueMode = Prefix
+				value = strings.TrimSuffix(value, "*")
+			case strings.HasSuffix(value, "~"):
+				valueMode = Fuzzy
+				value = strings.TrimSuffix(value, "~")
+			}
+		}
+
+		if field.Normalize != nil {
+			value = field.Normalize(value)
+		}
+
+		if value == "" {
+			return nil, &SyntaxError{v.pos, fmt.Sprintf("empty value for %q", name)}
+		}
+
+		// only exact value could be checked against enum
+		if valueMode == Exact {
+			if allowed, ok := field.allowed(value); !ok {
+				return nil, &SyntaxError{v.pos, fmt.Sprintf("%q is not one of: %s", value, strings.Join(allowed, ", "))}
+			}
+		}
+
+		node.Values = append(node.Values, TextValue{value, valueMode})
+	}
+
+	if op == "!=" {
+		return &Not{node}, nil
+	}
+
+	return node, nil
+}
+
+// Create number node, comparison only accept one value
+func newNumber(name string, op *token, values []*token) (Node, error) {
+	node := &Number{Field: name, Op: op.value}
+
+	switch op.value {
+	case ":", "=", "!=":
+		node.Op = "="
+	default:
+		if len(values) > 1 {
+			return nil, &SyntaxError{op.pos, fmt.Sprintf("operator %q only accept one value", op.value)}
+		}
+	}
+
+	for _, v := range values {
+		n, ok := parseNumber(v.value)
+
+		if !ok {
+			return nil, &SyntaxError{v.pos, fmt.Sprintf("%q is not a number", v.value)}
+		}
+
+		node.Values = append(node.Values, n)
+	}
+
+	if op.value == "!=" {
+		return &Not{node}, nil
+	}
+
+	return node, nil
+}
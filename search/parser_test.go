@@ -0,0 +1,243 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/xid"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Create cat for test, birthdate is set from age in years
+func newCat(name, variety, gender string, years int) *models.Cat {
+	return &models.Cat{
+		ID:      xid.New(),
+		Name:    name,
+		Variety: variety,
+		Gender:  gender,
+		Status:  models.Available,
+		Birth:   models.Birthdate{Date: time.Now().AddDate(-years, 0, -1).Format("2006-01-02")},
+	}
+}
+
+// Get names of cats that is matched by node
+func matchNames(node Node, cats models.Cats) []string {
+	names := []string{}
+
+	for _, cat := range cats {
+		if node.Match(cat) {
+			names = append(names, cat.Name)
+		}
+	}
+
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestTextValueMode(t *testing.T) {
+	cats := models.Cats{
+		newCat("Tommy", "Persian", "male", 1),
+		newCat("Felix", "Siamese", "male", 2),
+		newCat("Felixa", "Siamese", "female", 3),
+		newCat("Atom", "Persian", "female", 4),
+	}
+
+	// mode of one value must not change how other value is matched
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"name:tom*,felix", []string{"Tommy", "Felix", "Felixa"}},
+		{"name:felix,tom*", []string{"Tommy", "Felix", "Felixa"}},
+		{"variety:pers*,siamese", []string{"Tommy", "Felix", "Felixa", "Atom"}},
+		{"variety:siamese,pers*", []string{"Tommy", "Felix", "Felixa", "Atom"}},
+		{"name:ix,tom*", []string{"Tommy", "Felix", "Felixa"}},
+		{"variety:sia,pers*", []string{"Tommy", "Atom"}},
+		{"name=felix,tom*", []string{"Felix"}},
+		{"name:felx~,atom", []string{"Felix", "Atom"}},
+		{`name:"tom*",felixa`, []string{"Felixa"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := Parse(tt.query)
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := matchNames(node, cats); !equalStrings(got, tt.want) {
+				t.Errorf("Parse(%q) matched %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "*"},
+		{"   ", "*"},
+		{"tom", `name:"tom"`},
+		{"Tom", `name:"tom"`},
+		{`"Tom Lee"`, `name:"tom lee"`},
+		{"name:tom", `name:"tom"`},
+		{"name=tom", `name="tom"`},
+		{"name!=tom", `NOT name="tom"`},
+		{"name:tom*", `name:*"tom"`},
+		{"name:tom~", `name:~"tom"`},
+		{`name:"tom*"`, `name:"tom*"`},
+		{"variety:persian", `variety="persian"`},
+		{`variety:"Maine Coon"`, `variety="maine coon"`},
+		{"variety:persian,siamese", `variety="persian,siamese"`},
+		{"name:tom*,felix", `(name:*"tom" OR name:"felix")`},
+		{"status=adopted", `status="adopted"`},
+		{"zip_code:\"sw1a 1aa\"", `zip_code="sw1a1aa"`},
+		{"tom-cat", `name:"tom-cat"`},
+		{"age>=2", "age>=2"},
+		{"age<1.5", "age<1.5"},
+		{"age:1,2", "age=1,2"},
+		{"age!=3", "NOT age=3"},
+		{"tom felix", `(name:"tom" AND name:"felix")`},
+		{"tom AND felix", `(name:"tom" AND name:"felix")`},
+		{"tom && felix", `(name:"tom" AND name:"felix")`},
+		{"tom OR felix", `(name:"tom" OR name:"felix")`},
+		{"tom || felix", `(name:"tom" OR name:"felix")`},
+		{"a OR b c", `(name:"a" OR (name:"b" AND name:"c"))`},
+		{"(a OR b) c", `((name:"a" OR name:"b") AND name:"c")`},
+		{"a OR b OR c", `((name:"a" OR name:"b") OR name:"c")`},
+		{"NOT tom", `NOT name:"tom"`},
+		{"!tom", `NOT name:"tom"`},
+		{"-tom", `NOT name:"tom"`},
+		{"-(a OR b)", `NOT (name:"a" OR name:"b")`},
+		{"NOT NOT tom", `NOT NOT name:"tom"`},
+		{"gender=female -variety:persian", `(gender="female" AND NOT variety="persian")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			node, err := Parse(tt.query)
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := node.String(); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantPos int
+		wantMsg string
+	}{
+		{"name:", 6, `expected value for "name"`},
+		{"name:tom,", 10, `expected value for "name"`},
+		{"owner:tom", 1, `unknown field "owner"`},
+		{"(tom", 5, `expected ")"`},
+		{"tom)", 4, `unexpected ")"`},
+		{"tom &felix", 5, `unexpected '&', use "&&"`},
+		{"tom | felix", 5, `unexpected '|', use "||"`},
+		{`name:"tom`, 6, "unterminated string"},
+		{"age>young", 5, `"young" is not a number`},
+		{"age>1,2", 4, `operator ">" only accept one value`},
+		{"name>tom", 5, `operator ">" is not supported for "name"`},
+		{"status=lost", 8, `"lost" is not one of: ` + strings.Join(models.Statuses, ", ")},
+		{"name:*", 6, `empty value for "name"`},
+		{"AND tom", 1, `unexpected "AND"`},
+		{"NOT", 4, "unexpected end of query"},
+		{"tom OR", 7, "unexpected end of query"},
+		{"tom $", 5, "unexpected '$'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+
+			var syntax *SyntaxError
+			if !errors.As(err, &syntax) {
+				t.Fatalf("Parse() error = %v, want SyntaxError", err)
+			}
+
+			if syntax.Pos != tt.wantPos || syntax.Msg != tt.wantMsg {
+				t.Errorf("Parse(%q) error at %d %q, want at %d %q", tt.query, syntax.Pos, syntax.Msg, tt.wantPos, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"max length", strings.Repeat("a", MaxLength), false},
+		{"too long", strings.Repeat("a", MaxLength+1), true},
+		{"max depth", strings.Repeat("(", MaxDepth) + "tom" + strings.Repeat(")", MaxDepth), false},
+		{"too deep", strings.Repeat("(", MaxDepth+1) + "tom" + strings.Repeat(")", MaxDepth+1), true},
+		{"too deep negation", strings.Repeat("!", MaxDepth+1) + "tom", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.query); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	tests := []struct {
+		text  string
+		value string
+		mode  Mode
+		want  bool
+	}{
+		{"persian", "persian", Exact, true},
+		{"persian", "pers", Exact, false},
+		{"felix", "eli", Substring, true},
+		{"felix", "tom", Substring, false},
+		{"maine coon", "mai", Prefix, true},
+		{"maine coon", "coo", Prefix, true},
+		{"maine coon", "oon", Prefix, false},
+		{"felix", "felx", Fuzzy, true},
+		{"felix", "fel", Fuzzy, false},
+		{"maine coon", "mane", Fuzzy, true},
+		{"siamese", "siameze", Fuzzy, true},
+		{"siamese", "sameze", Fuzzy, true},
+		{"siamese", "samezz", Fuzzy, false},
+		{"tom", "tim", Fuzzy, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text+"/"+tt.value, func(t *testing.T) {
+			if got := matchText(tt.text, tt.value, tt.mode); got != tt.want {
+				t.Errorf("matchText(%q, %q, %d) = %v, want %v", tt.text, tt.value, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
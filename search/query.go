@@ -0,0 +1,175 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Value of status parameter to list cat of any status
+const AnyStatus = "all"
+
+// ParamError type store error of query parameter
+type ParamError struct {
+	Param string
+	Msg   string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("error %s: %s", e.Param, e.Msg)
+}
+
+// Combine nodes with AND
+func and(left, right Node) Node {
+	if _, ok := left.(All); ok {
+		return right
+	}
+
+	return &And{left, right}
+}
+
+// Parse number of parameter, NaN and Inf is
+// rejected since it would never match anything
+func parseNumber(value string) (float64, bool) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// Split comma separated value and drop the empty one
+func split(value string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// Function to create node from url query, simple filter
+// parameter is combined with "q" parameter using AND:
+// - name=tom            name contain "tom"
+// - variety=a,b         variety is one of a or b
+// - gender=male         gender is male
+// - age=min,max         age in years between min and max
+// - life_stage=a,b      life stage is one of a or b
+// - status=a,b          status is one of a or b, default is available
+// - shelter_id=a,b      cat is owned by shelter a or b
+// - zip_code=a,b        postal code is one of a or b
+// - country=US          country of address is US, also used by near
+// - near=zip            within radius_km from zip code
+// - radius_km=n         radius of near in km, default is 25
+// - q=expression        query language, see Parse
+func FromQuery(query url.Values) (Node, error) {
+	var node Node = All{}
+
+	if v := query.Get("name"); v != "" {
+		node = and(node, newTextNode("name", Substring, strings.ToLower(v)))
+	}
+
+	for _, name := range []string{"variety", "gender", "life_stage", "shelter_id"} {
+		values := split(query.Get(name))
+
+		if len(values) == 0 {
+			continue
+		}
+
+		for i := range values {
+			values[i] = strings.ToLower(values[i])
+
+			if allowed, ok := Fields[name].allowed(values[i]); !ok {
+				return nil, &ParamError{name, fmt.Sprintf("%q is not one of: %s", values[i], strings.Join(allowed, ", "))}
+			}
+		}
+
+		node = and(node, newTextNode(name, Exact, values...))
+	}
+
+	// only available cat is listed unless status is requested
+	switch v := query.Get("status"); v {
+	case "":
+		node = and(node, newTextNode("status", Exact, models.Available))
+	case AnyStatus:
+	default:
+		values := split(v)
+
+		for i := range values {
+			if values[i] = strings.ToLower(values[i]); models.Transitions[values[i]] == nil {
+				return nil, &ParamError{"status", fmt.Sprintf("unknown status %q", values[i])}
+			}
+		}
+
+		node = and(node, newTextNode("status", Exact, values...))
+	}
+
+	if v := query.Get("age"); v != "" {
+		var bounds []float64
+
+		for _, s := range strings.Split(v, ",") {
+			n, ok := parseNumber(s)
+
+			if !ok {
+				return nil, &ParamError{"age", fmt.Sprintf("%q is not a number", s)}
+			}
+
+			bounds = append(bounds, n)
+		}
+
+		switch len(bounds) {
+		case 1:
+			node = and(node, &Number{Field: "age", Op: "=", Values: bounds})
+		case 2:
+			node = and(node, &Number{Field: "age", Op: ">=", Values: bounds[:1]})
+			node = and(node, &Number{Field: "age", Op: "<=", Values: bounds[1:]})
+		default:
+			return nil, &ParamError{"age", "expected min,max"}
+		}
+	}
+
+	if values := split(query.Get("zip_code")); len(values) != 0 {
+		for i := range values {
+			values[i] = compactValue(values[i])
+		}
+
+		node = and(node, newTextNode("zip_code", Exact, values...))
+	}
+
+	if v := query.Get("country"); v != "" {
+		node = and(node, newTextNode("country", Exact, strings.ToLower(v)))
+	}
+
+	if v := query.Get("near"); v != "" {
+		near, err := newNear(query.Get("country"), v, query.Get("radius_km"))
+
+		if err != nil {
+			return nil, err
+		}
+
+		node = and(node, near)
+	} else if query.Get("radius_km") != "" {
+		return nil, &ParamError{"radius_km", "could only be used with near"}
+	}
+
+	if v := query.Get("q"); v != "" {
+		q, err := Parse(v)
+
+		if err != nil {
+			return nil, &ParamError{"q", err.Error()}
+		}
+
+		node = and(node, q)
+	}
+
+	return node, nil
+}
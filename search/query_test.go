@@ -0,0 +1,101 @@
+package search
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+func TestMain(m *testing.M) {
+	// near search use the bundled sample of postal codes
+	os.Setenv("APP_ENV", "dev")
+	os.Exit(m.Run())
+}
+
+func TestFromQuery(t *testing.T) {
+	var (
+		tom   = newCat("Tom", "Persian", "male", 0)
+		felix = newCat("Felix", "Siamese", "male", 5)
+		bella = newCat("Bella", "Persian", "female", 12)
+		oscar = newCat("Oscar", "Persian", "male", 2)
+		cats  = models.Cats{tom, felix, bella, oscar}
+	)
+
+	oscar.Status = models.OnHold
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Tom", "Felix", "Bella"}},
+		{"status=all", []string{"Tom", "Felix", "Bella", "Oscar"}},
+		{"status=on_hold", []string{"Oscar"}},
+		{"name=el", []string{"Felix", "Bella"}},
+		{"variety=persian", []string{"Tom", "Bella"}},
+		{"gender=female,male&variety=siamese", []string{"Felix"}},
+		{"life_stage=kitten,senior", []string{"Tom", "Bella"}},
+		{"life_stage=Adult", []string{"Felix"}},
+		{"age=0,5", []string{"Tom", "Felix"}},
+		{"age=12", []string{"Bella"}},
+		{"q=age>=5 OR name:tom*", []string{"Tom", "Felix", "Bella"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			node, err := FromQuery(query)
+
+			if err != nil {
+				t.Fatalf("FromQuery() error = %v", err)
+			}
+
+			if got := matchNames(node, cats); !equalStrings(got, tt.want) {
+				t.Errorf("FromQuery(%q) matched %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromQueryInvalid(t *testing.T) {
+	tests := []struct {
+		query string
+		param string
+	}{
+		{"age=NaN", "age"},
+		{"age=1,Inf", "age"},
+		{"age=-inf,3", "age"},
+		{"age=x", "age"},
+		{"age=1,2,3", "age"},
+		{"life_stage=baby", "life_stage"},
+		{"life_stage=kitten,old", "life_stage"},
+		{"status=sold", "status"},
+		{"country=US&near=10001&radius_km=NaN", "radius_km"},
+		{"country=US&near=10001&radius_km=+Inf", "radius_km"},
+		{"country=US&near=10001&radius_km=0", "radius_km"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var e *ParamError
+
+			query, _ := url.ParseQuery(tt.query)
+
+			if _, err := FromQuery(query); !errors.As(err, &e) || e.Param != tt.param {
+				t.Errorf("FromQuery() error = %v, want %s ParamError", err, tt.param)
+			}
+		})
+	}
+}
+
+func TestParseInvalidNumberAndEnum(t *testing.T) {
+	for _, q := range []string{"age>=NaN", "age<Inf", "age=1,NaN", "life_stage:baby", "status=sold"} {
+		var e *SyntaxError
+
+		if _, err := Parse(q); !errors.As(err, &e) {
+			t.Errorf("Parse(%q) error = %v, want SyntaxError", q, err)
+		}
+	}
+}
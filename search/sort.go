@@ -0,0 +1,137 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// SortKey type store one key of sorting
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Compare function of sortable field, it return negative
+// when a is before b, positive when a is after b and zero when equal
+type compareFunc func(a, b *models.Cat) int
+
+// Sortable fields, keyed by its name
+var Sorters = map[string]compareFunc{
+	"created_at": func(a, b *models.Cat) int {
+		return compareTime(a.Create.UnixNano(), b.Create.UnixNano())
+	},
+	"updated_at": func(a, b *models.Cat) int {
+		return compareTime(a.Update.UnixNano(), b.Update.UnixNano())
+	},
+	// older cat is born earlier, so age
+	// is ordered by reversed birthdate
+	"age": func(a, b *models.Cat) int {
+		at, _ := a.Birth.Time()
+		bt, _ := b.Birth.Time()
+
+		return compareTime(bt.UnixNano(), at.UnixNano())
+	},
+	"name": func(a, b *models.Cat) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	},
+	// distance is only set on near search,
+	// cat without distance is placed last
+	"distance": func(a, b *models.Cat) int {
+		switch {
+		case a.Distance == nil && b.Distance == nil:
+			return 0
+		case a.Distance == nil:
+			return 1
+		case b.Distance == nil:
+			return -1
+		case *a.Distance < *b.Distance:
+			return -1
+		case *a.Distance > *b.Distance:
+			return 1
+		}
+
+		return 0
+	},
+}
+
+// Compare two unix time
+func compareTime(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// Function to parse sort parameter, keys is separated by comma
+// and could be prefixed by "-" or suffixed by ":desc" for descending
+// order, ex: "-created_at,name" or "age:asc,name:desc"
+func ParseSort(value string) ([]SortKey, error) {
+	var keys []SortKey
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		key := SortKey{Field: v}
+
+		switch {
+		case strings.HasPrefix(v, "-"):
+			key = SortKey{Field: v[1:], Desc: true}
+		case strings.HasPrefix(v, "+"):
+			key = SortKey{Field: v[1:]}
+		case strings.HasSuffix(v, ":desc"):
+			key = SortKey{Field: strings.TrimSuffix(v, ":desc"), Desc: true}
+		case strings.HasSuffix(v, ":asc"):
+			key = SortKey{Field: strings.TrimSuffix(v, ":asc")}
+		}
+
+		if _, ok := Sorters[key.Field]; !ok {
+			return nil, &ParamError{"sort", fmt.Sprintf("unknown sort field %q", key.Field)}
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Function to get less function of keys, cat with
+// the same keys is ordered by its id
+func Less(keys []SortKey) func(a, b *models.Cat) bool {
+	return func(a, b *models.Cat) bool {
+		for _, k := range keys {
+			c := Sorters[k.Field](a, b)
+
+			if c == 0 {
+				continue
+			}
+
+			if k.Desc {
+				return c > 0
+			}
+
+			return c < 0
+		}
+
+		return a.ID.Compare(b.ID) < 0
+	}
+}
+
+// Function to sort cats by keys, cat with the same
+// keys is ordered by its id so the order is always
+// the same across pages
+func Sort(cats models.Cats, keys []SortKey) {
+	less := Less(keys)
+
+	sort.SliceStable(cats, func(i, j int) bool {
+		return less(cats[i], cats[j])
+	})
+}
@@ -0,0 +1,118 @@
+package search
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []SortKey
+	}{
+		{"", nil},
+		{"name", []SortKey{{"name", false}}},
+		{"-created_at,name", []SortKey{{"created_at", true}, {"name", false}}},
+		{"+age", []SortKey{{"age", false}}},
+		{"age:asc,name:desc", []SortKey{{"age", false}, {"name", true}}},
+		{" name , ,-age ", []SortKey{{"name", false}, {"age", true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSort(tt.value)
+
+			if err != nil {
+				t.Fatalf("ParseSort() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseSort() = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseSort() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSortUnknown(t *testing.T) {
+	for _, v := range []string{"color", "-color", "name:up", "name,-"} {
+		var e *ParamError
+
+		if _, err := ParseSort(v); !errors.As(err, &e) || e.Param != "sort" {
+			t.Errorf("ParseSort(%q) error = %v, want sort ParamError", v, err)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	var (
+		now    = time.Now()
+		near   = 1.5
+		far    = 9.0
+		tom    = newCat("tom", "Persian", "male", 5)
+		felix  = newCat("Felix", "Persian", "male", 2)
+		bella  = newCat("Bella", "Persian", "female", 5)
+		oscar  = newCat("oscar", "Persian", "male", 9)
+		sorted = func(cats models.Cats) []string {
+			names := make([]string, len(cats))
+			for i, v := range cats {
+				names[i] = v.Name
+			}
+			return names
+		}
+	)
+
+	tom.Create, felix.Create, bella.Create, oscar.Create = now, now.Add(-time.Hour), now.Add(time.Hour), now.Add(-2*time.Hour)
+	tom.Distance, bella.Distance = &far, &near
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"name", []string{"Bella", "Felix", "oscar", "tom"}},
+		{"-name", []string{"tom", "oscar", "Felix", "Bella"}},
+		{"created_at", []string{"oscar", "Felix", "tom", "Bella"}},
+		{"-age,name", []string{"oscar", "Bella", "tom", "Felix"}},
+		{"age,-name", []string{"Felix", "tom", "Bella", "oscar"}},
+		{"distance,name", []string{"Bella", "tom", "Felix", "oscar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			keys, err := ParseSort(tt.sort)
+
+			if err != nil {
+				t.Fatalf("ParseSort() error = %v", err)
+			}
+
+			cats := models.Cats{tom, felix, bella, oscar}
+			Sort(cats, keys)
+
+			if got := sorted(cats); !equalStrings(got, tt.want) {
+				t.Errorf("Sort(%q) = %v, want %v", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortTieByID(t *testing.T) {
+	a := newCat("same", "Persian", "male", 1)
+	b := newCat("same", "Persian", "male", 1)
+
+	// xid is ordered by creation so a is before b
+	for _, cats := range []models.Cats{{a, b}, {b, a}} {
+		Sort(cats, []SortKey{{"name", false}})
+
+		if cats[0] != a {
+			t.Errorf("Sort() did not order tie by id")
+		}
+	}
+}
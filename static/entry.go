@@ -2,6 +2,8 @@
 // Entry file that would be manage the route for static file/assets.
 // This entry file would not using models and controllers, it only serving
 // static file to client without read/write data.
+// Image of each tenant is served from the tenant root, tenant is
+// resolved from host or explicitly from the path.
 // ==================
 
 package static
@@ -9,32 +11,69 @@ package static
 import (
 	"net/http"
 	"os"
-	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/middleware"
+	"github.com/ArkjuniorK/store_app/tenant"
 )
 
 // struct to hold function for serving static file
 type Entry struct{}
 
-//
+// tenantFiles type serve cat image of tenant, FileServer
+// of each tenant is created once on the first request
+type tenantFiles struct {
+	servers sync.Map // tenant id -> *FileServer
+}
+
+// Get file server of tenant
+func (f *tenantFiles) server(t *tenant.Tenant) *FileServer {
+	if v, ok := f.servers.Load(t.ID); ok {
+		return v.(*FileServer)
+	}
+
+	v, _ := f.servers.LoadOrStore(t.ID, NewFileServer(os.DirFS(t.Path("static/cats"))))
+	return v.(*FileServer)
+}
+
+// Serve image of tenant resolved by Tenant middleware
+func (f *tenantFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.server(middleware.CurrentTenant(r)).ServeHTTP(w, r)
+}
+
+// Serve image of tenant in the path
+func (f *tenantFiles) serveTenant(w http.ResponseWriter, r *http.Request) {
+	t, err := tenant.Get(chi.URLParam(r, "tenant"))
+
+	if err != nil {
+		NotFound(w, r)
+		return
+	}
+
+	f.server(t).ServeHTTP(w, r)
+}
+
+// Routes would return router for static file, each directory
+// is served by FileServer that is created once
 func (e Entry) Routes() chi.Router {
 	// init new chi router
 	r := chi.NewRouter()
 
-	// set working directory
-	workdir, _ := os.Getwd()
+	// handle static assets for "/cats" of tenant of the host
+	// and "/tenants/{tenant}/cats" that is used by image link
+	// of tenant other than default tenant
+	cats := new(tenantFiles)
+
+	r.With(middleware.Tenant).Method(http.MethodGet, "/cats/*", cats)
+	r.With(middleware.Tenant).Method(http.MethodHead, "/cats/*", cats)
+	r.Get("/"+tenant.Dir+"/{tenant}/cats/*", cats.serveTenant)
+	r.Head("/"+tenant.Dir+"/{tenant}/cats/*", cats.serveTenant)
 
-	// handle static assets for "/cats"
-	r.Get("/cats/*", func(w http.ResponseWriter, r *http.Request) {
-		filedir := http.Dir(filepath.Join(workdir, "static/cats"))
-		rctx := chi.RouteContext(r.Context())
-		pathPrefix := strings.TrimSuffix(rctx.RoutePattern(), "/*")
-		fs := http.StripPrefix(pathPrefix, http.FileServer(filedir))
-		fs.ServeHTTP(w, r)
-	})
+	// everything else is not found
+	r.NotFound(NotFound)
 
-	// return the router
+	// return the route so main file could mounted it
 	return r
 }
@@ -0,0 +1,283 @@
+// ==================
+// File server used by static entry. Unlike http.FileServer it is
+// built once, does not list directory, serve precompressed file
+// when client accept it, set strong ETag and cache policy and
+// respond not found as problem JSON so API consumer could read it.
+// File is read from fs.FS so it could serve embedded file too.
+// ==================
+
+package static
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/ArkjuniorK/store_app/problem"
+)
+
+// Cache policy for file named by content hash,
+// the content would never change so it could be cached forever
+const immutable = "public, max-age=31536000, immutable"
+
+// Cache policy for other file
+const revalidate = "public, no-cache"
+
+// filename that is content addressed (sha256 hex)
+var hashedName = regexp.MustCompile(`^[0-9a-f]{64}\.[a-z0-9]+$`)
+
+// Precompressed encoding ordered by preference,
+// file would be looked up as "{name}{ext}"
+var encodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// etag type store computed etag of file, it's only
+// valid as long as size and modtime of the file is the same
+type etag struct {
+	size    int64
+	modTime time.Time
+	value   string
+}
+
+// FileServer type serve file from file system
+type FileServer struct {
+	fsys  fs.FS
+	etags sync.Map // path -> etag
+
+	// Immutable report whether file could be cached forever,
+	// default is file named by content hash
+	Immutable func(name string) bool
+
+	// NotFound handle request for file that is not exist,
+	// default is JSON response
+	NotFound http.HandlerFunc
+}
+
+// Function to create new FileServer that serve file inside fsys
+func NewFileServer(fsys fs.FS) *FileServer {
+	return &FileServer{
+		fsys:      fsys,
+		Immutable: IsHashed,
+		NotFound:  NotFound,
+	}
+}
+
+// IsHashed report whether file is named by content hash
+func IsHashed(name string) bool {
+	return hashedName.MatchString(path.Base(name))
+}
+
+// NotFound send not found as problem JSON
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	problem.NotFound(w, r, "file not found")
+}
+
+// Check if client accept the given encoding,
+// encoding with "q=0" is refused by the client
+func accepts(r *http.Request, encoding string) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(v, ";")
+
+		if strings.TrimSpace(params[0]) != encoding {
+			continue
+		}
+
+		return quality(params[1:]) > 0
+	}
+
+	return false
+}
+
+// Get quality value of encoding parameters, it's
+// 1 when not given and 0 when it could not be parsed
+func quality(params []string) float64 {
+	for _, p := range params {
+		p = strings.TrimSpace(p)
+
+		if !strings.HasPrefix(p, "q=") && !strings.HasPrefix(p, "Q=") {
+			continue
+		}
+
+		q, err := strconv.ParseFloat(p[2:], 64)
+
+		if err != nil {
+			return 0
+		}
+
+		return q
+	}
+
+	return 1
+}
+
+// Get the strong etag of file, etag is computed from
+// file content and cached until the file change
+func (s *FileServer) etag(name string, f io.ReadSeeker, info fs.FileInfo) (string, error) {
+	if v, ok := s.etags.Load(name); ok {
+		e := v.(*etag)
+		if e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+			return e.value, nil
+		}
+	}
+
+	// content addressed file already have the hash as name
+	base := path.Base(name)
+	if hashedName.MatchString(base) {
+		value := `"` + strings.SplitN(base, ".", 2)[0] + `"`
+		s.etags.Store(name, &etag{info.Size(), info.ModTime(), value})
+		return value, nil
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	value := `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+	s.etags.Store(name, &etag{info.Size(), info.ModTime(), value})
+
+	return value, nil
+}
+
+// readSeekFile is file that could be served by http.ServeContent
+type readSeekFile interface {
+	fs.File
+	io.ReadSeeker
+}
+
+// Open file inside file system, directory is treated as not found
+// so directory listing is never served
+func (s *FileServer) open(name string) (readSeekFile, fs.FileInfo, error) {
+	f, err := s.fsys.Open(strings.TrimPrefix(name, "/"))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := f.Stat()
+
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	rs, ok := f.(readSeekFile)
+
+	if info.IsDir() || !ok {
+		f.Close()
+		return nil, nil, fs.ErrNotExist
+	}
+
+	return rs, info, nil
+}
+
+// Exists report whether file with given name could be served
+func (s *FileServer) Exists(name string) bool {
+	f, _, err := s.open(path.Clean("/" + name))
+
+	if err != nil {
+		return false
+	}
+
+	f.Close()
+	return true
+}
+
+// Serve the requested file, the path is taken from
+// wildcard param of the route
+func (s *FileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.ServeFile(w, r, chi.URLParam(r, "*"))
+}
+
+// ServeFile serve file with given name relative to file system
+func (s *FileServer) ServeFile(w http.ResponseWriter, r *http.Request, name string) {
+	// clean the path so it could not go outside root
+	name = path.Clean("/" + name)
+
+	if name == "/" || strings.HasPrefix(path.Base(name), ".") {
+		s.NotFound(w, r)
+		return
+	}
+
+	f, info, err := s.open(name)
+
+	if err != nil {
+		s.NotFound(w, r)
+		return
+	}
+
+	defer f.Close()
+
+	// response would differ based on accepted encoding
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	// serve precompressed file when it's exist
+	// and accepted by client
+	for _, enc := range encodings {
+		if !accepts(r, enc.name) {
+			continue
+		}
+
+		cf, cinfo, err := s.open(name + enc.ext)
+
+		if err != nil {
+			continue
+		}
+
+		defer cf.Close()
+
+		f, info = cf, cinfo
+		w.Header().Set("Content-Encoding", enc.name)
+		name = name + enc.ext
+		break
+	}
+
+	tag, err := s.etag(name, f, info)
+
+	if err != nil {
+		problem.Internal(w, r, "error reading file")
+		return
+	}
+
+	if enc := w.Header().Get("Content-Encoding"); enc != "" {
+		tag = fmt.Sprintf(`%s-%s"`, strings.TrimSuffix(tag, `"`), enc)
+	}
+
+	w.Header().Set("ETag", tag)
+
+	// content type is detected from the original name
+	// so precompressed file would keep it's content type
+	original := name
+	if w.Header().Get("Content-Encoding") != "" {
+		original = strings.TrimSuffix(name, path.Ext(name))
+	}
+
+	if s.Immutable(original) {
+		w.Header().Set("Cache-Control", immutable)
+	} else {
+		w.Header().Set("Cache-Control", revalidate)
+	}
+
+	// ServeContent handle range request and conditional request
+	http.ServeContent(w, r, original, info.ModTime(), f)
+}
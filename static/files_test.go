@@ -0,0 +1,178 @@
+package static
+
+import (
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// Name of content addressed file in test file system
+const hashedFile = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.jpg"
+
+// Create file server of test file system
+func testServer() *FileServer {
+	return NewFileServer(fstest.MapFS{
+		"app.js":        {Data: []byte("console.log('app')")},
+		"app.js.br":     {Data: []byte("brotli")},
+		"app.js.gz":     {Data: []byte("gzip")},
+		"style.css":     {Data: []byte("body{}")},
+		"style.css.gz":  {Data: []byte("gzip")},
+		hashedFile:      {Data: []byte("image")},
+		".env":          {Data: []byte("SECRET=1")},
+		"dir/index.txt": {Data: []byte("index")},
+	})
+}
+
+func TestServeFile(t *testing.T) {
+	s := testServer()
+
+	tests := []struct {
+		name         string
+		file         string
+		accept       string
+		wantCode     int
+		wantBody     string
+		wantEncoding string
+		typeOf       string // content type is of original extension
+		wantCache    string
+	}{
+		{"plain file", "app.js", "", http.StatusOK, "console.log('app')", "", ".js", revalidate},
+		{"prefer brotli", "app.js", "gzip, deflate, br", http.StatusOK, "brotli", "br", ".js", revalidate},
+		{"gzip only", "app.js", "gzip", http.StatusOK, "gzip", "gzip", ".js", revalidate},
+		{"quality value", "app.js", "gzip;q=1.0", http.StatusOK, "gzip", "gzip", ".js", revalidate},
+		{"refused brotli", "app.js", "br;q=0, gzip", http.StatusOK, "gzip", "gzip", ".js", revalidate},
+		{"refused brotli with space", "app.js", "gzip, br ; q=0.0", http.StatusOK, "gzip", "gzip", ".js", revalidate},
+		{"refused all", "app.js", "br;q=0, gzip;q=0", http.StatusOK, "console.log('app')", "", ".js", revalidate},
+		{"invalid quality", "app.js", "br;q=high", http.StatusOK, "console.log('app')", "", ".js", revalidate},
+		{"missing brotli variant", "style.css", "br, gzip", http.StatusOK, "gzip", "gzip", ".css", revalidate},
+		{"unknown encoding", "style.css", "deflate", http.StatusOK, "body{}", "", ".css", revalidate},
+		{"hashed file", hashedFile, "", http.StatusOK, "image", "", ".jpg", immutable},
+		{"missing file", "missing.js", "", http.StatusNotFound, "", "", "", ""},
+		{"dot file", ".env", "", http.StatusNotFound, "", "", "", ""},
+		{"directory", "dir", "", http.StatusNotFound, "", "", "", ""},
+		{"root", "", "", http.StatusNotFound, "", "", "", ""},
+		{"outside root", "../dir/index.txt", "", http.StatusOK, "index", "", ".txt", revalidate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept-Encoding", tt.accept)
+			}
+
+			w := httptest.NewRecorder()
+			s.ServeFile(w, r, tt.file)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+
+			if got, want := w.Header().Get("Content-Type"), mime.TypeByExtension(tt.typeOf); got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+
+			if got := w.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCache)
+			}
+
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	s := testServer()
+
+	get := func(file, accept, match string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Encoding", accept)
+		if match != "" {
+			r.Header.Set("If-None-Match", match)
+		}
+
+		w := httptest.NewRecorder()
+		s.ServeFile(w, r, file)
+
+		return w
+	}
+
+	plain := get("app.js", "", "").Header().Get("ETag")
+	gzip := get("app.js", "gzip", "").Header().Get("ETag")
+
+	if !strings.HasPrefix(plain, `"`) || !strings.HasSuffix(plain, `"`) || len(plain) != 34 {
+		t.Errorf("ETag = %s, want quoted 32 hex", plain)
+	}
+
+	if !strings.HasSuffix(gzip, `-gzip"`) || gzip == plain {
+		t.Errorf("ETag of gzip = %s, want tag with encoding other than %s", gzip, plain)
+	}
+
+	if got := get("app.js", "", "").Header().Get("ETag"); got != plain {
+		t.Errorf("ETag = %s, want the same %s on next request", got, plain)
+	}
+
+	if got := get(hashedFile, "", "").Header().Get("ETag"); got != `"`+hashedFile[:64]+`"` {
+		t.Errorf("ETag of hashed file = %s, want hash of the name", got)
+	}
+
+	tests := []struct {
+		name   string
+		accept string
+		match  string
+		want   int
+	}{
+		{"same tag", "", plain, http.StatusNotModified},
+		{"same encoding tag", "gzip", gzip, http.StatusNotModified},
+		{"tag of other encoding", "gzip", plain, http.StatusOK},
+		{"other tag", "", `"0"`, http.StatusOK},
+		{"any tag", "", "*", http.StatusNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := get("app.js", tt.accept, tt.match).Code; got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHashed(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{hashedFile, true},
+		{"cats/" + hashedFile, true},
+		{"app.js", false},
+		{strings.ToUpper(hashedFile[:64]) + ".jpg", false},
+		{hashedFile[:63] + ".jpg", false},
+		{hashedFile[:64], false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsHashed(tt.name); got != tt.want {
+				t.Errorf("IsHashed(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package storage
+
+import (
+	"os"
+	"syscall"
+)
+
+// Take exclusive lock of file without waiting
+func lockFile(file *os.File) error {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+
+	if err == syscall.EWOULDBLOCK {
+		return ErrLocked
+	}
+
+	return err
+}
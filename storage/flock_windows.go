@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package storage
+
+import "os"
+
+// Lock file is not supported on windows, the file is only
+// created so repair must not be run beside the server there
+func lockFile(file *os.File) error {
+	return nil
+}
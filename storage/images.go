@@ -0,0 +1,312 @@
+// ======================
+// This package is package to manage image storage for cats.
+// Image is stored inside "static/cats" and named by content hash
+// of the processed image, so the same image uploaded twice would
+// be stored once. Each image has reference count that stored inside
+// "data/refs.json" so image only deleted when no cat use it.
+// ======================
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Extension of stored image
+const Ext = ".webp"
+
+// Refs type store reference count of each image
+// keyed by image filename
+type Refs map[string]int
+
+// mutex to guard refs file since it would be
+// read and written by concurrent request
+var mu sync.Mutex
+
+// number of request that has saved the image but not yet
+// acquired or discarded it, keyed by image path. Image with
+// pending request is never deleted since it would be linked soon
+var pending = make(map[string]int)
+
+// lock of cat and shelter data that link to image, request that
+// change the data hold it shared and maintenance task hold it
+// alone so reference is never recounted while a link is changed
+var dataMu sync.RWMutex
+
+// Function to hold data lock shared until the returned
+// function is called, it's used by request that change data
+func Share() func() {
+	dataMu.RLock()
+	return dataMu.RUnlock
+}
+
+// Function to run fn while data lock is held alone,
+// no cat or shelter data would be changed until it's done
+func Exclusive(fn func() error) error {
+	dataMu.Lock()
+	defer dataMu.Unlock()
+
+	return fn()
+}
+
+// Get the path of refs file inside working directory
+func refsPath(wd string) string {
+	return filepath.Join(wd, "data/refs.json")
+}
+
+// Get the path of image inside working directory
+func imagePath(wd, filename string) string {
+	return filepath.Join(wd, "static/cats", filename)
+}
+
+// Function to read refs file, when file is not exist
+// return empty refs instead
+func ReadRefs(wd string) (Refs, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return readRefs(wd)
+}
+
+// Read refs file without locking
+func readRefs(wd string) (Refs, error) {
+	refs := make(Refs)
+
+	data, err := ioutil.ReadFile(refsPath(wd))
+
+	if os.IsNotExist(err) {
+		return refs, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &refs); err != nil {
+		return nil, err
+	}
+
+	// file could contain null
+	if refs == nil {
+		refs = make(Refs)
+	}
+
+	return refs, nil
+}
+
+// Write refs to file
+func writeRefs(wd string, refs Refs) error {
+	data, err := json.Marshal(refs)
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(refsPath(wd), data, 0644)
+}
+
+// Function to get filename of image buffer,
+// filename is sha256 hash of the buffer
+func Filename(buff []byte) string {
+	sum := sha256.Sum256(buff)
+	return hex.EncodeToString(sum[:]) + Ext
+}
+
+// Function to save image buffer to disk and return the filename.
+// Image would not be written when the same image is already exist.
+// Saved image is pending until it's acquired or discarded,
+// so each Save must be followed by one of them
+func Save(wd string, buff []byte) (string, error) {
+	filename := Filename(buff)
+	path := imagePath(wd, filename)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		pending[path]++
+		return filename, nil
+	}
+
+	// write to temporary file first then rename it
+	// so incomplete image would never be served
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buff, 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	pending[path]++
+
+	return filename, nil
+}
+
+// Remove one pending request of image, it must be called with lock
+func unpend(path string) {
+	if pending[path] > 1 {
+		pending[path]--
+		return
+	}
+
+	delete(pending, path)
+}
+
+// Function to add reference to image,
+// it should be called after link is written to cat data
+func Acquire(wd, filename string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	refs, err := readRefs(wd)
+
+	if err != nil {
+		return err
+	}
+
+	refs[filename]++
+
+	if err = writeRefs(wd, refs); err != nil {
+		return err
+	}
+
+	unpend(imagePath(wd, filename))
+
+	return nil
+}
+
+// Function to remove reference from image, image would
+// be deleted from disk when nothing else use it.
+// Return the remaining reference count
+func Release(wd, filename string) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	refs, err := readRefs(wd)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if refs[filename] > 0 {
+		refs[filename]--
+	}
+
+	count := refs[filename]
+
+	if count == 0 {
+		delete(refs, filename)
+	}
+
+	// image is kept when other request is going to acquire it
+	if count == 0 && pending[imagePath(wd, filename)] == 0 {
+		err = os.Remove(imagePath(wd, filename))
+
+		if err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+	}
+
+	return count, writeRefs(wd, refs)
+}
+
+// Function to delete image that has been saved but
+// not referenced by any cat, ex: when writing cat data failed.
+// Image is kept when other request has saved the same image
+func Discard(wd, filename string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	path := imagePath(wd, filename)
+	unpend(path)
+
+	refs, err := readRefs(wd)
+
+	if err != nil {
+		return err
+	}
+
+	if refs[filename] > 0 || pending[path] > 0 {
+		return nil
+	}
+
+	err = os.Remove(imagePath(wd, filename))
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Function to delete orphan image found by maintenance task, image
+// that has been referenced or saved again since it's checked is kept
+func RemoveOrphan(wd, filename string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	refs, err := readRefs(wd)
+
+	if err != nil {
+		return err
+	}
+
+	if refs[filename] > 0 || pending[imagePath(wd, filename)] > 0 {
+		return nil
+	}
+
+	err = os.Remove(imagePath(wd, filename))
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Function to replace all reference count, it would be used
+// by maintenance task after counting reference from cat data.
+// It must be called inside Exclusive so the count is not stale
+func Rebuild(wd string, refs Refs) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return writeRefs(wd, refs)
+}
+
+// Function to get the image filename from link url, link url is
+// formatted as "{host}/static/cats/{filename}" or for other tenant
+// "{host}/static/tenants/{tenant}/cats/{filename}"
+func FilenameFromURL(url string) string {
+	i := strings.Index(url, "/static/")
+
+	if i < 0 {
+		return ""
+	}
+
+	return filepath.Base(url[i:])
+}
+
+// Function to get all image filename used by link,
+// animated image also use the poster image
+func LinkFiles(link *models.Link) []string {
+	files := []string{FilenameFromURL(link.URL)}
+
+	if link.Poster != "" {
+		files = append(files, FilenameFromURL(link.Poster))
+	}
+
+	return files
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ArkjuniorK/store_app/models"
+)
+
+// Create working directory with image directory
+func workDir(t *testing.T) string {
+	t.Helper()
+
+	wd := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(wd, "static/cats"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(wd, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	return wd
+}
+
+// Report whether image is on disk
+func exists(wd, filename string) bool {
+	_, err := os.Stat(imagePath(wd, filename))
+	return err == nil
+}
+
+func TestImageRefs(t *testing.T) {
+	wd := workDir(t)
+	buff := []byte("image")
+
+	filename, err := Save(wd, buff)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filename != Filename(buff) {
+		t.Errorf("Save() = %q, want content hash %q", filename, Filename(buff))
+	}
+
+	// the same image uploaded for second cat
+	if _, err = Save(wd, buff); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Acquire(wd, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Acquire(wd, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := ReadRefs(wd)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refs[filename] != 2 {
+		t.Errorf("refs = %d, want 2", refs[filename])
+	}
+
+	if count, err := Release(wd, filename); err != nil || count != 1 {
+		t.Errorf("Release() = %d, %v, want 1", count, err)
+	}
+
+	if !exists(wd, filename) {
+		t.Error("image is deleted while other cat use it")
+	}
+
+	if count, err := Release(wd, filename); err != nil || count != 0 {
+		t.Errorf("Release() = %d, %v, want 0", count, err)
+	}
+
+	if exists(wd, filename) {
+		t.Error("image is kept when nothing use it")
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	tests := []struct {
+		name     string
+		acquired bool // other cat use the image
+		pending  bool // other request saved the image
+		want     bool
+	}{
+		{"unused", false, false, false},
+		{"used by other cat", true, false, true},
+		{"saved by other request", false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wd := workDir(t)
+			buff := []byte("image")
+
+			if tt.acquired {
+				filename, err := Save(wd, buff)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err = Acquire(wd, filename); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.pending {
+				if _, err := Save(wd, buff); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			filename, err := Save(wd, buff)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = Discard(wd, filename); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := exists(wd, filename); got != tt.want {
+				t.Errorf("image exists = %v, want %v", got, tt.want)
+			}
+
+			// release the other request so it's not kept pending
+			if tt.pending {
+				Discard(wd, filename)
+			}
+		})
+	}
+}
+
+func TestReleasePending(t *testing.T) {
+	wd := workDir(t)
+	buff := []byte("image")
+
+	filename, err := Save(wd, buff)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Acquire(wd, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// other request saved the same image but not yet linked it
+	if _, err = Save(wd, buff); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = Release(wd, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if !exists(wd, filename) {
+		t.Fatal("image is deleted while other request is going to acquire it")
+	}
+
+	if err = RemoveOrphan(wd, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if !exists(wd, filename) {
+		t.Error("pending image is removed as orphan")
+	}
+
+	if err = Acquire(wd, filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if refs, _ := ReadRefs(wd); refs[filename] != 1 {
+		t.Errorf("refs = %d, want 1", refs[filename])
+	}
+}
+
+func TestLinkFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		link *models.Link
+		want []string
+	}{
+		{"image", &models.Link{URL: "http://localhost:3000/static/cats/a.webp"}, []string{"a.webp"}},
+		{"tenant image", &models.Link{URL: "https://paws.org/static/tenants/paws/cats/a.webp"}, []string{"a.webp"}},
+		{"animated", &models.Link{URL: "/static/cats/a.webp", Poster: "/static/cats/b.webp"}, []string{"a.webp", "b.webp"}},
+		{"other url", &models.Link{URL: "https://example.com/a.webp"}, []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := LinkFiles(tt.link)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("LinkFiles() = %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("LinkFiles() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// How to work:
+// - Share and Exclusive only guard request and maintenance task
+//   inside the same process, pending image is also only known by it
+// - So server hold lock file of the working directory while it's
+//   running and repair command of other process must take the same
+//   lock first, it's refused when the server hold it
+// - Lock is released by the system when the process exit, so crashed
+//   server never leave stale lock behind
+
+// Path of lock file inside working directory
+const lockPath = "data/storage.lock"
+
+// Error of lock that is held by other process
+var ErrLocked = errors.New("storage is used by other process")
+
+// FileLock type store lock file of working directory held by this process
+type FileLock struct {
+	file *os.File
+}
+
+// Function to lock storage of working directory for this process,
+// ErrLocked is returned at once when other process hold it
+func LockDir(wd string) (*FileLock, error) {
+	path := filepath.Join(wd, lockPath)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &FileLock{file}, nil
+}
+
+// Unlock would release the lock so other process could take it
+func (l *FileLock) Unlock() error {
+	return l.file.Close()
+}
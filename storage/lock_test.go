@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockDir(t *testing.T) {
+	wd := t.TempDir()
+
+	server, err := LockDir(wd)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// repair of other process is refused while the server hold it
+	if _, err = LockDir(wd); !errors.Is(err, ErrLocked) {
+		t.Errorf("LockDir() while it's held error = %v, want %v", err, ErrLocked)
+	}
+
+	if err = server.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	repair, err := LockDir(wd)
+
+	if err != nil {
+		t.Fatalf("LockDir() after unlock error = %v, want nil", err)
+	}
+
+	repair.Unlock()
+}
@@ -0,0 +1,231 @@
+// ======================
+// This package is package to separate data of each rescue organization.
+// Each tenant has its own root directory with the same layout as the
+// working directory ("data/cats", "data/users", "static/cats", ...) so
+// cats, images, users and sessions of one tenant is never read by other
+// tenant. The default tenant use the working directory itself so data of
+// single organization setup is kept where it was.
+//
+// Other tenants is registered in "tenants.json" inside working directory
+// (or file in TENANTS_FILE env) and stored inside "tenants/{id}". Entry
+// with "default" id only change the setting of the default tenant.
+// ======================
+
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ID of tenant that use the working directory
+const DefaultID = "default"
+
+// Directory inside working directory that store other tenants
+const Dir = "tenants"
+
+// Error of unknown tenant
+var ErrNotFound = errors.New("tenant not found")
+
+// Allowed id of tenant, it's used as directory name and subdomain
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Tenant type store one rescue organization
+type Tenant struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Hosts []string `json:"hosts"` // custom domain of the tenant
+
+	// RequireTwoFactor would require admin and staff
+	// to enroll two-factor authentication
+	RequireTwoFactor bool `json:"require_two_factor"`
+
+	// Root is directory of tenant data, it's set when
+	// the tenant is loaded and never read from file
+	Root string `json:"-"`
+}
+
+// Path would join elem to root of tenant
+func (t *Tenant) Path(elem ...string) string {
+	return filepath.Join(append([]string{t.Root}, elem...)...)
+}
+
+// StaticPath is path of tenant image on static endpoint,
+// image of default tenant is served at the old path
+func (t *Tenant) StaticPath() string {
+	if t.ID == DefaultID {
+		return "/static/cats/"
+	}
+
+	return "/static/" + Dir + "/" + t.ID + "/cats/"
+}
+
+// Loaded tenants keyed by id
+var (
+	tenants map[string]*Tenant
+	mu      sync.RWMutex
+)
+
+// Get path of tenants file
+func filePath(wd string) string {
+	if v := os.Getenv("TENANTS_FILE"); v != "" {
+		return v
+	}
+
+	return filepath.Join(wd, "tenants.json")
+}
+
+// Function to load tenants of working directory wd, missing
+// file means there is only default tenant. Directory of each
+// tenant is created so it could be written by controllers
+func Load(wd string) error {
+	var list []*Tenant
+
+	data, err := ioutil.ReadFile(filePath(wd))
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil {
+		if err = json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("tenant: %w", err)
+		}
+	}
+
+	loaded := map[string]*Tenant{
+		DefaultID: {ID: DefaultID, Name: "Default", Root: wd},
+	}
+
+	seen := make(map[string]bool)
+
+	for _, t := range list {
+		t.ID = strings.ToLower(t.ID)
+
+		if !validID.MatchString(t.ID) {
+			return fmt.Errorf("tenant: invalid id %q", t.ID)
+		}
+
+		if seen[t.ID] {
+			return fmt.Errorf("tenant: duplicate id %q", t.ID)
+		}
+
+		seen[t.ID] = true
+
+		// default tenant keep its root and name when it's not given
+		if t.ID == DefaultID {
+			if t.Name == "" {
+				t.Name = loaded[DefaultID].Name
+			}
+
+			t.Root = wd
+			loaded[DefaultID] = t
+			continue
+		}
+
+		t.Root = filepath.Join(wd, Dir, t.ID)
+
+		for _, dir := range []string{"data/cats", "static/cats"} {
+			if err = os.MkdirAll(t.Path(dir), 0755); err != nil {
+				return err
+			}
+		}
+
+		loaded[t.ID] = t
+	}
+
+	mu.Lock()
+	tenants = loaded
+	mu.Unlock()
+
+	return nil
+}
+
+// Function to get tenant by id
+func Get(id string) (*Tenant, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if t, ok := tenants[strings.ToLower(id)]; ok {
+		return t, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// Function to get default tenant
+func Default() *Tenant {
+	t, err := Get(DefaultID)
+
+	if err != nil {
+		panic("tenant: tenants is not loaded")
+	}
+
+	return t
+}
+
+// Function to get all tenants ordered by id, used by
+// maintenance task that run for each tenant
+func All() []*Tenant {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]*Tenant, 0, len(tenants))
+	for _, t := range tenants {
+		list = append(list, t)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	return list
+}
+
+// Function to find tenant of request host, host could be custom
+// domain of tenant or subdomain of TENANT_DOMAIN env. Host that
+// is not tenant's host is served by default tenant
+func FromHost(host string) (*Tenant, error) {
+	host = strings.ToLower(host)
+
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+
+	mu.RLock()
+	for _, t := range tenants {
+		for _, v := range t.Hosts {
+			if strings.EqualFold(v, host) {
+				mu.RUnlock()
+				return t, nil
+			}
+		}
+	}
+	mu.RUnlock()
+
+	domain := strings.ToLower(strings.Trim(os.Getenv("TENANT_DOMAIN"), "."))
+
+	if domain == "" || !strings.HasSuffix(host, "."+domain) {
+		return Default(), nil
+	}
+
+	// only the first label is the tenant,
+	// "www" is treated as the domain itself
+	sub := strings.TrimSuffix(host, "."+domain)
+
+	if strings.Contains(sub, ".") {
+		return nil, ErrNotFound
+	}
+
+	if sub == "www" {
+		return Default(), nil
+	}
+
+	return Get(sub)
+}
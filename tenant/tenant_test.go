@@ -0,0 +1,165 @@
+package tenant
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Load tenants file with the content into temporary working directory
+func loadTenants(t *testing.T, content string) (string, error) {
+	t.Helper()
+
+	wd := t.TempDir()
+
+	if content != "" {
+		if err := ioutil.WriteFile(filepath.Join(wd, "tenants.json"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return wd, Load(wd)
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantIDs []string
+		wantErr bool
+	}{
+		{"without file", "", []string{DefaultID}, false},
+		{"tenants", `[{"id":"paws"},{"id":"Meow-Club"}]`, []string{DefaultID, "meow-club", "paws"}, false},
+		{"default setting", `[{"id":"default","require_two_factor":true}]`, []string{DefaultID}, false},
+		{"invalid id", `[{"id":"../paws"}]`, nil, true},
+		{"empty id", `[{"id":""}]`, nil, true},
+		{"duplicate id", `[{"id":"paws"},{"id":"PAWS"}]`, nil, true},
+		{"invalid json", `{"id":"paws"}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wd, err := loadTenants(t, tt.content)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			var ids []string
+			for _, v := range All() {
+				ids = append(ids, v.ID)
+			}
+
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("tenants = %v, want %v", ids, tt.wantIDs)
+			}
+
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("tenants = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+
+			if Default().Root != wd {
+				t.Errorf("default root = %q, want working directory %q", Default().Root, wd)
+			}
+		})
+	}
+}
+
+func TestTenantRoot(t *testing.T) {
+	wd, err := loadTenants(t, `[{"id":"default","name":"Main","require_two_factor":true},{"id":"paws","name":"Paws"}]`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	def := Default()
+
+	if def.Name != "Main" || !def.RequireTwoFactor {
+		t.Errorf("default = %+v, want setting of default entry", def)
+	}
+
+	paws, err := Get("PAWS")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// data of each tenant is kept in its own root
+	if got, want := paws.Path("data/cats"), filepath.Join(wd, Dir, "paws", "data/cats"); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+
+	if got, want := def.Path("data/cats"), filepath.Join(wd, "data/cats"); got != want {
+		t.Errorf("Path() of default = %q, want %q", got, want)
+	}
+
+	for _, dir := range []string{"data/cats", "static/cats"} {
+		if info, err := os.Stat(paws.Path(dir)); err != nil || !info.IsDir() {
+			t.Errorf("directory %q is not created", dir)
+		}
+	}
+
+	if got := paws.StaticPath(); got != "/static/tenants/paws/cats/" {
+		t.Errorf("StaticPath() = %q, want tenant path", got)
+	}
+
+	if got := def.StaticPath(); got != "/static/cats/" {
+		t.Errorf("StaticPath() of default = %q, want the old path", got)
+	}
+
+	if _, err = Get("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of unknown = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestFromHost(t *testing.T) {
+	if _, err := loadTenants(t, `[{"id":"paws","hosts":["paws.org","www.paws.org"]},{"id":"meow"}]`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		domain  string
+		host    string
+		wantID  string
+		wantErr error
+	}{
+		{"custom host", "", "paws.org", "paws", nil},
+		{"custom host with port", "", "PAWS.org:8080", "paws", nil},
+		{"other host", "", "example.com", DefaultID, nil},
+		{"subdomain without domain", "", "meow.example.com", DefaultID, nil},
+		{"subdomain", "example.com", "meow.example.com", "meow", nil},
+		{"subdomain with port", "example.com", "meow.example.com:3000", "meow", nil},
+		{"domain itself", "example.com", "example.com", DefaultID, nil},
+		{"www", "example.com", "www.example.com", DefaultID, nil},
+		{"unknown subdomain", "example.com", "other.example.com", "", ErrNotFound},
+		{"nested subdomain", "example.com", "a.meow.example.com", "", ErrNotFound},
+		{"suffix of other domain", "example.com", "meowexample.com", DefaultID, nil},
+		{"ipv6", "example.com", "[::1]:3000", DefaultID, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("TENANT_DOMAIN", tt.domain)
+			defer os.Unsetenv("TENANT_DOMAIN")
+
+			got, err := FromHost(tt.host)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FromHost() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.ID != tt.wantID {
+				t.Errorf("FromHost(%q) = %q, want %q", tt.host, got.ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+// ======================
+// This package is package to validate model using `validate` struct tag.
+// Rules is separated by comma and field name is taken from json tag, ex:
+//
+//	Name string `json:"name" validate:"required,max=50"`
+//
+// Available rules:
+// - required    value must not be zero value
+// - min=N       minimum number, or minimum length for string
+// - max=N       maximum number, or maximum length for string
+// - oneof=a b   value must be one of space separated values
+// - enum=name   value must be one of values registered by RegisterEnum
+// - email       value must be an email address, ex: "tom@example.com"
+// - immutable   value could not be changed once it's created
+// - readonly    value is set by server, it's ignored by Decode
+//
+// Struct field with rules is validated recursively and its violation
+// is named with the parent prefix, ex: "address.postal_code". Rule that
+// could not be written as tag is written by implementing Validator.
+//
+// All violations is returned at once as problem.FieldError
+// so it could be sent to client directly.
+// ======================
+
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ArkjuniorK/store_app/problem"
+)
+
+// Errors type store all violations
+type Errors []*problem.FieldError
+
+// Validator is implemented by struct that has rule depending
+// on other field, its violations is added after the tag rules
+type Validator interface {
+	Validate() Errors
+}
+
+// Function to create violation of field, used by Validator
+func Error(field, format string, args ...interface{}) *problem.FieldError {
+	return &problem.FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// registered enums, keyed by enum name
+var (
+	enums   = make(map[string][]string)
+	enumsMu sync.RWMutex
+)
+
+// Function to register allowed values that could be
+// referenced by "enum=name" rule
+func RegisterEnum(name string, values ...string) {
+	enumsMu.Lock()
+	defer enumsMu.Unlock()
+
+	enums[name] = values
+}
+
+// Function to get allowed values of registered enum
+func Enum(name string) []string {
+	enumsMu.RLock()
+	defer enumsMu.RUnlock()
+
+	return append([]string(nil), enums[name]...)
+}
+
+// Add violation of field
+func (e *Errors) add(field, format string, args ...interface{}) {
+	*e = append(*e, Error(field, format, args...))
+}
+
+// Get the nested struct of field value, time is
+// not counted as nested struct since it's a value
+func nestedOf(v reflect.Value) (interface{}, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct || v.Type() == reflect.TypeOf(time.Time{}) {
+		return nil, false
+	}
+
+	return v.Interface(), true
+}
+
+// Get the json name of struct field, empty name
+// means the field is not exposed as json
+func jsonName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+
+	if tag == "-" {
+		return ""
+	}
+
+	name := strings.Split(tag, ",")[0]
+
+	if name == "" {
+		return f.Name
+	}
+
+	return name
+}
+
+// Get the struct value of pointer
+func structOf(v interface{}) reflect.Value {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		panic("validation: value is not struct")
+	}
+
+	return rv
+}
+
+// Get the rules of struct field as map
+func rulesOf(f reflect.StructField) map[string]string {
+	rules := make(map[string]string)
+
+	for _, v := range strings.Split(f.Tag.Get("validate"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		kv := strings.SplitN(v, "=", 2)
+
+		if len(kv) == 2 {
+			rules[kv[0]] = kv[1]
+		} else {
+			rules[kv[0]] = ""
+		}
+	}
+
+	return rules
+}
+
+// Get the size of value to be compared with min and max,
+// string is measured by its length
+func sizeOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
+
+// Get the unit of size for message
+func unitOf(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return " items"
+	}
+
+	return ""
+}
+
+// Check if value is one of allowed values
+func oneOf(v reflect.Value, allowed []string) bool {
+	s := fmt.Sprint(v.Interface())
+
+	for _, a := range allowed {
+		if s == a {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Function to validate struct using the rules in `validate` tag,
+// zero value that is not required would not be checked
+func Struct(v interface{}) Errors {
+	var (
+		errs Errors
+		rv   = structOf(v)
+		rt   = rv.Type()
+	)
+
+	for i := 0; i < rt.NumField(); i++ {
+		var (
+			f     = rt.Field(i)
+			name  = jsonName(f)
+			value = rv.Field(i)
+			rules = rulesOf(f)
+		)
+
+		if name == "" || len(rules) == 0 {
+			continue
+		}
+
+		if value.IsZero() {
+			if _, ok := rules["required"]; ok {
+				errs.add(name, "is required")
+			}
+
+			continue
+		}
+
+		size, sized := sizeOf(value)
+
+		if min, ok := rules["min"]; ok && sized {
+			if n, err := strconv.ParseFloat(min, 64); err == nil && size < n {
+				errs.add(name, "must be at least %s%s", min, unitOf(value))
+			}
+		}
+
+		if max, ok := rules["max"]; ok && sized {
+			if n, err := strconv.ParseFloat(max, 64); err == nil && size > n {
+				errs.add(name, "must be at most %s%s", max, unitOf(value))
+			}
+		}
+
+		if values, ok := rules["oneof"]; ok {
+			allowed := strings.Fields(values)
+			if !oneOf(value, allowed) {
+				errs.add(name, "must be one of: %s", strings.Join(allowed, ", "))
+			}
+		}
+
+		if enum, ok := rules["enum"]; ok {
+			enumsMu.RLock()
+			allowed := enums[enum]
+			enumsMu.RUnlock()
+
+			if !oneOf(value, allowed) {
+				errs.add(name, "must be one of: %s", strings.Join(allowed, ", "))
+			}
+		}
+
+		if _, ok := rules["email"]; ok && value.Kind() == reflect.String {
+			if a, err := mail.ParseAddress(value.String()); err != nil || a.Address != value.String() {
+				errs.add(name, "must be an email address")
+			}
+		}
+
+		// validate nested struct with its own rules
+		if nested, ok := nestedOf(value); ok {
+			for _, e := range Struct(nested) {
+				errs.add(name+"."+e.Field, "%s", e.Message)
+			}
+		}
+	}
+
+	if validator, ok := v.(Validator); ok {
+		errs = append(errs, validator.Validate()...)
+	}
+
+	return errs
+}
+
+// Check if two values is equal, time is compared
+// by its instant instead of its location
+func equal(a, b reflect.Value) bool {
+	if t, ok := a.Interface().(time.Time); ok {
+		return t.Equal(b.Interface().(time.Time))
+	}
+
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// Function to check that field with immutable rule
+// is not changed between old and new value
+func Immutable(old, new interface{}) Errors {
+	var (
+		errs Errors
+		ov   = structOf(old)
+		nv   = structOf(new)
+		rt   = ov.Type()
+	)
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+
+		if _, ok := rulesOf(f)["immutable"]; !ok {
+			continue
+		}
+
+		if !equal(ov.Field(i), nv.Field(i)) {
+			errs.add(jsonName(f), "is immutable")
+		}
+	}
+
+	return errs
+}
+
+// Describe json type of go type for message, type
+// with its own json format is described by its name
+func jsonType(t reflect.Type) string {
+	// pointer is decoded as its element or null
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return "a valid " + strings.ToLower(t.Name())
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+
+	return "a valid " + strings.ToLower(t.Name())
+}
+
+// Function to decode each json field into the struct field
+// with the same json name. Unknown field and field with
+// mismatched type is returned as violation instead of stopping
+func Decode(dst interface{}, fields map[string]json.RawMessage) Errors {
+	var (
+		errs   Errors
+		rv     = structOf(dst)
+		rt     = rv.Type()
+		byName = make(map[string]int, rt.NumField())
+	)
+
+	for i := 0; i < rt.NumField(); i++ {
+		if name := jsonName(rt.Field(i)); name != "" {
+			byName[name] = i
+		}
+	}
+
+	for name, raw := range fields {
+		i, ok := byName[name]
+
+		if !ok {
+			errs.add(name, "is not a known field")
+			continue
+		}
+
+		// field that is set by server is ignored so the
+		// object sent by server could be sent back as it is
+		if _, ok := rulesOf(rt.Field(i))["readonly"]; ok {
+			continue
+		}
+
+		// decode to new value first so the field
+		// would not be changed partially on error
+		value := reflect.New(rt.Field(i).Type)
+
+		if err := decodeField(raw, value.Interface()); err != nil {
+			errs.add(name, "must be %s", jsonType(rt.Field(i).Type))
+			continue
+		}
+
+		rv.Field(i).Set(value.Elem())
+	}
+
+	// keep violations in the same order
+	// since map iteration is random
+	errs.sort()
+
+	return errs
+}
+
+// Decode json value of one field. Unmarshaler of some type, ex: xid.ID,
+// panic on value of other type so the panic is returned as error
+func decodeField(raw json.RawMessage, v interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid value: %v", r)
+		}
+	}()
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+
+	return dec.Decode(v)
+}
+
+// Sort violations by field name
+func (e Errors) sort() {
+	sort.SliceStable(e, func(i, j int) bool {
+		return e[i].Field < e[j].Field
+	})
+}
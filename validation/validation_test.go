@@ -0,0 +1,244 @@
+package validation
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+func init() {
+	RegisterEnum("test_color", "black", "white")
+}
+
+type testAddress struct {
+	City       string `json:"city" validate:"required"`
+	PostalCode string `json:"postal_code" validate:"max=5"`
+}
+
+type testCat struct {
+	ID       string       `json:"id" validate:"immutable"`
+	Name     string       `json:"name" validate:"required,min=2,max=5"`
+	Gender   string       `json:"gender" validate:"oneof=male female"`
+	Color    string       `json:"color" validate:"enum=test_color"`
+	Email    string       `json:"email" validate:"email"`
+	Weight   float64      `json:"weight" validate:"min=0.5,max=20"`
+	Tags     []string     `json:"tags" validate:"max=2"`
+	Address  *testAddress `json:"address"`
+	Owner    *testAddress `json:"owner" validate:"immutable"`
+	Home     testAddress  `json:"home" validate:"required"`
+	Create   time.Time    `json:"created_at" validate:"immutable"`
+	Status   string       `json:"status" validate:"readonly"`
+	Internal string       `json:"-" validate:"required"`
+	Note     string       `json:"note"`
+}
+
+// Validator of test cat, female cat could not be heavier than 10 kg
+func (c *testCat) Validate() Errors {
+	if c.Gender == "female" && c.Weight > 10 {
+		return Errors{Error("weight", "must be at most %d for female", 10)}
+	}
+
+	return nil
+}
+
+// Create valid test cat
+func validCat() *testCat {
+	return &testCat{
+		Name:   "Tom",
+		Gender: "male",
+		Color:  "black",
+		Email:  "tom@example.com",
+		Weight: 4,
+		Home:   testAddress{City: "Boston", PostalCode: "02108"},
+	}
+}
+
+// Format violations as "field: message" to be compared
+func messages(errs Errors) []string {
+	list := make([]string, len(errs))
+	for i, e := range errs {
+		list[i] = e.Field + ": " + e.Message
+	}
+
+	return list
+}
+
+// Compare list of string
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *testCat)
+		want   []string
+	}{
+		{"valid", func(c *testCat) {}, nil},
+		{"missing required", func(c *testCat) { c.Name = "" }, []string{"name: is required"}},
+		{"too short", func(c *testCat) { c.Name = "T" }, []string{"name: must be at least 2 characters"}},
+		{"length is counted by rune", func(c *testCat) { c.Name = "Tömmy" }, nil},
+		{"too long", func(c *testCat) { c.Name = "Tommy Lee" }, []string{"name: must be at most 5 characters"}},
+		{"too light", func(c *testCat) { c.Weight = 0.2 }, []string{"weight: must be at least 0.5"}},
+		{"too many items", func(c *testCat) { c.Tags = []string{"a", "b", "c"} }, []string{"tags: must be at most 2 items"}},
+		{"not one of", func(c *testCat) { c.Gender = "other" }, []string{"gender: must be one of: male, female"}},
+		{"not in enum", func(c *testCat) { c.Color = "orange" }, []string{"color: must be one of: black, white"}},
+		{"invalid email", func(c *testCat) { c.Email = "Tom <tom@example.com>" }, []string{"email: must be an email address"}},
+		{"optional zero value", func(c *testCat) { c.Gender, c.Color, c.Email, c.Weight = "", "", "", 0 }, nil},
+		{"nested struct", func(c *testCat) { c.Home.City = "" }, []string{"home.city: is required"}},
+		{"nested pointer", func(c *testCat) { c.Owner = &testAddress{PostalCode: "123456"} }, []string{"owner.city: is required", "owner.postal_code: must be at most 5 characters"}},
+		{"nested without rules", func(c *testCat) { c.Address = &testAddress{} }, nil},
+		{"validator", func(c *testCat) { c.Gender, c.Weight = "female", 12 }, []string{"weight: must be at most 10 for female"}},
+		{"all violations", func(c *testCat) { c.Name, c.Gender = "", "other" }, []string{"name: is required", "gender: must be one of: male, female"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validCat()
+			tt.change(c)
+
+			if got := messages(Struct(c)); !equalStrings(got, tt.want) {
+				t.Errorf("Struct() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImmutable(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		change func(c *testCat)
+		want   []string
+	}{
+		{"unchanged", func(c *testCat) {}, nil},
+		{"mutable field", func(c *testCat) { c.Name = "Max" }, nil},
+		{"changed id", func(c *testCat) { c.ID = "other" }, []string{"id: is immutable"}},
+		{"same instant other location", func(c *testCat) { c.Create = now.In(time.FixedZone("UTC+7", 7*3600)) }, nil},
+		{"changed time", func(c *testCat) { c.Create = now.Add(time.Second) }, []string{"created_at: is immutable"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := validCat()
+			old.ID, old.Create = "cat", now
+
+			c := *old
+			tt.change(&c)
+
+			if got := messages(Immutable(old, &c)); !equalStrings(got, tt.want) {
+				t.Errorf("Immutable() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		want     []string
+		wantName string
+	}{
+		{"known fields", `{"name":"Max","weight":3.5}`, nil, "Max"},
+		{"unknown field", `{"name":"Max","age":3}`, []string{"age: is not a known field"}, "Max"},
+		{"mismatched type", `{"name":1}`, []string{"name: must be a string"}, "Tom"},
+		{"mismatched number", `{"weight":"heavy"}`, []string{"weight: must be a number"}, "Tom"},
+		{"mismatched array", `{"tags":"a"}`, []string{"tags: must be an array"}, "Tom"},
+		{"mismatched time", `{"created_at":"yesterday"}`, []string{"created_at: must be a valid time"}, "Tom"},
+		{"unknown nested field", `{"home":{"city":"Paris","zip":"1"}}`, []string{"home: must be a valid testaddress"}, "Tom"},
+		{"readonly ignored", `{"status":"adopted"}`, nil, "Tom"},
+		{"hidden field", `{"Internal":"x"}`, []string{"Internal: is not a known field"}, "Tom"},
+		{"sorted violations", `{"zzz":1,"aaa":1,"name":true}`, []string{"aaa: is not a known field", "name: must be a string", "zzz: is not a known field"}, "Tom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields map[string]json.RawMessage
+
+			if err := json.Unmarshal([]byte(tt.body), &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			c := validCat()
+
+			if got := messages(Decode(c, fields)); !equalStrings(got, tt.want) {
+				t.Errorf("Decode() = %q, want %q", got, tt.want)
+			}
+
+			if c.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", c.Name, tt.wantName)
+			}
+
+			if c.Status != "" {
+				t.Errorf("status = %q, want readonly field unchanged", c.Status)
+			}
+		})
+	}
+}
+
+func TestDecodeID(t *testing.T) {
+	type testApplication struct {
+		Cat      xid.ID   `json:"cat_id"`
+		Shelter  *xid.ID  `json:"shelter_id"`
+		Shelters []xid.ID `json:"shelter_ids"`
+	}
+
+	id := xid.New()
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"valid ids", `{"cat_id":"` + id.String() + `","shelter_id":"` + id.String() + `","shelter_ids":["` + id.String() + `"]}`, nil},
+		{"null ids", `{"cat_id":null,"shelter_id":null,"shelter_ids":null}`, nil},
+		{"one digit number", `{"cat_id":1}`, []string{"cat_id: must be a valid id"}},
+		{"number", `{"cat_id":12}`, []string{"cat_id: must be a valid id"}},
+		{"boolean", `{"cat_id":true}`, []string{"cat_id: must be a valid id"}},
+		{"object", `{"cat_id":{}}`, []string{"cat_id: must be a valid id"}},
+		{"invalid string", `{"cat_id":"cat"}`, []string{"cat_id: must be a valid id"}},
+		{"pointer number", `{"shelter_id":1}`, []string{"shelter_id: must be a valid id"}},
+		{"number in array", `{"shelter_ids":[1]}`, []string{"shelter_ids: must be an array"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields map[string]json.RawMessage
+
+			if err := json.Unmarshal([]byte(tt.body), &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := messages(Decode(&testApplication{}, fields)); !equalStrings(got, tt.want) {
+				t.Errorf("Decode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnum(t *testing.T) {
+	values := Enum("test_color")
+	values[0] = "changed"
+
+	if got := Enum("test_color"); !equalStrings(got, []string{"black", "white"}) {
+		t.Errorf("Enum() = %q, want registered values unchanged", got)
+	}
+
+	if got := Enum("unknown"); len(got) != 0 {
+		t.Errorf("Enum() of unknown = %q, want none", got)
+	}
+}
@@ -0,0 +1,5 @@
+// vue.config.js
+module.exports = {
+  // build to web/dist so it could be embedded to the go binary
+  outputDir: "../web/dist",
+};
@@ -0,0 +1,15 @@
+//go:build embed
+// +build embed
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed dist
+var files embed.FS
+
+// built Vue app, rooted at "dist"
+var dist, _ = fs.Sub(files, "dist")
@@ -0,0 +1,101 @@
+// ==================
+// Entry file that would be manage the route for the Vue app.
+// The built app inside "web/dist" is embedded to the binary when it's
+// built with "embed" tag, ex:
+//
+//	cd view && yarn build && cd .. && go build -tags embed
+//
+// When APP_ENV is "dev" request is proxied to Vue dev server instead
+// so the app could be developed with hot reload.
+// ==================
+
+package web
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+
+	"github.com/ArkjuniorK/store_app/static"
+)
+
+// default address of Vue dev server,
+// could be changed using VUE_DEV_SERVER env
+const devServer = "http://localhost:8080"
+
+// asset built by Vue CLI have content hash inside the name,
+// ex: "js/app.3f2a1b4c.js" so it could be cached forever
+var hashedAsset = regexp.MustCompile(`\.[0-9a-f]{8,}\.[a-z0-9]+$`)
+
+// struct to hold function for serving the Vue app
+type Entry struct{}
+
+// Routes would return router that serve the Vue app
+func (e Entry) Routes() chi.Router {
+	// init new chi router
+	r := chi.NewRouter()
+
+	// proxy to dev server on dev mode
+	if os.Getenv("APP_ENV") == "dev" {
+		r.Handle("/*", proxy())
+		return r
+	}
+
+	// app is not embedded
+	if dist == nil {
+		r.Get("/*", func(w http.ResponseWriter, r *http.Request) {
+			render.PlainText(w, r, "Vue app is not embedded, build it with embed tag")
+		})
+		return r
+	}
+
+	fs := static.NewFileServer(dist)
+
+	fs.Immutable = func(name string) bool {
+		return hashedAsset.MatchString(name)
+	}
+
+	// index.html is checked once, serving it when it's missing
+	// would call NotFound again and never stop
+	index := fs.Exists("index.html")
+
+	// history mode fallback, route that is handled by
+	// vue-router is served by index.html
+	fs.NotFound = func(w http.ResponseWriter, r *http.Request) {
+		// missing asset is still not found
+		if !index || path.Ext(r.URL.Path) != "" {
+			static.NotFound(w, r)
+			return
+		}
+
+		fs.ServeFile(w, r, "index.html")
+	}
+
+	r.Get("/*", fs.ServeHTTP)
+	r.Head("/*", fs.ServeHTTP)
+
+	return r
+}
+
+// Create reverse proxy to Vue dev server
+func proxy() http.Handler {
+	addr := os.Getenv("VUE_DEV_SERVER")
+
+	if addr == "" {
+		addr = devServer
+	}
+
+	target, err := url.Parse(addr)
+
+	if err != nil {
+		panic("invalid VUE_DEV_SERVER: " + err.Error())
+	}
+
+	return httputil.NewSingleHostReverseProxy(target)
+}
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// Serve request using router of the built app
+func serve(app fs.FS, method, target string) *httptest.ResponseRecorder {
+	dist = app
+
+	w := httptest.NewRecorder()
+	Entry{}.Routes().ServeHTTP(w, httptest.NewRequest(method, target, nil))
+
+	return w
+}
+
+func TestRoutes(t *testing.T) {
+	os.Unsetenv("APP_ENV")
+
+	original := dist
+	defer func() { dist = original }()
+
+	built := fstest.MapFS{
+		"index.html":          {Data: []byte("<div id=app>")},
+		"favicon.ico":         {Data: []byte("icon")},
+		"js/app.3f2a1b4c.js":  {Data: []byte("app")},
+		"js/chunk-vendors.js": {Data: []byte("vendors")},
+	}
+
+	withoutIndex := fstest.MapFS{
+		"js/app.3f2a1b4c.js": {Data: []byte("app")},
+	}
+
+	tests := []struct {
+		name      string
+		app       fs.FS
+		method    string
+		target    string
+		wantCode  int
+		wantBody  string
+		wantCache string
+	}{
+		{"index", built, http.MethodGet, "/", http.StatusOK, "<div id=app>", "public, no-cache"},
+		{"history route", built, http.MethodGet, "/cats/123", http.StatusOK, "<div id=app>", "public, no-cache"},
+		{"nested history route", built, http.MethodGet, "/shelters/1/cats/edit", http.StatusOK, "<div id=app>", "public, no-cache"},
+		{"head history route", built, http.MethodHead, "/cats", http.StatusOK, "", "public, no-cache"},
+		{"hashed asset", built, http.MethodGet, "/js/app.3f2a1b4c.js", http.StatusOK, "app", "public, max-age=31536000, immutable"},
+		{"asset without hash", built, http.MethodGet, "/js/chunk-vendors.js", http.StatusOK, "vendors", "public, no-cache"},
+		{"missing asset", built, http.MethodGet, "/js/missing.3f2a1b4c.js", http.StatusNotFound, "", ""},
+		{"missing file with extension", built, http.MethodGet, "/robots.txt", http.StatusNotFound, "", ""},
+		// fallback without index.html must not call itself again
+		{"missing index route", withoutIndex, http.MethodGet, "/cats/123", http.StatusNotFound, "", ""},
+		{"missing index root", withoutIndex, http.MethodGet, "/", http.StatusNotFound, "", ""},
+		{"asset without index", withoutIndex, http.MethodGet, "/js/app.3f2a1b4c.js", http.StatusOK, "app", "public, max-age=31536000, immutable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.app, tt.method, tt.target)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+
+			if tt.wantCode == http.StatusNotFound {
+				if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/problem+json") {
+					t.Errorf("Content-Type = %q, want problem JSON", got)
+				}
+				return
+			}
+
+			if tt.method == http.MethodGet && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+
+			if got := w.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCache)
+			}
+		})
+	}
+}
+
+func TestRoutesNotEmbedded(t *testing.T) {
+	os.Unsetenv("APP_ENV")
+
+	original := dist
+	defer func() { dist = original }()
+
+	w := serve(nil, http.MethodGet, "/cats")
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "not embedded") {
+		t.Errorf("response = %d %q, want message of app that is not embedded", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !embed
+// +build !embed
+
+package web
+
+import "io/fs"
+
+// Vue app is not embedded
+var dist fs.FS